@@ -2,6 +2,7 @@ package build
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"strings"
 	"time"
@@ -9,6 +10,8 @@ import (
 	"github.com/docker/cli/cli/config"
 	"github.com/docker/cli/cli/config/configfile"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/go-units"
+	composeTypes "github.com/kelda/compose-go/types"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
@@ -19,8 +22,11 @@ import (
 	"github.com/kelda/blimp/pkg/build"
 	"github.com/kelda/blimp/pkg/build/buildkit"
 	"github.com/kelda/blimp/pkg/build/docker"
+	"github.com/kelda/blimp/pkg/build/local"
+	"github.com/kelda/blimp/pkg/cfgdir"
 	"github.com/kelda/blimp/pkg/dockercompose"
 	"github.com/kelda/blimp/pkg/errors"
+	"github.com/kelda/blimp/pkg/lockfile"
 	protoAuth "github.com/kelda/blimp/pkg/proto/auth"
 	"github.com/kelda/blimp/pkg/proto/cluster"
 	"github.com/kelda/blimp/pkg/proto/node"
@@ -29,9 +35,15 @@ import (
 
 func New() *cobra.Command {
 	var composePaths []string
+	var profiles []string
 	var pull bool
 	var noCache bool
 	var forceBuildkit bool
+	var prune bool
+	var parallel int
+	var keepGoing bool
+	var lock bool
+	var progressMode string
 	cobraCmd := &cobra.Command{
 		Use:   "build [OPTIONS] [SERVICE...]",
 		Short: "Build or rebuild services.",
@@ -44,6 +56,22 @@ func New() *cobra.Command {
 				errors.HandleFatalError(err)
 			}
 
+			mode, err := build.ParseProgressMode(progressMode)
+			if err != nil {
+				errors.HandleFatalError(err)
+			}
+			// The interactive displays can't show multiple builds at once.
+			if parallel > 1 && mode == build.ProgressAuto {
+				mode = build.ProgressPlain
+			}
+			progress := build.NewProgress(mode, os.Stdout, cfgdir.BuildLogDir())
+			defer progress.Close()
+
+			if prune {
+				pruneBuildCache(blimpConfig.BlimpAuth(), progress)
+				return
+			}
+
 			dockerConfig, err := config.Load(config.Dir())
 			if err != nil {
 				log.WithError(err).Fatal("Failed to load docker config")
@@ -83,16 +111,11 @@ func New() *cobra.Command {
 				log.WithError(err).Fatal("Failed to get absolute path to Compose file")
 			}
 
-			parsedCompose, err := dockercompose.Load(composePath, overridePaths, services)
+			parsedCompose, err := dockercompose.Load(composePath, overridePaths, services, profiles)
 			if err != nil {
 				log.WithError(err).Fatal("Failed to load compose file")
 			}
 
-			builder, err := getImageBuilder(regCreds, dockerConfig, blimpConfig.BlimpAuth(), forceBuildkit)
-			if err != nil {
-				log.WithError(err).Fatal("Get image builder")
-			}
-
 			buildOpts := map[string]build.BuildPushConfig{}
 			for _, svc := range parsedCompose.Services {
 				if svc.Build == nil {
@@ -100,62 +123,191 @@ func New() *cobra.Command {
 					continue
 				}
 
-				imageName := build.RemoteImageName(composePath, svc.Name, imageNamespace)
+				secrets, err := dockercompose.ReadBuildSecrets(parsedCompose, svc)
+				if err != nil {
+					log.WithError(err).Fatal("Failed to read build secrets")
+				}
+
+				digest, err := build.Digest(*svc.Build)
+				if err != nil {
+					log.WithError(err).WithField("service", svc.Name).Fatal("Failed to compute build digest")
+				}
+
+				imageName := build.RemoteImageName(svc.Name, imageNamespace, digest)
+				cacheImports, cacheExport := build.CacheRepos(svc.Name, imageNamespace,
+					blimpConfig.ConfigFile.TeamBuildCache, blimpConfig.ConfigFile.ExportTeamBuildCache)
 				buildOpts[svc.Name] = build.BuildPushConfig{
-					BuildConfig: *svc.Build,
-					ImageName:   imageName,
-					PullParent:  pull,
-					NoCache:     noCache,
-					ForceBuild:  true,
+					BuildConfig:  *svc.Build,
+					ImageName:    imageName,
+					PullParent:   pull,
+					NoCache:      noCache,
+					ForceBuild:   true,
+					SSH:          dockercompose.BuildSSH(svc),
+					Secrets:      secrets,
+					CacheImports: cacheImports,
+					CacheExport:  cacheExport,
 				}
 			}
 
-			_, err = builder.BuildAndPush(buildOpts)
+			builderSetting, err := build.ParseBuilder(blimpConfig.ConfigFile.Builder)
 			if err != nil {
+				errors.HandleFatalError(err)
+			}
+			if forceBuildkit {
+				builderSetting = build.BuilderRemote
+			}
+
+			// Docker and Podman don't support forwarding SSH agents or
+			// secrets, so build with BuildKit if any services need them.
+			builder, err := getImageBuilder(regCreds, dockerConfig, blimpConfig.BlimpAuth(), progress,
+				builderSetting, build.RequiresBuildkit(buildOpts))
+			if err != nil {
+				errors.HandleFatalError(errors.WithContext("get image builder", err))
+			}
+
+			results, err := builder.BuildAndPush(buildOpts, build.ScheduleOptions{
+				Parallelism: parallel,
+				KeepGoing:   keepGoing,
+			})
+			if err != nil {
+				log.WithError(err).Warn("Failed to build services")
+				return
+			}
+
+			progress.PrintResults(results, keepGoing)
+			if err := results.Err(); err != nil && !keepGoing {
 				log.WithError(err).Warn("Failed to build services")
 			}
+
+			if lock {
+				if len(results.Failed()) != 0 {
+					log.Fatalf("Not updating %s since some services failed to build", lockfile.Filename)
+				}
+
+				// Publish the built images to the team build cache so that
+				// teammates and CI can run them. Otherwise, they're only
+				// accessible from the user's sandbox.
+				var publishRepo string
+				if blimpConfig.ConfigFile.ExportTeamBuildCache {
+					publishRepo = blimpConfig.ConfigFile.TeamBuildCache
+				}
+				err := writeLockfile(lockfile.Path(composePath), parsedCompose, results.Images(), publishRepo,
+					regCreds, progress)
+				if err != nil {
+					errors.HandleFatalError(err)
+				}
+			}
 		},
 	}
 	cobraCmd.Flags().StringSliceVarP(&composePaths, "file", "f", nil,
 		"Specify an alternate compose file\nDefaults to docker-compose.yml and docker-compose.yaml")
+	cobraCmd.Flags().StringSliceVarP(&profiles, "profile", "", nil,
+		"Specify a profile to enable\nDefaults to the profiles in COMPOSE_PROFILES")
 	cobraCmd.Flags().BoolVarP(&pull, "pull", "", false,
 		"Always attempt to pull a newer version of the image.")
 	cobraCmd.Flags().BoolVarP(&noCache, "no-cache", "", false,
 		"Do not use cache when building the image")
 	cobraCmd.Flags().BoolVarP(&forceBuildkit, "remote-build", "", false,
 		"Force Docker images to be built in your sandbox instead of locally")
+	cobraCmd.Flags().IntVarP(&parallel, "parallel", "", 1,
+		"Build up to this many images at once")
+	cobraCmd.Flags().BoolVarP(&keepGoing, "keep-going", "", false,
+		"Continue building the remaining services after a build fails")
+	cobraCmd.Flags().BoolVarP(&lock, "lock", "", false,
+		"Record the digests of the built and pulled images in blimp.lock, "+
+			"so that `blimp up --locked` runs the same images\n"+
+			"Built images are copied to the team build cache if export_team_build_cache is set")
+	cobraCmd.Flags().BoolVarP(&prune, "prune", "", false,
+		"Clear the build cache of the image builder in your sandbox, rather than building")
+	cobraCmd.Flags().StringVarP(&progressMode, "progress", "", string(build.ProgressAuto),
+		"Set the type of build progress output (auto, plain, json)\n"+
+			"Build logs are also saved in ~/.blimp/build-logs")
 	return cobraCmd
 }
 
-func getImageBuilder(regCreds auth.RegistryCredentials, dockerConfig *configfile.ConfigFile, auth *protoAuth.BlimpAuth, forceBuildkit bool) (build.Interface, error) {
-	if !forceBuildkit {
-		dockerClient, err := docker.New(regCreds, dockerConfig, auth, docker.CacheOptions{})
+// pruneBuildCache deletes the build cache of the buildkitd instance in the
+// user's sandbox. The cache is otherwise persisted across `blimp down`s.
+func pruneBuildCache(blimpAuth *protoAuth.BlimpAuth, progress *build.Progress) {
+	buildkitClient, err := getBuildkitClient(auth.RegistryCredentials{}, blimpAuth, progress)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to connect to remote image builder")
+	}
+
+	reclaimed, err := buildkitClient.Prune()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to prune build cache")
+	}
+	progress.Printf("", "Cleared the build cache. Reclaimed %s.", units.HumanSize(float64(reclaimed)))
+}
+
+// writeLockfile records the built images, and the current digests of the
+// services' pulled images, in the lockfile.
+func writeLockfile(path string, cfg composeTypes.Project, builtImages map[string]string, publishRepo string,
+	regCreds auth.RegistryCredentials, progress *build.Progress) error {
+	lock, err := lockfile.ReadIfExists(path)
+	if err != nil {
+		return err
+	}
+
+	changes, err := lock.Update(cfg, builtImages, publishRepo, regCreds)
+	if err != nil {
+		return errors.WithContext("update lockfile", err)
+	}
+
+	if err := lock.Write(path); err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		progress.Printf("", "%s", change)
+	}
+	progress.Printf("", "Wrote %s", path)
+	return nil
+}
+
+func getImageBuilder(regCreds auth.RegistryCredentials, dockerConfig *configfile.ConfigFile, auth *protoAuth.BlimpAuth,
+	progress *build.Progress, builder build.Builder, requireBuildkit bool) (build.Interface, error) {
+	if builder != build.BuilderRemote {
+		localBuilder, err := local.New(builder, requireBuildkit, regCreds, dockerConfig, auth, progress,
+			docker.CacheOptions{})
 		if err == nil {
-			return dockerClient, nil
+			return localBuilder, nil
 		}
-		log.WithError(err).Debug("Failed to get Docker client for local builder. " +
+		if builder != build.BuilderAuto {
+			return nil, errors.WithContext(fmt.Sprintf("create %s image builder", builder), err)
+		}
+		log.WithError(err).Debug("Failed to get local image builder. " +
 			"Falling back to building remotely with buildkit")
 	}
 
+	buildkitClient, err := getBuildkitClient(regCreds, auth, progress)
+	if err != nil {
+		return nil, err
+	}
+	return buildkitClient, nil
+}
+
+func getBuildkitClient(regCreds auth.RegistryCredentials, auth *protoAuth.BlimpAuth,
+	progress *build.Progress) (buildkit.Client, error) {
 	// Get a connection to the remote buildkit container.
-	pp := util.NewProgressPrinter(os.Stdout, "Booting remote Docker image builder")
+	pp := util.NewProgressPrinter(progress.StatusOutput(), "Booting remote Docker image builder")
 	go pp.Run()
 	ctx, _ := context.WithTimeout(context.Background(), 3*time.Minute)
 	buildkitConn, err := manager.C.GetBuildkit(ctx, &cluster.GetBuildkitRequest{Auth: auth})
 	pp.Stop()
 	if err != nil {
-		return nil, errors.WithContext("boot buildkit", err)
+		return buildkit.Client{}, errors.WithContext("boot buildkit", err)
 	}
 
 	nodeConn, err := util.Dial(buildkitConn.NodeAddress, buildkitConn.NodeCert, "")
 	if err != nil {
-		return nil, errors.WithContext("connect to node controller", err)
+		return buildkit.Client{}, errors.WithContext("connect to node controller", err)
 	}
 	tunnelManager := tunnel.NewManager(node.NewControllerClient(nodeConn), auth)
 
-	buildkitClient, err := buildkit.New(tunnelManager, regCreds)
+	buildkitClient, err := buildkit.New(tunnelManager, regCreds, progress)
 	if err != nil {
-		return nil, errors.WithContext("create buildkit image builder", err)
+		return buildkit.Client{}, errors.WithContext("create buildkit image builder", err)
 	}
 	return buildkitClient, nil
 }
@@ -14,7 +14,6 @@ import (
 	"github.com/kelda/blimp/cli/cp/kubectlcp"
 	"github.com/kelda/blimp/cli/manager"
 	"github.com/kelda/blimp/pkg/errors"
-	"github.com/kelda/blimp/pkg/names"
 	"github.com/kelda/blimp/pkg/proto/auth"
 )
 
@@ -86,36 +85,42 @@ func run(src, dst string) error {
 	}
 
 	if len(srcSpec.PodName) != 0 {
-		translatedSrcSpec, err := translateSpec(srcSpec, blimpConfig.BlimpAuth())
+		translatedSrcSpec, container, err := translateSpec(srcSpec, blimpConfig.BlimpAuth())
 		if err != nil {
 			return err
 		}
+		o.Container = container
 		return o.CopyFromPod(translatedSrcSpec, destSpec)
 	}
 	if len(destSpec.PodName) != 0 {
-		translatedDestSpec, err := translateSpec(destSpec, blimpConfig.BlimpAuth())
+		translatedDestSpec, container, err := translateSpec(destSpec, blimpConfig.BlimpAuth())
 		if err != nil {
 			return err
 		}
+		o.Container = container
 		return o.CopyToPod(srcSpec, translatedDestSpec, &exec.ExecOptions{})
 	}
 	return errors.NewFriendlyError(
 		"One of src or dest must be a remote file specification.")
 }
 
-func translateSpec(fileSpec kubectlcp.FileSpec, auth *auth.BlimpAuth) (kubectlcp.FileSpec, error) {
+// translateSpec converts a file specification that refers to a service into
+// one that refers to the service's pod. It also returns the name of the
+// service's container within the pod.
+func translateSpec(fileSpec kubectlcp.FileSpec, auth *auth.BlimpAuth) (kubectlcp.FileSpec, string, error) {
 	if len(fileSpec.PodNamespace) != 0 {
-		return kubectlcp.FileSpec{}, errors.NewFriendlyError(
+		return kubectlcp.FileSpec{}, "", errors.NewFriendlyError(
 			"Specifying the remote namespace is not allowed.")
 	}
 
-	err := manager.CheckServiceRunning(fileSpec.PodName, auth)
+	svcStatus, err := manager.CheckServiceRunning(fileSpec.PodName, auth)
 	if err != nil {
-		return kubectlcp.FileSpec{}, err
+		return kubectlcp.FileSpec{}, "", err
 	}
 
+	podName, containerName := manager.ServiceContainer(fileSpec.PodName, svcStatus)
 	return kubectlcp.FileSpec{
-		PodName: names.ToDNS1123(fileSpec.PodName),
+		PodName: podName,
 		File:    fileSpec.File,
-	}, nil
+	}, containerName, nil
 }
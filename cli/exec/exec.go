@@ -13,7 +13,6 @@ import (
 	"github.com/kelda/blimp/cli/config"
 	"github.com/kelda/blimp/cli/manager"
 	"github.com/kelda/blimp/pkg/errors"
-	"github.com/kelda/blimp/pkg/names"
 )
 
 func New() *cobra.Command {
@@ -49,10 +48,11 @@ func run(svc, cmd string, cmdArguments []string, enableTTY bool) error {
 	}
 
 	// Make sure the pod is actually booted.
-	err = manager.CheckServiceRunning(svc, blimpConfig.BlimpAuth())
+	svcStatus, err := manager.CheckServiceRunning(svc, blimpConfig.BlimpAuth())
 	if err != nil {
 		return err
 	}
+	podName, containerName := manager.ServiceContainer(svc, svcStatus)
 
 	kubeClient, restConfig, err := blimpConfig.Auth.KubeClient()
 	if err != nil {
@@ -73,11 +73,12 @@ func run(svc, cmd string, cmdArguments []string, enableTTY bool) error {
 	}
 
 	execOpts := core.PodExecOptions{
-		Command: append([]string{cmd}, cmdArguments...),
-		Stdin:   true,
-		Stdout:  true,
-		Stderr:  true,
-		TTY:     tty,
+		Container: containerName,
+		Command:   append([]string{cmd}, cmdArguments...),
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       tty,
 	}
 	streamOpts := remotecommand.StreamOptions{
 		Stdin:  os.Stdin,
@@ -89,7 +90,7 @@ func run(svc, cmd string, cmdArguments []string, enableTTY bool) error {
 	req := kubeClient.CoreV1().RESTClient().Post().
 		Resource("pods").
 		SubResource("exec").
-		Name(names.ToDNS1123(svc)).
+		Name(podName).
 		Namespace(blimpConfig.Auth.KubeNamespace).
 		VersionedParams(&execOpts, scheme.ParameterCodec)
 	exec, err := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
@@ -24,7 +24,6 @@ import (
 	"github.com/kelda/blimp/cli/config"
 	"github.com/kelda/blimp/cli/manager"
 	"github.com/kelda/blimp/pkg/errors"
-	"github.com/kelda/blimp/pkg/names"
 )
 
 type Command struct {
@@ -32,7 +31,17 @@ type Command struct {
 	Opts     corev1.PodLogOptions
 	Config   config.Config
 
+	// Out is where the logs are printed. It defaults to stdout.
+	Out io.Writer
+
 	svcStatus map[string]*statusNotifier
+
+	// containers maps each service to the pod and container that it runs in.
+	containers map[string]serviceContainer
+}
+
+type serviceContainer struct {
+	pod, container string
 }
 
 type rawLogLine struct {
@@ -108,13 +117,17 @@ func (cmd Command) Run(ctx context.Context) error {
 		return errors.WithContext("connect to cluster", err)
 	}
 
-	for _, container := range cmd.Services {
+	cmd.containers = map[string]serviceContainer{}
+	for _, service := range cmd.Services {
 		// For logs to work, the container needs to have started, but it doesn't
 		// necessarily need to be running.
-		err = manager.CheckServiceStarted(container, cmd.Config.BlimpAuth())
+		svcStatus, err := manager.CheckServiceStarted(service, cmd.Config.BlimpAuth())
 		if err != nil {
 			return err
 		}
+
+		pod, container := manager.ServiceContainer(service, svcStatus)
+		cmd.containers[service] = serviceContainer{pod: pod, container: container}
 	}
 
 	// Exit gracefully when the user Ctrl-C's.
@@ -204,8 +217,12 @@ func (cmd Command) Run(ctx context.Context) error {
 		cancel()
 	}()
 
+	out := cmd.Out
+	if out == nil {
+		out = os.Stdout
+	}
 	hideServiceName := len(cmd.Services) == 1
-	return printLogs(ctx, combinedLogs, hideServiceName)
+	return printLogs(ctx, out, combinedLogs, hideServiceName)
 }
 
 // forwardLogs forwards each log line from `logsReq` to the `combinedLogs`
@@ -246,6 +263,7 @@ func (cmd *Command) forwardLogs(ctx context.Context, combinedLogs chan<- rawLogL
 
 	for {
 		opts := cmd.Opts
+		opts.Container = cmd.containers[service].container
 		// Enable timestamps so that `forwardLogs` can parse the logs.
 		opts.Timestamps = true
 		// If we are reconnecting, set SinceTime so we don't double-print logs.
@@ -260,7 +278,7 @@ func (cmd *Command) forwardLogs(ctx context.Context, combinedLogs chan<- rawLogL
 
 		logsReq := kubeClient.CoreV1().
 			Pods(cmd.Config.Auth.KubeNamespace).
-			GetLogs(names.ToDNS1123(service), &opts)
+			GetLogs(cmd.containers[service].pod, &opts)
 
 		logsStream, err := logsReq.Stream()
 		if err != nil {
@@ -343,8 +361,8 @@ func printStatusMessage(service, message string, hideServiceName bool) {
 const windowSize = 100 * time.Millisecond
 
 // printLogs reads logs from the `rawLogs` in `windowSize` intervals, and
-// prints the logs in each window in sorted order.
-func printLogs(ctx context.Context, rawLogs <-chan rawLogLine, hideServiceName bool) error {
+// prints the logs in each window in sorted order to `out`.
+func printLogs(ctx context.Context, out io.Writer, rawLogs <-chan rawLogLine, hideServiceName bool) error {
 	var window []rawLogLine
 	var flushTrigger <-chan time.Time
 
@@ -406,14 +424,14 @@ func printLogs(ctx context.Context, rawLogs <-chan rawLogLine, hideServiceName b
 		for _, log := range parsedLogs {
 			switch {
 			case log.formatOverride != "":
-				fmt.Fprintf(os.Stdout, "%s", log.formatOverride)
+				fmt.Fprintf(out, "%s", log.formatOverride)
 
 			case hideServiceName:
-				fmt.Fprintln(os.Stdout, log.message)
+				fmt.Fprintln(out, log.message)
 
 			default:
 				coloredContainer := goterm.Color(log.fromContainer, pickColor(log.fromContainer))
-				fmt.Fprintf(os.Stdout, "%s › %s\n", coloredContainer, log.message)
+				fmt.Fprintf(out, "%s › %s\n", coloredContainer, log.message)
 			}
 		}
 
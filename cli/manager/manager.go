@@ -9,6 +9,7 @@ import (
 
 	"github.com/kelda/blimp/cli/util"
 	"github.com/kelda/blimp/pkg/errors"
+	"github.com/kelda/blimp/pkg/names"
 	"github.com/kelda/blimp/pkg/proto/auth"
 	"github.com/kelda/blimp/pkg/proto/cluster"
 	"github.com/kelda/blimp/pkg/version"
@@ -70,34 +71,36 @@ func dial(host, cert, hostname string) (Client, error) {
 	return client, nil
 }
 
+// CheckServiceStatus checks that the service's status satisfies the
+// predicate, and returns the status.
 func CheckServiceStatus(svc string, auth *auth.BlimpAuth,
-	predicate func(*cluster.ServiceStatus) bool) error {
+	predicate func(*cluster.ServiceStatus) bool) (*cluster.ServiceStatus, error) {
 	statusResp, err := C.GetStatus(context.Background(), &cluster.GetStatusRequest{
 		Auth: auth,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	status := statusResp.GetStatus()
 	if status.GetPhase() != cluster.SandboxStatus_RUNNING {
-		return errors.NewFriendlyError(
+		return nil, errors.NewFriendlyError(
 			"Your sandbox is not booted. Please run `blimp up` first.")
 	}
 
 	for svcName, svcStatus := range status.GetServices() {
 		if svcName == svc && predicate(svcStatus) {
 			// We are booted!
-			return nil
+			return svcStatus, nil
 		}
 	}
 
 	// Either the service hasn't been created, or it isn't in the RUNNING phase.
-	return errors.NewFriendlyError(
+	return nil, errors.NewFriendlyError(
 		"This service isn't booted. You can check its status with `blimp ps`.")
 }
 
-func CheckServiceRunning(svc string, auth *auth.BlimpAuth) error {
+func CheckServiceRunning(svc string, auth *auth.BlimpAuth) (*cluster.ServiceStatus, error) {
 	return CheckServiceStatus(svc, auth, func(svcStatus *cluster.ServiceStatus) bool {
 		// If a service is unhealthy, we probably still want to be able to
 		// interact with it, to figure out why it's unhealthy.
@@ -107,8 +110,21 @@ func CheckServiceRunning(svc string, auth *auth.BlimpAuth) error {
 }
 
 // CheckServiceStarted checks that the service has started at some point. It may or may not be actively running.
-func CheckServiceStarted(svc string, auth *auth.BlimpAuth) error {
+func CheckServiceStarted(svc string, auth *auth.BlimpAuth) (*cluster.ServiceStatus, error) {
 	return CheckServiceStatus(svc, auth, func(svcStatus *cluster.ServiceStatus) bool {
 		return svcStatus.GetHasStarted()
 	})
 }
+
+// ServiceContainer returns the names of the pod and container that run the
+// first replica of the service. Services that share a namespace with
+// another service run as a container in the other service's pod.
+func ServiceContainer(svc string, status *cluster.ServiceStatus) (pod, container string) {
+	pod = status.GetPodName()
+	// Older clusters don't report the pod name, and always run services in
+	// their own pod.
+	if pod == "" {
+		pod = names.ToDNS1123(svc)
+	}
+	return pod, names.ToDNS1123(svc)
+}
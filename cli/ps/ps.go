@@ -57,7 +57,7 @@ func printStatus(status cluster.SandboxStatus) {
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
 	defer w.Flush()
-	fmt.Fprintln(w, "SERVICE\tSTATUS")
+	fmt.Fprintln(w, "SERVICE\tREPLICAS\tSTATUS")
 
 	var serviceNames []string
 	for name := range status.Services {
@@ -66,7 +66,9 @@ func printStatus(status cluster.SandboxStatus) {
 	sort.Strings(serviceNames)
 
 	for _, name := range serviceNames {
-		statusStr, statusColor, _ := GetStatusString(status.Services[name])
-		fmt.Fprintf(w, "%s\t%s\n", name, goterm.Color(statusStr, statusColor))
+		svcStatus := status.Services[name]
+		statusStr, statusColor, _ := GetStatusString(svcStatus)
+		replicas := fmt.Sprintf("%d/%d", svcStatus.ReadyReplicas, svcStatus.Replicas)
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, replicas, goterm.Color(statusStr, statusColor))
 	}
 }
@@ -55,6 +55,9 @@ func GetStatusString(svcStatus *cluster.ServiceStatus) (msg string, color int, b
 	case cluster.ServicePhase_UNSCHEDULABLE:
 		msg = "Unschedulable. You may need to run `blimp down` and recreate your sandbox."
 		color = goterm.RED
+	case cluster.ServicePhase_BUILD_FAILED:
+		msg = "Build failed"
+		color = goterm.RED
 	}
 
 	if svcStatus.Msg != "" {
@@ -13,7 +13,6 @@ import (
 	"github.com/kelda/blimp/cli/config"
 	"github.com/kelda/blimp/cli/manager"
 	"github.com/kelda/blimp/pkg/errors"
-	"github.com/kelda/blimp/pkg/names"
 )
 
 func New() *cobra.Command {
@@ -40,10 +39,11 @@ func run(svc string) error {
 	}
 
 	// Make sure the pod is actually booted.
-	err = manager.CheckServiceRunning(svc, blimpConfig.BlimpAuth())
+	svcStatus, err := manager.CheckServiceRunning(svc, blimpConfig.BlimpAuth())
 	if err != nil {
 		return err
 	}
+	podName, containerName := manager.ServiceContainer(svc, svcStatus)
 
 	kubeClient, restConfig, err := blimpConfig.Auth.KubeClient()
 	if err != nil {
@@ -61,11 +61,12 @@ func run(svc string) error {
 	}()
 
 	execOpts := core.PodExecOptions{
-		Command: []string{"sh"},
-		Stdin:   true,
-		Stdout:  true,
-		Stderr:  true,
-		TTY:     true,
+		Container: containerName,
+		Command:   []string{"sh"},
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       true,
 	}
 	streamOpts := remotecommand.StreamOptions{
 		Stdin:  os.Stdin,
@@ -77,7 +78,7 @@ func run(svc string) error {
 	req := kubeClient.CoreV1().RESTClient().Post().
 		Resource("pods").
 		SubResource("exec").
-		Name(names.ToDNS1123(svc)).
+		Name(podName).
 		Namespace(blimpConfig.Auth.KubeNamespace).
 		VersionedParams(&execOpts, scheme.ParameterCodec)
 	exec, err := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
@@ -1,6 +1,8 @@
 package up
 
 import (
+	"fmt"
+	"os"
 	"sync"
 
 	"github.com/google/go-containerregistry/pkg/name"
@@ -13,10 +15,18 @@ import (
 	"github.com/kelda/blimp/pkg/build"
 	"github.com/kelda/blimp/pkg/build/buildkit"
 	"github.com/kelda/blimp/pkg/build/docker"
+	"github.com/kelda/blimp/pkg/build/local"
+	"github.com/kelda/blimp/pkg/cfgdir"
+	"github.com/kelda/blimp/pkg/dockercompose"
 	"github.com/kelda/blimp/pkg/errors"
 )
 
-func (cmd *up) buildImages(composeFile composeTypes.Project) (map[string]string, error) {
+// buildImages builds the services' images, and returns the images to deploy.
+// If --keep-going was specified, builds that fail don't cause an error.
+// Instead, the failures are returned as a map from the services to their
+// errors.
+func (cmd *up) buildImages(composeFile composeTypes.Project) (
+	builtImages map[string]string, buildErrors map[string]string, err error) {
 	var buildServices composeTypes.Services
 	for _, svc := range composeFile.Services {
 		if svc.Build != nil {
@@ -25,70 +35,147 @@ func (cmd *up) buildImages(composeFile composeTypes.Project) (map[string]string,
 	}
 
 	if len(buildServices) == 0 {
-		return map[string]string{}, nil
+		return map[string]string{}, nil, nil
 	}
 
-	builtImages := cmd.getRemoteCachedImages(buildServices)
+	// Tag each image with the digest of its build inputs so that services
+	// are only rebuilt when their inputs change.
+	imageNames := map[string]string{}
+	for _, svc := range buildServices {
+		digest, err := build.Digest(*svc.Build)
+		if err != nil {
+			return nil, nil, errors.WithContext(fmt.Sprintf("compute build digest for %s", svc.Name), err)
+		}
+		imageNames[svc.Name] = build.RemoteImageName(svc.Name, cmd.imageNamespace, digest)
+	}
 
-	builder, err := cmd.getImageBuilder(composeFile.Name)
-	if err != nil {
-		return nil, errors.WithContext("get image builder", err)
+	builtImages = cmd.getRemoteCachedImages(imageNames)
+
+	// The interactive displays can't show multiple builds at once.
+	progressMode := cmd.progressMode
+	if cmd.parallel > 1 && progressMode == build.ProgressAuto {
+		progressMode = build.ProgressPlain
 	}
+	progress := build.NewProgress(progressMode, os.Stdout, cfgdir.BuildLogDir())
+	defer progress.Close()
 
 	buildOpts := map[string]build.BuildPushConfig{}
 	for _, svc := range buildServices {
-		if !cmd.alwaysBuild {
-			if _, ok := builtImages[svc.Name]; ok {
-				log.Debugf("Using remote cache image for %s\n", svc.Name)
-				continue
-			}
+		if _, ok := builtImages[svc.Name]; ok {
+			progress.Printf(svc.Name, "Reusing the image for %s since its build context, Dockerfile, and "+
+				"build options haven't changed.", svc.Name)
+			continue
 		}
 
-		imageName := build.RemoteImageName(cmd.composePath, svc.Name, cmd.imageNamespace)
+		if cmd.alwaysBuild {
+			progress.Printf(svc.Name, "Rebuilding %s since --build was specified.", svc.Name)
+		} else {
+			progress.Printf(svc.Name, "Building %s since there's no image for its current build context, "+
+				"Dockerfile, and build options.", svc.Name)
+		}
+
+		secrets, err := dockercompose.ReadBuildSecrets(composeFile, svc)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		imageName := imageNames[svc.Name]
+		cacheImports, cacheExport := build.CacheRepos(svc.Name, cmd.imageNamespace,
+			cmd.config.ConfigFile.TeamBuildCache, cmd.config.ConfigFile.ExportTeamBuildCache)
 		buildOpts[svc.Name] = build.BuildPushConfig{
-			BuildConfig: *svc.Build,
-			ImageName:   imageName,
-			ForceBuild:  cmd.alwaysBuild,
+			BuildConfig:  *svc.Build,
+			ImageName:    imageName,
+			ForceBuild:   cmd.alwaysBuild,
+			SSH:          dockercompose.BuildSSH(svc),
+			Secrets:      secrets,
+			CacheImports: cacheImports,
+			CacheExport:  cacheExport,
 		}
 	}
 
 	if len(buildOpts) == 0 {
 		// All images are already present in the remote.
-		return builtImages, nil
+		return builtImages, nil, nil
+	}
+
+	// Docker and Podman don't support forwarding SSH agents or secrets, so
+	// build with BuildKit if any services need them.
+	builder, err := cmd.getImageBuilder(composeFile.Name, progress, build.RequiresBuildkit(buildOpts))
+	if err != nil {
+		return nil, nil, errors.WithContext("get image builder", err)
 	}
 
-	newBuiltImages, err := builder.BuildAndPush(buildOpts)
+	results, err := builder.BuildAndPush(buildOpts, build.ScheduleOptions{
+		Parallelism: cmd.parallel,
+		KeepGoing:   cmd.keepGoing,
+	})
 	if err != nil {
-		return nil, errors.WithContext("build images", err)
+		return nil, nil, errors.WithContext("build images", err)
 	}
-	for s, i := range newBuiltImages {
+	progress.PrintResults(results, cmd.keepGoing)
+
+	if !cmd.keepGoing {
+		if err := results.Err(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for s, i := range results.Images() {
 		builtImages[s] = i
 	}
 
-	return builtImages, nil
+	buildErrors = map[string]string{}
+	for s, err := range results.Failed() {
+		buildErrors[s] = buildErrorMessage(progress, s, err)
+	}
+	return builtImages, buildErrors, nil
 }
 
-func (cmd *up) getImageBuilder(projectName string) (build.Interface, error) {
-	if !cmd.forceBuildkit {
-		dockerClient, err := docker.New(cmd.regCreds, cmd.dockerConfig, cmd.config.BlimpAuth(), docker.CacheOptions{
-			ProjectName: projectName,
-			ComposePath: cmd.composePath,
-		})
+// buildErrorMessage returns the summary of a failed build that's shown in
+// `blimp ps`.
+func buildErrorMessage(progress *build.Progress, service string, err error) string {
+	if err == build.ErrSkipped {
+		return err.Error()
+	}
+	return fmt.Sprintf("See the build log at %s", progress.LogPath(service))
+}
+
+func (cmd *up) getImageBuilder(projectName string, progress *build.Progress, requireBuildkit bool) (
+	build.Interface, error) {
+	builder, err := build.ParseBuilder(cmd.config.ConfigFile.Builder)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.forceBuildkit {
+		builder = build.BuilderRemote
+	}
+
+	if builder != build.BuilderRemote {
+		localBuilder, err := local.New(builder, requireBuildkit, cmd.regCreds, cmd.dockerConfig,
+			cmd.config.BlimpAuth(), progress, docker.CacheOptions{
+				ProjectName: projectName,
+				ComposePath: cmd.composePath,
+			})
 		if err == nil {
-			return dockerClient, nil
+			return localBuilder, nil
+		}
+		if builder != build.BuilderAuto {
+			return nil, errors.WithContext(fmt.Sprintf("create %s image builder", builder), err)
 		}
-		log.WithError(err).Debug("Failed to get Docker client for local builder. " +
+		log.WithError(err).Debug("Failed to get local image builder. " +
 			"Falling back to building remotely with buildkit")
 	}
 
-	buildkitClient, err := buildkit.New(cmd.tunnelManager, cmd.regCreds)
+	buildkitClient, err := buildkit.New(cmd.tunnelManager, cmd.regCreds, progress)
 	if err != nil {
 		return nil, errors.WithContext("create buildkit image builder", err)
 	}
 	return buildkitClient, nil
 }
 
-func (cmd *up) getRemoteCachedImages(services composeTypes.Services) map[string]string {
+// getRemoteCachedImages returns the images that have already been pushed to
+// the registry. `images` maps service names to their image names.
+func (cmd *up) getRemoteCachedImages(images map[string]string) map[string]string {
 	if cmd.alwaysBuild {
 		return map[string]string{}
 	}
@@ -109,7 +196,7 @@ func (cmd *up) getRemoteCachedImages(services composeTypes.Services) map[string]
 		go func() {
 			defer wg.Done()
 			for service := range checkImageReqChan {
-				imgName := build.RemoteImageName(cmd.composePath, service, cmd.imageNamespace)
+				imgName := images[service]
 				imageRef, err := name.NewTag(imgName)
 				if err != nil {
 					log.WithError(err).WithField("name", imgName).Debug("Failed to parse image name")
@@ -149,8 +236,8 @@ func (cmd *up) getRemoteCachedImages(services composeTypes.Services) map[string]
 		}()
 	}
 
-	for _, svc := range services {
-		checkImageReqChan <- svc.Name
+	for service := range images {
+		checkImageReqChan <- service
 	}
 	close(checkImageReqChan)
 	wg.Wait()
@@ -3,8 +3,9 @@ package up
 import (
 	"context"
 	"fmt"
-	"os"
+	"io"
 	"sort"
+	"strings"
 	"sync"
 	"text/tabwriter"
 	"time"
@@ -16,12 +17,14 @@ import (
 
 	"github.com/kelda/blimp/cli/manager"
 	"github.com/kelda/blimp/cli/ps"
+	"github.com/kelda/blimp/cli/util"
 	"github.com/kelda/blimp/pkg/errors"
 	"github.com/kelda/blimp/pkg/proto/auth"
 	"github.com/kelda/blimp/pkg/proto/cluster"
 )
 
 type statusPrinter struct {
+	out           io.Writer
 	services      []string
 	disableOutput bool
 
@@ -34,8 +37,8 @@ type statusPrinter struct {
 
 var spinnerChars = []string{"/", "-", "\\", "|"}
 
-func newStatusPrinter(services []string, disableOutput bool) *statusPrinter {
-	sp := &statusPrinter{services: services, disableOutput: disableOutput}
+func newStatusPrinter(out io.Writer, services []string, disableOutput bool) *statusPrinter {
+	sp := &statusPrinter{out: out, services: services, disableOutput: disableOutput}
 	sort.Strings(sp.services)
 	return sp
 }
@@ -64,7 +67,12 @@ func (sp *statusPrinter) Run(ctx context.Context,
 		}
 
 		if allReady {
-			fmt.Println(goterm.Color("All containers successfully started", goterm.GREEN))
+			if failed := sp.failedBuilds(); len(failed) != 0 {
+				fmt.Fprintln(sp.out, goterm.Color("All containers started, except for the services that failed to build: "+
+					strings.Join(failed, ", "), goterm.YELLOW))
+			} else {
+				fmt.Fprintln(sp.out, goterm.Color("All containers successfully started", goterm.GREEN))
+			}
 			return true
 		}
 
@@ -124,15 +132,14 @@ func (sp *statusPrinter) printStatus() {
 	// Reset the cursor so that we'll write over the previous status update.
 	// TODO: Doesn't properly work if the previous print spanned multiple lines.
 	for i := 0; i < sp.prevLinesPrinted; i++ {
-		goterm.MoveCursorUp(1)
-		goterm.Flush()
-		fmt.Printf(goterm.ResetLine(""))
+		util.MoveCursorUp(sp.out, 1)
+		fmt.Fprint(sp.out, goterm.ResetLine(""))
 	}
 
 	sp.spinnerIdx = (sp.spinnerIdx + 1) % len(spinnerChars)
 	spinner := spinnerChars[sp.spinnerIdx]
 
-	out := tabwriter.NewWriter(os.Stdout, 0, 10, 5, ' ', 0)
+	out := tabwriter.NewWriter(sp.out, 0, 10, 5, ' ', 0)
 	defer out.Flush()
 	for _, svc := range sp.services {
 		statusStr, color, done := sp.getServiceStatus(svc)
@@ -155,5 +162,23 @@ func (sp *statusPrinter) getServiceStatus(svc string) (msg string, color int, bo
 		return "Pending", goterm.YELLOW, false
 	}
 
-	return ps.GetStatusString(svcStatus)
+	// Services that failed to build will never boot, so we don't wait for
+	// them.
+	msg, color, booted = ps.GetStatusString(svcStatus)
+	return msg, color, booted || svcStatus.Phase == cluster.ServicePhase_BUILD_FAILED
+}
+
+// failedBuilds returns the services that weren't deployed because their
+// images failed to build.
+func (sp *statusPrinter) failedBuilds() []string {
+	sp.Lock()
+	defer sp.Unlock()
+
+	var failed []string
+	for _, svc := range sp.services {
+		if svcStatus, ok := sp.currStatus[svc]; ok && svcStatus.Phase == cluster.ServicePhase_BUILD_FAILED {
+			failed = append(failed, svc)
+		}
+	}
+	return failed
 }
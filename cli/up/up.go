@@ -3,12 +3,16 @@ package up
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 
+	"github.com/buger/goterm"
 	"github.com/docker/cli/cli/config"
 	"github.com/docker/cli/cli/config/configfile"
 	"github.com/docker/docker/api/types"
@@ -25,8 +29,10 @@ import (
 	"github.com/kelda/blimp/cli/manager"
 	"github.com/kelda/blimp/cli/util"
 	"github.com/kelda/blimp/pkg/auth"
+	"github.com/kelda/blimp/pkg/build"
 	"github.com/kelda/blimp/pkg/dockercompose"
 	"github.com/kelda/blimp/pkg/errors"
+	"github.com/kelda/blimp/pkg/lockfile"
 	"github.com/kelda/blimp/pkg/proto/cluster"
 	"github.com/kelda/blimp/pkg/proto/node"
 	"github.com/kelda/blimp/pkg/syncthing"
@@ -35,6 +41,7 @@ import (
 
 func New() *cobra.Command {
 	var composePaths []string
+	var progressMode string
 	var cmd up
 	cobraCmd := &cobra.Command{
 		Use:   "up [options] [SERVICE...]",
@@ -67,6 +74,12 @@ func New() *cobra.Command {
 				log.WithError(err).Fatal("Failed to load docker config")
 			}
 
+			cmd.progressMode, err = build.ParseProgressMode(progressMode)
+			if err != nil {
+				errors.HandleFatalError(err)
+			}
+			cmd.out = build.StatusOutput(cmd.progressMode)
+
 			cmd.composePath = composePath
 			cmd.overridePaths = overridePaths
 			cmd.dockerConfig = dockerConfig
@@ -78,12 +91,26 @@ func New() *cobra.Command {
 	}
 	cobraCmd.Flags().StringSliceVarP(&composePaths, "file", "f", nil,
 		"Specify an alternate compose file\nDefaults to docker-compose.yml and docker-compose.yaml")
+	cobraCmd.Flags().StringSliceVarP(&cmd.profiles, "profile", "", nil,
+		"Specify a profile to enable\nDefaults to the profiles in COMPOSE_PROFILES")
 	cobraCmd.Flags().BoolVarP(&cmd.alwaysBuild, "build", "", false,
 		"Build images before starting containers")
 	cobraCmd.Flags().BoolVarP(&cmd.detach, "detach", "d", false,
 		"Leave containers running after blimp up exits")
 	cobraCmd.Flags().BoolVarP(&cmd.forceBuildkit, "remote-build", "", false,
 		"Force Docker images to be built in your sandbox instead of locally")
+	cobraCmd.Flags().IntVarP(&cmd.parallel, "parallel", "", 1,
+		"Build up to this many images at once")
+	cobraCmd.Flags().BoolVarP(&cmd.keepGoing, "keep-going", "", false,
+		"Deploy the services that built successfully even if other services fail to build")
+	cobraCmd.Flags().BoolVarP(&cmd.locked, "locked", "", false,
+		"Run the images recorded in blimp.lock by `blimp build --lock`, rather than building images "+
+			"or pulling the latest tags\n"+
+			"Built images are only accessible to other users if they were locked with "+
+			"export_team_build_cache set")
+	cobraCmd.Flags().StringVarP(&progressMode, "progress", "", string(build.ProgressAuto),
+		"Set the type of build progress output (auto, plain, json)\n"+
+			"Build logs are also saved in ~/.blimp/build-logs")
 
 	cobraCmd.Flags().BoolVarP(&cmd.disableStatusOutput, "disable-status-output", "", false,
 		"Don't print status updates. Used by preview implementation.")
@@ -98,9 +125,14 @@ type up struct {
 	config              cliConfig.Config
 	composePath         string
 	overridePaths       []string
+	profiles            []string
 	alwaysBuild         bool
 	detach              bool
 	forceBuildkit       bool
+	parallel            int
+	keepGoing           bool
+	locked              bool
+	progressMode        build.ProgressMode
 	disableStatusOutput bool
 	dockerConfig        *configfile.ConfigFile
 	regCreds            auth.RegistryCredentials
@@ -109,18 +141,23 @@ type up struct {
 	nodeControllerConn   *grpc.ClientConn
 	nodeControllerClient node.ControllerClient
 	tunnelManager        tunnel.Manager
+
+	// out is where everything other than build progress is printed. It's
+	// stderr when the build progress is printed as JSON, so that stdout
+	// only contains JSON.
+	out io.Writer
 }
 
 func (cmd *up) run(services []string) error {
 	// TODO: Make locking atomic. Currently there could be TOCTTOU problems.
 	if util.UpRunning() {
-		fmt.Printf("It looks like `blimp up` is already running.\n" +
+		fmt.Fprintf(cmd.out, "It looks like `blimp up` is already running.\n"+
 			"Are you sure you want to continue, even though things might break? (y/N) ")
 		var response string
 		num, err := fmt.Scanln(&response)
 		if err != nil || num != 1 ||
 			(strings.ToLower(response) != "y" && strings.ToLower(response) != "yes") {
-			fmt.Printf("Aborting.\n")
+			fmt.Fprintf(cmd.out, "Aborting.\n")
 			os.Exit(1)
 		}
 	}
@@ -131,16 +168,40 @@ func (cmd *up) run(services []string) error {
 	}
 	defer util.ReleaseUpLock()
 
-	parsedCompose, err := dockercompose.Load(cmd.composePath, cmd.overridePaths, services)
+	parsedCompose, err := dockercompose.Load(cmd.composePath, cmd.overridePaths, services, cmd.profiles)
 	if err != nil {
 		return errors.WithContext("load compose file", err)
 	}
 
+	// Pin the services to the images in the lockfile. This has to happen
+	// before the Compose file is serialized.
+	var lock lockfile.Lockfile
+	if cmd.locked {
+		lock, err = lockfile.Read(lockfile.Path(cmd.composePath))
+		if err != nil {
+			return err
+		}
+
+		if err := lock.Pin(&parsedCompose); err != nil {
+			return err
+		}
+	}
+
 	parsedComposeBytes, err := dockercompose.Marshal(parsedCompose)
 	if err != nil {
 		return err
 	}
 
+	secrets, err := dockercompose.ReadSecrets(parsedCompose)
+	if err != nil {
+		return errors.WithContext("read secrets", err)
+	}
+
+	configs, err := dockercompose.ReadConfigs(parsedCompose)
+	if err != nil {
+		return errors.WithContext("read configs", err)
+	}
+
 	stClient := cmd.makeSyncthingClient(parsedCompose)
 	idPathMap := stClient.GetIDPathMap()
 
@@ -152,31 +213,48 @@ func (cmd *up) run(services []string) error {
 	}
 	cmd.regCreds = regCreds
 
+	if cmd.locked {
+		printLockDrift(cmd.out, lock.TagDrift(regCreds))
+	}
+
 	// Start creating the sandbox immediately so that the systems services
 	// start booting as soon as possible.
-	if err := cmd.createSandbox(string(parsedComposeBytes), idPathMap); err != nil {
+	if err := cmd.createSandbox(string(parsedComposeBytes), idPathMap, secrets); err != nil {
 		log.WithError(err).Fatal("Failed to create development sandbox")
 	}
 	defer cmd.nodeControllerConn.Close()
 
-	builtImages, err := cmd.buildImages(parsedCompose)
+	// Fail early if the locked images can't be pulled, rather than when the
+	// pods fail to start. This has to happen after the sandbox is created
+	// since that's when the credentials for the Blimp registry are added.
+	if cmd.locked {
+		if err := lock.CheckBuiltImages(parsedCompose, cmd.regCreds); err != nil {
+			return err
+		}
+	}
+
+	builtImages, buildErrors, err := cmd.buildImages(parsedCompose)
 	if err != nil {
 		return err
 	}
 
 	// Send the boot request to the cluster manager.
-	pp := util.NewProgressPrinter(os.Stdout, "Deploying Docker Compose file to sandbox")
+	pp := util.NewProgressPrinter(cmd.out, "Deploying Docker Compose file to sandbox")
 	go pp.Run()
 
-	_, err = manager.C.DeployToSandbox(context.Background(), &cluster.DeployRequest{
+	deployResp, err := manager.C.DeployToSandbox(context.Background(), &cluster.DeployRequest{
 		Auth:        cmd.config.BlimpAuth(),
 		ComposeFile: string(parsedComposeBytes),
 		BuiltImages: builtImages,
+		BuildErrors: buildErrors,
+		Configs:     configs,
+		ProjectName: parsedCompose.Name,
 	})
 	pp.Stop()
 	if err != nil {
 		return err
 	}
+	printDeploySummary(cmd.out, deployResp.GetChanges(), buildErrors)
 
 	syncthingError := make(chan error, 1)
 	syncthingCtx, cancelSyncthing := context.WithCancel(context.Background())
@@ -209,11 +287,17 @@ func (cmd *up) run(services []string) error {
 		svc := svc
 		for _, mapping := range svc.Ports {
 			mapping := mapping
-			if mapping.Protocol == "tcp" {
+			switch mapping.Protocol {
+			case "tcp":
 				startedTunnels = true
 				tunnelsErrGroup.Go(func() error {
 					return cmd.tunnelManager.Run(mapping.HostIP, mapping.Published, svc.Name, mapping.Target, nil)
 				})
+			case "udp":
+				startedTunnels = true
+				tunnelsErrGroup.Go(func() error {
+					return cmd.tunnelManager.RunUDP(mapping.HostIP, mapping.Published, svc.Name, mapping.Target, nil)
+				})
 			}
 		}
 	}
@@ -253,8 +337,8 @@ func (cmd *up) run(services []string) error {
 		cancelGui()
 
 		if cmd.detach {
-			fmt.Println("Cleaning up local processes. The remote containers will continue running.")
-			fmt.Println("Use `blimp down` to clean up your remote sandbox.")
+			fmt.Fprintln(cmd.out, "Cleaning up local processes. The remote containers will continue running.")
+			fmt.Fprintln(cmd.out, "Use `blimp down` to clean up your remote sandbox.")
 		}
 
 		// If we spawned a child process for Syncthing, terminate it gracefully.
@@ -264,8 +348,8 @@ func (cmd *up) run(services []string) error {
 		}
 
 		if !cmd.detach {
-			fmt.Println("Cleaning up your containers and volumes.")
-			fmt.Println("To keep your sandbox running, use `blimp up -d` instead.")
+			fmt.Fprintln(cmd.out, "Cleaning up your containers and volumes.")
+			fmt.Fprintln(cmd.out, "To keep your sandbox running, use `blimp up -d` instead.")
 
 			downFinished := make(chan error)
 			go func() {
@@ -286,8 +370,8 @@ func (cmd *up) run(services []string) error {
 	return nil
 }
 
-func (cmd *up) createSandbox(composeCfg string, idPathMap map[string]string) error {
-	pp := util.NewProgressPrinter(os.Stdout, "Booting cloud sandbox")
+func (cmd *up) createSandbox(composeCfg string, idPathMap map[string]string, secrets map[string][]byte) error {
+	pp := util.NewProgressPrinter(cmd.out, "Booting cloud sandbox")
 	go pp.Run()
 	defer pp.Stop()
 
@@ -297,13 +381,14 @@ func (cmd *up) createSandbox(composeCfg string, idPathMap map[string]string) err
 			ComposeFile:         composeCfg,
 			RegistryCredentials: cmd.regCreds.ToProtobuf(),
 			SyncedFolders:       idPathMap,
+			Secrets:             secrets,
 		})
 	if err != nil {
 		return err
 	}
 
 	if resp.Message != "" {
-		fmt.Printf("\n" + resp.Message)
+		fmt.Fprint(cmd.out, "\n"+resp.Message)
 	}
 
 	switch resp.Action {
@@ -348,7 +433,7 @@ func (cmd *up) createSandbox(composeCfg string, idPathMap map[string]string) err
 
 func (cmd *up) runGUI(ctx context.Context, parsedCompose composeTypes.Project) error {
 	services := parsedCompose.ServiceNames()
-	statusPrinter := newStatusPrinter(services, cmd.disableStatusOutput)
+	statusPrinter := newStatusPrinter(cmd.out, services, cmd.disableStatusOutput)
 	if !statusPrinter.Run(ctx, manager.C, cmd.config.BlimpAuth()) {
 		return nil
 	}
@@ -357,6 +442,7 @@ func (cmd *up) runGUI(ctx context.Context, parsedCompose composeTypes.Project) e
 		Services: services,
 		Opts:     corev1.PodLogOptions{Follow: true},
 		Config:   cmd.config,
+		Out:      cmd.out,
 	}.Run(ctx)
 }
 
@@ -379,8 +465,8 @@ func (cmd *up) makeSyncthingClient(dcCfg composeTypes.Project) syncthing.Client
 
 		// Now, mask off any native volumes that fall under these mounts.
 		for _, v := range svc.Volumes {
-			// Theoretically this could match other types of volumes like tmpfs,
-			// although we don't support these yet.
+			// This also masks tmpfs volumes, since their contents only exist in
+			// the container.
 			if v.Type == composeTypes.VolumeTypeBind {
 				continue
 			}
@@ -414,3 +500,39 @@ func (cmd *up) makeSyncthingClient(dcCfg composeTypes.Project) syncthing.Client
 
 	return syncthing.NewClient(allVolumes)
 }
+
+// printLockDrift warns about the images that differ from the lockfile.
+func printLockDrift(out io.Writer, drift []string) {
+	if len(drift) == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf("WARNING: Some images have drifted from %s:\n", lockfile.Filename)
+	for _, d := range drift {
+		msg += fmt.Sprintf("  %s\n", d)
+	}
+	fmt.Fprint(out, goterm.Color(msg, goterm.YELLOW))
+}
+
+// printDeploySummary prints how each service's pods changed during the
+// deploy. Services that failed to build are left out, since they're already
+// reported as failed.
+func printDeploySummary(out io.Writer, changes map[string]cluster.DeployChange, buildErrors map[string]string) {
+	var services []string
+	for svc := range changes {
+		if _, ok := buildErrors[svc]; !ok {
+			services = append(services, svc)
+		}
+	}
+	if len(services) == 0 {
+		return
+	}
+	sort.Strings(services)
+
+	fmt.Fprintln(out, "Deployed services:")
+	tw := tabwriter.NewWriter(out, 0, 10, 5, ' ', 0)
+	defer tw.Flush()
+	for _, svc := range services {
+		fmt.Fprintf(tw, "  %s\t%s\n", svc, strings.ToLower(changes[svc].String()))
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"io"
 	"time"
-
-	"github.com/buger/goterm"
 )
 
 // ProgressPrinter prints to the output every 2 seconds so that the user knows
@@ -39,8 +37,7 @@ func (pp ProgressPrinter) Run() {
 			return
 		case <-poll.C:
 			time++
-			goterm.MoveCursorBackward(1)
-			goterm.Flush()
+			MoveCursorBackward(pp.out, 1)
 			fmt.Fprintf(pp.out, spinnerChars[time%len(spinnerChars)])
 		}
 	}
@@ -51,7 +48,17 @@ func (pp ProgressPrinter) Run() {
 func (pp ProgressPrinter) Stop() {
 	close(pp.stop)
 	<-pp.stopped
-	goterm.MoveCursorBackward(1)
-	goterm.Flush()
+	MoveCursorBackward(pp.out, 1)
 	fmt.Fprint(pp.out, " \n")
 }
+
+// MoveCursorUp moves the cursor up `n` lines. Unlike goterm, it writes to
+// `out` rather than stdout, so that it works with other outputs.
+func MoveCursorUp(out io.Writer, n int) {
+	fmt.Fprintf(out, "\033[%dA", n)
+}
+
+// MoveCursorBackward moves the cursor back `n` characters.
+func MoveCursorBackward(out io.Writer, n int) {
+	fmt.Fprintf(out, "\033[%dD", n)
+}
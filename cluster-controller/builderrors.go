@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kelda/blimp/pkg/errors"
+	"github.com/kelda/blimp/pkg/metadata"
+)
+
+// withoutFailedBuilds removes the services whose images failed to build.
+// Services that share the network or PID namespace of a removed service, or
+// that depend on or link to a removed service, are removed as well, since
+// they can't run without it. It returns the remaining services, and a map
+// from the removed services to the reason they were removed.
+func withoutFailedBuilds(services []composeTypes.ServiceConfig, buildErrors map[string]string) (
+	[]composeTypes.ServiceConfig, map[string]string) {
+	removed := map[string]string{}
+	for svc, msg := range buildErrors {
+		removed[svc] = msg
+	}
+
+	// Services can depend on each other transitively, so keep removing
+	// services until there aren't any more that depend on a removed service.
+	for changed := true; changed; {
+		changed = false
+		for _, svc := range services {
+			if _, ok := removed[svc.Name]; ok {
+				continue
+			}
+
+			if msg, ok := removedDependencyMessage(svc, removed); ok {
+				removed[svc.Name] = msg
+				changed = true
+			}
+		}
+	}
+
+	var remaining []composeTypes.ServiceConfig
+	for _, svc := range services {
+		if _, ok := removed[svc.Name]; !ok {
+			remaining = append(remaining, svc)
+		}
+	}
+	return remaining, removed
+}
+
+// removedDependencyMessage returns why the service can't be deployed if it
+// requires one of the removed services.
+func removedDependencyMessage(svc composeTypes.ServiceConfig, removed map[string]string) (string, bool) {
+	for _, mode := range []string{svc.NetworkMode, svc.Pid} {
+		target, ok := sharedNamespaceService(mode)
+		if _, targetRemoved := removed[target]; ok && targetRemoved {
+			return fmt.Sprintf(
+				"Not deployed since it shares a namespace with %s, which failed to build", target), true
+		}
+	}
+
+	var dependencies []string
+	for dependency := range svc.DependsOn {
+		dependencies = append(dependencies, dependency)
+	}
+	for _, link := range svc.Links {
+		dependencies = append(dependencies, strings.Split(link, ":")[0])
+	}
+	sort.Strings(dependencies)
+
+	for _, dependency := range dependencies {
+		if _, ok := removed[dependency]; ok {
+			return fmt.Sprintf(
+				"Not deployed since it depends on %s, which failed to build", dependency), true
+		}
+	}
+	return "", false
+}
+
+// setBuildErrors records the services that failed to build on the sandbox's
+// namespace so that they're included in the sandbox's status. The errors
+// from the previous deployment are cleared.
+func (s *server) setBuildErrors(namespace string, buildErrors map[string]string) error {
+	namespaceClient := s.kubeClient.CoreV1().Namespaces()
+	ns, err := namespaceClient.Get(namespace, metav1.GetOptions{})
+	if err != nil {
+		return errors.WithContext("get namespace", err)
+	}
+
+	_, hadErrors := ns.Annotations[metadata.BuildErrorsKey]
+	if len(buildErrors) == 0 && !hadErrors {
+		return nil
+	}
+
+	if len(buildErrors) == 0 {
+		delete(ns.Annotations, metadata.BuildErrorsKey)
+	} else {
+		if ns.Annotations == nil {
+			ns.Annotations = map[string]string{}
+		}
+		ns.Annotations[metadata.BuildErrorsKey] = metadata.BuildErrors(buildErrors)
+	}
+
+	if _, err := namespaceClient.Update(ns); err != nil {
+		return errors.WithContext("update namespace", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithoutFailedBuilds(t *testing.T) {
+	services := []composeTypes.ServiceConfig{
+		{Name: "vpn"},
+		{Name: "app", NetworkMode: "service:vpn"},
+		{Name: "debug", NetworkMode: "service:app", Pid: "service:app"},
+		{Name: "db"},
+		{
+			Name: "web",
+			DependsOn: composeTypes.DependsOnConfig{
+				"db": {Condition: composeTypes.ServiceConditionStarted},
+			},
+		},
+		{Name: "worker", Links: []string{"web:frontend"}},
+	}
+
+	tests := []struct {
+		name        string
+		buildErrors map[string]string
+		expServices []string
+		expRemoved  map[string]string
+	}{
+		{
+			name:        "no failures",
+			expServices: []string{"vpn", "app", "debug", "db", "web", "worker"},
+			expRemoved:  map[string]string{},
+		},
+		{
+			name:        "independent service",
+			buildErrors: map[string]string{"worker": "error"},
+			expServices: []string{"vpn", "app", "debug", "db", "web"},
+			expRemoved:  map[string]string{"worker": "error"},
+		},
+		{
+			name:        "dependencies",
+			buildErrors: map[string]string{"db": "error"},
+			expServices: []string{"vpn", "app", "debug"},
+			expRemoved: map[string]string{
+				"db":     "error",
+				"web":    "Not deployed since it depends on db, which failed to build",
+				"worker": "Not deployed since it depends on web, which failed to build",
+			},
+		},
+		{
+			name:        "shared namespaces",
+			buildErrors: map[string]string{"vpn": "error"},
+			expServices: []string{"db", "web", "worker"},
+			expRemoved: map[string]string{
+				"vpn":   "error",
+				"app":   "Not deployed since it shares a namespace with vpn, which failed to build",
+				"debug": "Not deployed since it shares a namespace with app, which failed to build",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			remaining, removed := withoutFailedBuilds(services, test.buildErrors)
+
+			var remainingNames []string
+			for _, svc := range remaining {
+				remainingNames = append(remainingNames, svc.Name)
+			}
+			assert.Equal(t, test.expServices, remainingNames)
+			assert.Equal(t, test.expRemoved, removed)
+		})
+	}
+}
@@ -1,18 +1,29 @@
 package main
 
 import (
+	"context"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/kelda/blimp/cluster-controller/affinity"
+	"github.com/kelda/blimp/cluster-controller/volume"
 	"github.com/kelda/blimp/pkg/kube"
 	"github.com/kelda/blimp/pkg/version"
 	"github.com/kelda/blimp/pkg/errors"
 )
 
-func createBuildkitd(kubeClient kubernetes.Interface, namespace string) error {
+// buildkitdStateDir is the directory where buildkitd stores its build cache.
+// It's backed by a PersistentVolume so that the cache survives `blimp down`.
+const buildkitdStateDir = "/var/lib/buildkit"
+
+func createBuildkitd(ctx context.Context, kubeClient kubernetes.Interface, namespace string) error {
+	if err := volume.CreateBuildCachePVC(ctx, kubeClient, namespace); err != nil {
+		return errors.WithContext("create build cache", err)
+	}
+
 	runAsUser := int64(1000)
 	runAsGroup := int64(1000)
 	pod := corev1.Pod{
@@ -40,6 +51,17 @@ func createBuildkitd(kubeClient kubernetes.Interface, namespace string) error {
 					"--addr",
 					"tcp://0.0.0.0:1234",
 					"--oci-worker-no-process-sandbox",
+					"--root",
+					buildkitdStateDir,
+					// Allow builds that use `network: host`. This is safe
+					// since the pod doesn't use the node's network, so
+					// the "host" network is just the pod's network
+					// namespace. Builds with `network: host` can't reach
+					// anything that buildkitd itself can't already reach,
+					// and the entitlement is only requested by builds that
+					// use it.
+					"--allow-insecure-entitlement",
+					"network.host",
 				},
 				SecurityContext: &corev1.SecurityContext{
 					RunAsUser:  &runAsUser,
@@ -55,10 +77,14 @@ func createBuildkitd(kubeClient kubernetes.Interface, namespace string) error {
 						"memory": resource.MustParse("100Mi"),
 					},
 				},
-				// XXX: We should persist the disk used for build cache, so that
-				// re-building after `blimp down` can still hit the build cache.
-				// When adding this, we should be careful that the readiness
-				// probe does not cause high CPU usage in the systemd process.
+				VolumeMounts: []corev1.VolumeMount{
+					{
+						Name:      volume.BuildCacheVolume.Name,
+						MountPath: buildkitdStateDir,
+					},
+				},
+				// The readiness probe runs infrequently so that it doesn't
+				// cause high CPU usage in the systemd process.
 				ReadinessProbe: &corev1.Probe{
 					Handler: corev1.Handler{
 						Exec: &corev1.ExecAction{
@@ -76,8 +102,14 @@ func createBuildkitd(kubeClient kubernetes.Interface, namespace string) error {
 					InitialDelaySeconds: 5,
 				},
 			}},
+			Volumes:       []corev1.Volume{volume.BuildCacheVolume},
 			Affinity:      affinity.OnBuilderNode(),
 			RestartPolicy: corev1.RestartPolicyAlways,
+			// Make the build cache volume writable by buildkitd, since it
+			// doesn't run as root.
+			SecurityContext: &corev1.PodSecurityContext{
+				FSGroup: &runAsGroup,
+			},
 		},
 	}
 
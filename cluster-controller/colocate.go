@@ -0,0 +1,350 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kelda/blimp/pkg/errors"
+	"github.com/kelda/blimp/pkg/hash"
+	"github.com/kelda/blimp/pkg/kube"
+	"github.com/kelda/blimp/pkg/metadata"
+	"github.com/kelda/blimp/pkg/names"
+	"github.com/kelda/blimp/pkg/proto/wait"
+)
+
+// sharedNamespaceService parses a `network_mode` or `pid` setting, and
+// returns the service whose namespace it refers to, if any.
+func sharedNamespaceService(mode string) (string, bool) {
+	if !strings.HasPrefix(mode, "service:") {
+		return "", false
+	}
+	return strings.TrimPrefix(mode, "service:"), true
+}
+
+// podOwners returns a map from the services that share a namespace with
+// another service to the service whose pod they run in. Kubernetes can only
+// share namespaces between containers in the same pod, so services that share
+// a namespace, either directly or indirectly, are run as a single pod.
+func podOwners(services []composeTypes.ServiceConfig) (map[string]string, error) {
+	byName := map[string]composeTypes.ServiceConfig{}
+	for _, svc := range services {
+		byName[svc.Name] = svc
+	}
+
+	// Group the services that share any namespace, as well as the services
+	// that share a network namespace.
+	pods := unionFind{}
+	networks := unionFind{}
+
+	// Services that join another service's namespace can't be the owner of
+	// the pod, since they're not the service that defines the namespace.
+	joinsNamespace := map[string]bool{}
+	for _, svc := range services {
+		settings := []struct{ field, mode string }{
+			{"network_mode", svc.NetworkMode},
+			{"pid", svc.Pid},
+		}
+		for _, setting := range settings {
+			target, ok := sharedNamespaceService(setting.mode)
+			if !ok {
+				continue
+			}
+
+			if _, ok := byName[target]; !ok {
+				return nil, errors.NewFriendlyError(
+					"Service %s uses `%s: %s`, but service %s doesn't exist.",
+					svc.Name, setting.field, setting.mode, target)
+			}
+
+			joinsNamespace[svc.Name] = true
+			pods.union(svc.Name, target)
+			if setting.field == "network_mode" {
+				networks.union(svc.Name, target)
+			}
+		}
+	}
+
+	// Containers in a pod always share the network namespace, so sharing
+	// just the PID namespace would silently share the network namespace as
+	// well.
+	sharesPid := map[string]bool{}
+	for _, svc := range services {
+		target, ok := sharedNamespaceService(svc.Pid)
+		if !ok {
+			continue
+		}
+
+		if networks.find(svc.Name) != networks.find(target) {
+			return nil, errors.NewFriendlyError(
+				"Service %s uses `pid: %s`, but doesn't share the network namespace of service %s.\n"+
+					"Blimp runs services that share a PID namespace in the same pod, so they "+
+					"must share a network namespace as well.\n"+
+					"Please add `network_mode: service:%s` to service %s.",
+				svc.Name, svc.Pid, target, target, svc.Name)
+		}
+		sharesPid[pods.find(svc.Name)] = true
+	}
+
+	groups := map[string][]string{}
+	for _, svc := range services {
+		root := pods.find(svc.Name)
+		groups[root] = append(groups[root], svc.Name)
+	}
+
+	owners := map[string]string{}
+	for root, group := range groups {
+		if len(group) == 1 {
+			continue
+		}
+
+		// Pick the owner deterministically, preferring services that don't
+		// join another service's namespace.
+		sort.Slice(group, func(i, j int) bool {
+			if joinsNamespace[group[i]] != joinsNamespace[group[j]] {
+				return !joinsNamespace[group[i]]
+			}
+			return group[i] < group[j]
+		})
+
+		owner := group[0]
+		for _, member := range group[1:] {
+			if numReplicas(byName[member]) != numReplicas(byName[owner]) {
+				return nil, errors.NewFriendlyError(
+					"Services %s and %s share a namespace, so they must have the same number of replicas.",
+					owner, member)
+			}
+			owners[member] = owner
+		}
+
+		if sharesPid[root] {
+			for _, member := range group {
+				if err := checkSharedPidNamespace(byName[member]); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return owners, nil
+}
+
+// checkSharedPidNamespace returns an error if the service relies on running
+// as PID 1. When a pod shares its PID namespace, PID 1 is the pod's pause
+// container, so the init process and stop signal hook wouldn't work.
+func checkSharedPidNamespace(svc composeTypes.ServiceConfig) error {
+	var setting string
+	if stopSignal, err := normalizeSignal(svc.StopSignal); err == nil &&
+		stopSignal != "" && stopSignal != "SIGTERM" {
+		setting = "stop_signal"
+	}
+	if svc.Init != nil && *svc.Init {
+		setting = "init"
+	}
+	if setting == "" {
+		return nil
+	}
+
+	return errors.NewFriendlyError(
+		"Service %s uses `%s`, but runs in a shared PID namespace (because of `pid: service:`).\n"+
+			"Blimp doesn't support `%s` for services that share a PID namespace.",
+		svc.Name, setting, setting)
+}
+
+// unionFind groups services into disjoint sets.
+type unionFind map[string]string
+
+// find returns the representative service of the set containing `svc`.
+func (u unionFind) find(svc string) string {
+	parent, ok := u[svc]
+	if !ok {
+		return svc
+	}
+	root := u.find(parent)
+	u[svc] = root
+	return root
+}
+
+// union merges the sets containing `a` and `b`.
+func (u unionFind) union(a, b string) {
+	if a, b = u.find(a), u.find(b); a != b {
+		u[a] = b
+	}
+}
+
+// podService returns the service whose pod runs the given service.
+func (b podBuilder) podService(svc string) string {
+	if owner, ok := b.owners[svc]; ok {
+		return owner
+	}
+	return svc
+}
+
+// resolveDependencies updates the service's dependencies to refer to the pods
+// that run them. Dependencies on services that run in the same pod are
+// dropped, since the containers in a pod are started together.
+func (b podBuilder) resolveDependencies(svc string, deps []*wait.ServiceCondition) []*wait.ServiceCondition {
+	var resolved []*wait.ServiceCondition
+	for _, dep := range deps {
+		if b.podService(dep.Service) == b.podService(svc) {
+			continue
+		}
+
+		if owner, ok := b.owners[dep.Service]; ok {
+			dep.Pod = names.PodName(owner, 0)
+		}
+		resolved = append(resolved, dep)
+	}
+	return resolved
+}
+
+// addColocatedService merges the pod for a service that shares a namespace
+// with the pod's service into the pod. `i` is the index of the service within
+// the pod, and is used to disambiguate the names of the service's init
+// containers and volumes. Pod-level settings, such as the hostname and
+// restart policy, are taken from the pod's service.
+func addColocatedService(pod *corev1.Pod, svc composeTypes.ServiceConfig, svcPod corev1.Pod, i int) {
+	// Rename the service's volumes that conflict with the pod's volumes.
+	// Identical volumes are shared, except for memory-backed volumes since
+	// they hold the contents of each container's tmpfs mounts and secrets.
+	renamedVolumes := map[string]string{}
+	for _, v := range svcPod.Spec.Volumes {
+		existing, ok := getVolume(pod.Spec.Volumes, v.Name)
+		isMemory := v.EmptyDir != nil && v.EmptyDir.Medium == corev1.StorageMediumMemory
+		if ok && !isMemory && reflect.DeepEqual(existing, v) {
+			continue
+		}
+
+		if ok {
+			renamedVolumes[v.Name] = fmt.Sprintf("%s-%d", v.Name, i)
+			v.Name = renamedVolumes[v.Name]
+		}
+		pod.Spec.Volumes = append(pod.Spec.Volumes, v)
+	}
+
+	renameMounts := func(c corev1.Container) corev1.Container {
+		c = *c.DeepCopy()
+		for j, mount := range c.VolumeMounts {
+			if name, ok := renamedVolumes[mount.Name]; ok {
+				c.VolumeMounts[j].Name = name
+			}
+		}
+		return c
+	}
+
+	for _, c := range svcPod.Spec.InitContainers {
+		c = renameMounts(c)
+		c.Name = kube.InitContainerName(c.Name, i)
+		pod.Spec.InitContainers = append(pod.Spec.InitContainers, c)
+	}
+	for _, c := range svcPod.Spec.Containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, renameMounts(c))
+	}
+
+	if _, ok := sharedNamespaceService(svc.Pid); ok {
+		shareProcessNamespace := true
+		pod.Spec.ShareProcessNamespace = &shareProcessNamespace
+	}
+
+	if svcPod.Spec.SecurityContext != nil {
+		if pod.Spec.SecurityContext == nil {
+			pod.Spec.SecurityContext = &corev1.PodSecurityContext{}
+		}
+		for _, sysctl := range svcPod.Spec.SecurityContext.Sysctls {
+			if !hasSysctl(pod.Spec.SecurityContext.Sysctls, sysctl.Name) {
+				pod.Spec.SecurityContext.Sysctls = append(pod.Spec.SecurityContext.Sysctls, sysctl)
+			}
+		}
+	}
+
+	pod.Spec.HostAliases = append(pod.Spec.HostAliases, svcPod.Spec.HostAliases...)
+	if grace := svcPod.Spec.TerminationGracePeriodSeconds; grace != nil {
+		if pod.Spec.TerminationGracePeriodSeconds == nil || *pod.Spec.TerminationGracePeriodSeconds < *grace {
+			pod.Spec.TerminationGracePeriodSeconds = grace
+		}
+	}
+
+	// The service's labels, such as the networks it's connected to, are added
+	// to the pod without overriding the pod's own labels.
+	for key, value := range svcPod.Labels {
+		if _, ok := pod.Labels[key]; !ok {
+			pod.Labels[key] = value
+		}
+	}
+	pod.Labels[metadata.ServiceLabel(svc.Name)] = pod.Labels["blimp.replica"]
+
+	mergeColocatedAnnotations(pod, svc.Name, svcPod.Annotations)
+}
+
+func mergeColocatedAnnotations(pod *corev1.Pod, svc string, svcAnnotations map[string]string) {
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+
+	appendList := func(key string, values ...string) {
+		var list []string
+		if existing, ok := pod.Annotations[key]; ok {
+			list = strings.Split(existing, ",")
+		}
+		pod.Annotations[key] = strings.Join(append(list, values...), ",")
+	}
+
+	appendList(metadata.ColocatedServicesKey, svc)
+
+	// The service is resolvable via its name and aliases, even though it
+	// doesn't have its own pod.
+	aliases := []string{svc}
+	if svcAliases, ok := svcAnnotations[metadata.AliasesKey]; ok {
+		aliases = append(aliases, metadata.ParseAliases(svcAliases)...)
+	}
+	appendList(metadata.AliasesKey, aliases...)
+
+	for key, value := range svcAnnotations {
+		switch key {
+		case metadata.AliasesKey:
+			// Handled above.
+		case metadata.LabelAnnotationsKey:
+			appendList(key, strings.Split(value, ",")...)
+		case metadata.NetworkAliasesKey:
+			networkAliases, _ := metadata.ParseNetworkAliases(pod.Annotations[key])
+			svcNetworkAliases, _ := metadata.ParseNetworkAliases(value)
+			if networkAliases == nil {
+				networkAliases = map[string][]string{}
+			}
+			for network, aliases := range svcNetworkAliases {
+				networkAliases[network] = append(networkAliases[network], aliases...)
+			}
+			pod.Annotations[key] = metadata.NetworkAliases(networkAliases)
+		case fileObjectsHashKey:
+			if existing, ok := pod.Annotations[key]; ok {
+				value = hash.DNSCompliant(existing + "," + value)
+			}
+			pod.Annotations[key] = value
+		default:
+			if _, ok := pod.Annotations[key]; !ok {
+				pod.Annotations[key] = value
+			}
+		}
+	}
+}
+
+func getVolume(volumes []corev1.Volume, name string) (corev1.Volume, bool) {
+	for _, v := range volumes {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return corev1.Volume{}, false
+}
+
+func hasSysctl(sysctls []corev1.Sysctl, name string) bool {
+	for _, sysctl := range sysctls {
+		if sysctl.Name == name {
+			return true
+		}
+	}
+	return false
+}
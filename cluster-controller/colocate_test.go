@@ -0,0 +1,199 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	composeTypes "github.com/kelda/compose-go/types"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kelda/blimp/pkg/auth"
+	"github.com/kelda/blimp/pkg/kube"
+	"github.com/kelda/blimp/pkg/metadata"
+	"github.com/kelda/blimp/pkg/names"
+	"github.com/kelda/blimp/pkg/proto/wait"
+)
+
+func TestPodOwners(t *testing.T) {
+	replicas := uint64(2)
+	init := true
+	tests := []struct {
+		name      string
+		services  []composeTypes.ServiceConfig
+		expOwners map[string]string
+		expErr    string
+	}{
+		{
+			name: "no shared namespaces",
+			services: []composeTypes.ServiceConfig{
+				{Name: "web"},
+				{Name: "db"},
+			},
+			expOwners: map[string]string{},
+		},
+		{
+			name: "sidecars",
+			services: []composeTypes.ServiceConfig{
+				{Name: "app", NetworkMode: "service:vpn"},
+				{Name: "debug", NetworkMode: "service:app", Pid: "service:app"},
+				{Name: "vpn"},
+				{Name: "db"},
+			},
+			expOwners: map[string]string{
+				"app":   "vpn",
+				"debug": "vpn",
+			},
+		},
+		{
+			name: "unknown service",
+			services: []composeTypes.ServiceConfig{
+				{Name: "app", NetworkMode: "service:vpn"},
+			},
+			expErr: "Service app uses `network_mode: service:vpn`, but service vpn doesn't exist.",
+		},
+		{
+			name: "different replicas",
+			services: []composeTypes.ServiceConfig{
+				{Name: "app", NetworkMode: "service:vpn"},
+				{
+					Name:   "vpn",
+					Deploy: &composeTypes.DeployConfig{Replicas: &replicas},
+				},
+			},
+			expErr: "Services vpn and app share a namespace, so they must have the same number of replicas.",
+		},
+		{
+			name: "pid without network",
+			services: []composeTypes.ServiceConfig{
+				{Name: "debug", Pid: "service:app"},
+				{Name: "app"},
+			},
+			expErr: "Service debug uses `pid: service:app`, but doesn't share the network namespace of service app.\n" +
+				"Blimp runs services that share a PID namespace in the same pod, so they must share a " +
+				"network namespace as well.\n" +
+				"Please add `network_mode: service:app` to service debug.",
+		},
+		{
+			name: "pid and indirect network",
+			services: []composeTypes.ServiceConfig{
+				{Name: "debug", NetworkMode: "service:vpn", Pid: "service:app"},
+				{Name: "app", NetworkMode: "service:vpn"},
+				{Name: "vpn"},
+			},
+			expOwners: map[string]string{
+				"app":   "vpn",
+				"debug": "vpn",
+			},
+		},
+		{
+			name: "init with shared pid",
+			services: []composeTypes.ServiceConfig{
+				{Name: "debug", NetworkMode: "service:app", Pid: "service:app"},
+				{Name: "app", Init: &init},
+			},
+			expErr: "Service app uses `init`, but runs in a shared PID namespace (because of `pid: service:`).\n" +
+				"Blimp doesn't support `init` for services that share a PID namespace.",
+		},
+		{
+			name: "stop_signal with shared pid",
+			services: []composeTypes.ServiceConfig{
+				{Name: "debug", NetworkMode: "service:app", Pid: "service:app", StopSignal: "SIGINT"},
+				{Name: "app"},
+			},
+			expErr: "Service debug uses `stop_signal`, but runs in a shared PID namespace (because of `pid: service:`).\n" +
+				"Blimp doesn't support `stop_signal` for services that share a PID namespace.",
+		},
+		{
+			name: "init with shared network",
+			services: []composeTypes.ServiceConfig{
+				{Name: "app", NetworkMode: "service:vpn", Init: &init, StopSignal: "SIGINT"},
+				{Name: "vpn"},
+			},
+			expOwners: map[string]string{
+				"app": "vpn",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			owners, err := podOwners(test.services)
+			if test.expErr != "" {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expOwners, owners)
+		})
+	}
+}
+
+func TestToPodsColocated(t *testing.T) {
+	cfg := composeTypes.Project{
+		Services: []composeTypes.ServiceConfig{
+			{
+				Name:  "vpn",
+				Image: "vpn",
+				Tmpfs: composeTypes.StringList{"/run"},
+			},
+			{
+				Name:        "app",
+				Image:       "app",
+				NetworkMode: "service:vpn",
+				Pid:         "service:vpn",
+				Tmpfs:       composeTypes.StringList{"/tmp"},
+				DependsOn: composeTypes.DependsOnConfig{
+					"vpn": {Condition: composeTypes.ServiceConditionStarted},
+					"db":  {Condition: composeTypes.ServiceConditionStarted},
+				},
+			},
+			{
+				Name:  "db",
+				Image: "postgres",
+			},
+		},
+	}
+
+	pods, configMaps, err := toPods(auth.User{Namespace: "namespace"}, "10.0.0.10", "10.0.0.11",
+		cfg, nil, nil, nil, securityPolicy{}, fakeImageInspector{})
+	assert.NoError(t, err)
+	assert.Len(t, pods, 2)
+
+	pod := pods[0]
+	assert.Equal(t, names.PodName("vpn", 0), pod.Name)
+	assert.Equal(t, "0", pod.Labels[metadata.ServiceLabel("app")])
+	assert.Equal(t, "app", pod.Annotations[metadata.ColocatedServicesKey])
+	assert.Equal(t, "app", pod.Annotations[metadata.AliasesKey])
+	assert.Equal(t, true, *pod.Spec.ShareProcessNamespace)
+
+	var containers []string
+	for _, c := range pod.Spec.Containers {
+		containers = append(containers, c.Name)
+	}
+	assert.Equal(t, []string{names.ToDNS1123("vpn"), names.ToDNS1123("app")}, containers)
+
+	// The dependency on vpn is dropped since it runs in the same pod.
+	var initContainers []string
+	for _, c := range pod.Spec.InitContainers {
+		initContainers = append(initContainers, c.Name)
+	}
+	assert.Equal(t, []string{kube.InitContainerName(kube.ContainerNameWaitDependsOn, 1)}, initContainers)
+	if assert.Len(t, configMaps, 1) {
+		var spec wait.WaitSpec
+		assert.NoError(t, proto.Unmarshal(configMaps[0].BinaryData["wait-spec"], &spec))
+		assert.Len(t, spec.DependsOn, 1)
+		assert.Equal(t, "db", spec.DependsOn[0].Service)
+	}
+
+	// The services' tmpfs volumes aren't shared.
+	var volumes []string
+	for _, v := range pod.Spec.Volumes {
+		volumes = append(volumes, v.Name)
+	}
+	assert.Equal(t, []string{"tmpfs-0", configMaps[0].Name, "tmpfs-0-1"}, volumes)
+	assert.Equal(t, []corev1.VolumeMount{{Name: "tmpfs-0-1", MountPath: "/tmp"}},
+		pod.Spec.Containers[1].VolumeMounts)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kelda/blimp/pkg/errors"
+	"github.com/kelda/blimp/pkg/kube"
+	"github.com/kelda/blimp/pkg/metadata"
+	"github.com/kelda/blimp/pkg/proto/cluster"
+)
+
+// deployParallelism is the maximum number of customer pods that are created,
+// updated, or deleted at once.
+const deployParallelism = 8
+
+// customerPodSanitizers ignore the fields of customer pods that are expected
+// to differ from the deployed pods.
+var customerPodSanitizers = []kube.Sanitizer{
+	kube.SanitizeIgnoreInitContainerImages,
+	kube.SanitizeIgnoreNodeAffinity,
+}
+
+// podChange is a change that needs to be made to a customer pod.
+type podChange struct {
+	pod    corev1.Pod
+	change cluster.DeployChange
+}
+
+// diffPods compares the desired customer pods to the currently deployed
+// customer pods. It returns a change for each pod, sorted by the pod's name.
+// Pods that are deployed but no longer desired are deleted, unless they run
+// one of the `kept` services. This way, services that aren't part of the
+// deployment because they failed to build keep running their last
+// successful build.
+func diffPods(curr, desired []corev1.Pod, kept map[string]string) ([]podChange, error) {
+	currByName := map[string]corev1.Pod{}
+	for _, pod := range curr {
+		currByName[pod.Name] = pod
+	}
+
+	var changes []podChange
+	for _, pod := range desired {
+		currPod, ok := currByName[pod.Name]
+		if !ok {
+			changes = append(changes, podChange{pod, cluster.DeployChange_CREATED})
+			continue
+		}
+		delete(currByName, pod.Name)
+
+		upToDate, err := kube.PodUpToDate(pod, currPod, customerPodSanitizers)
+		if err != nil {
+			return nil, err
+		}
+
+		change := cluster.DeployChange_UPDATED
+		if upToDate {
+			change = cluster.DeployChange_UNCHANGED
+		}
+		changes = append(changes, podChange{pod, change})
+	}
+
+	for _, pod := range currByName {
+		change := cluster.DeployChange_DELETED
+		for _, svc := range podServices(pod) {
+			if _, ok := kept[svc]; ok {
+				change = cluster.DeployChange_UNCHANGED
+				break
+			}
+		}
+		changes = append(changes, podChange{pod, change})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].pod.Name < changes[j].pod.Name
+	})
+	return changes, nil
+}
+
+// applyPodChanges makes the given changes, with up to `deployParallelism`
+// changes running at once. All the changes are attempted even if some of
+// them fail. It returns the error of the first failed change.
+func (s *server) applyPodChanges(changes []podChange) error {
+	errs := make([]error, len(changes))
+
+	var wg sync.WaitGroup
+	indices := make(chan int)
+	for i := 0; i < deployParallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				errs[i] = s.applyPodChange(changes[i])
+			}
+		}()
+	}
+
+	for i := range changes {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *server) applyPodChange(change podChange) error {
+	pod := change.pod
+	switch change.change {
+	case cluster.DeployChange_CREATED:
+		opts := kube.DeployPodOptions{Sanitizers: customerPodSanitizers}
+		if err := kube.DeployPod(s.kubeClient, pod, opts); err != nil {
+			return errors.WithContext("create", err)
+		}
+	case cluster.DeployChange_UPDATED:
+		// diffPods already compared the pods, so there's no need for
+		// DeployPod to compare them again.
+		opts := kube.DeployPodOptions{ForceRestart: true}
+		if err := kube.DeployPod(s.kubeClient, pod, opts); err != nil {
+			return errors.WithContext("update", err)
+		}
+	case cluster.DeployChange_DELETED:
+		if err := kube.DeletePod(s.kubeClient, pod.Namespace, pod.Name); err != nil {
+			return errors.WithContext("delete", err)
+		}
+	}
+	return nil
+}
+
+// summarizePodChanges returns how each service changed. Services with
+// multiple replicas, or that share a pod with other services, have multiple
+// pod changes. If the changes differ, the service is considered updated.
+func summarizePodChanges(changes []podChange) map[string]cluster.DeployChange {
+	summary := map[string]cluster.DeployChange{}
+	for _, change := range changes {
+		// Pods without a service, such as the reservation pod, aren't
+		// reported.
+		for _, svc := range podServices(change.pod) {
+			prev, ok := summary[svc]
+			if ok && prev != change.change {
+				summary[svc] = cluster.DeployChange_UPDATED
+			} else {
+				summary[svc] = change.change
+			}
+		}
+	}
+	return summary
+}
+
+// podServices returns the services that run in the given pod.
+func podServices(pod corev1.Pod) []string {
+	service, ok := pod.Labels["blimp.service"]
+	if !ok {
+		return nil
+	}
+
+	services := []string{service}
+	if colocated, ok := pod.Annotations[metadata.ColocatedServicesKey]; ok {
+		services = append(services, metadata.ParseColocatedServices(colocated)...)
+	}
+	return services
+}
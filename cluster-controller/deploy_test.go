@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kelda/blimp/pkg/metadata"
+	"github.com/kelda/blimp/pkg/proto/cluster"
+)
+
+func TestDiffPods(t *testing.T) {
+	web := customerPod("web-0", "web", "web-image")
+	db := customerPod("db-0", "db", "db-image")
+	cache := customerPod("cache-0", "cache", "cache-image")
+	changedWeb := customerPod("web-0", "web", "changed-image")
+
+	failed := customerPod("failed-0", "failed", "failed-image")
+
+	changes, err := diffPods(
+		[]corev1.Pod{deployed(web), deployed(db), deployed(cache), deployed(failed)},
+		[]corev1.Pod{changedWeb, db, customerPod("worker-0", "worker", "worker-image")},
+		map[string]string{"failed": "build failed"})
+	require.NoError(t, err)
+
+	actual := map[string]cluster.DeployChange{}
+	var names []string
+	for _, change := range changes {
+		actual[change.pod.Name] = change.change
+		names = append(names, change.pod.Name)
+	}
+	assert.Equal(t, map[string]cluster.DeployChange{
+		"web-0":    cluster.DeployChange_UPDATED,
+		"db-0":     cluster.DeployChange_UNCHANGED,
+		"cache-0":  cluster.DeployChange_DELETED,
+		"worker-0": cluster.DeployChange_CREATED,
+		// Services that failed to build keep their existing pods.
+		"failed-0": cluster.DeployChange_UNCHANGED,
+	}, actual)
+	assert.Equal(t, []string{"cache-0", "db-0", "failed-0", "web-0", "worker-0"}, names)
+}
+
+func TestSummarizePodChanges(t *testing.T) {
+	colocated := customerPod("vpn-0", "vpn", "vpn-image")
+	colocated.Annotations = map[string]string{metadata.ColocatedServicesKey: "app"}
+
+	reservation := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "reservation",
+			Labels: map[string]string{"blimp.customerPod": "true"},
+		},
+	}
+
+	summary := summarizePodChanges([]podChange{
+		{customerPod("web-0", "web", "web-image"), cluster.DeployChange_CREATED},
+		{customerPod("web-1", "web", "web-image"), cluster.DeployChange_UNCHANGED},
+		{customerPod("db-0", "db", "db-image"), cluster.DeployChange_UNCHANGED},
+		{colocated, cluster.DeployChange_DELETED},
+		{reservation, cluster.DeployChange_DELETED},
+	})
+	assert.Equal(t, map[string]cluster.DeployChange{
+		"web": cluster.DeployChange_UPDATED,
+		"db":  cluster.DeployChange_UNCHANGED,
+		"vpn": cluster.DeployChange_DELETED,
+		"app": cluster.DeployChange_DELETED,
+	}, summary)
+}
+
+func customerPod(name, service, image string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				"blimp.service":     service,
+				"blimp.customerPod": "true",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: service, Image: image}},
+		},
+	}
+}
+
+// deployed returns the pod as it would be after being deployed by
+// kube.DeployPod.
+func deployed(pod corev1.Pod) corev1.Pod {
+	annot, err := runtime.Encode(unstructured.UnstructuredJSONScheme, &pod)
+	if err != nil {
+		panic(err)
+	}
+
+	pod = *pod.DeepCopy()
+	pod.Annotations = map[string]string{"blimp.appliedObject": string(annot)}
+	return pod
+}
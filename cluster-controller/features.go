@@ -13,13 +13,6 @@ import (
 // GetUnsupportedFeatures checks for any references to unsupported features.
 func GetUnsupportedFeatures(cfg types.Project) []string {
 	var messages []string
-	if len(cfg.Secrets) != 0 {
-		messages = append(messages, "secrets")
-	}
-	if len(cfg.Configs) != 0 {
-		messages = append(messages, "configs")
-	}
-
 	messages = append(messages, validateVolumes(cfg.Volumes)...)
 	messages = append(messages, validateServices(cfg.Services)...)
 	messages = append(messages, validateNetworks(cfg.Networks)...)
@@ -38,7 +31,7 @@ func validateNetworks(networks map[string]types.NetworkConfig) []string {
 }
 
 func validateServices(services types.Services) []string {
-	return addPrefix("Service", validator{[]field{
+	messages := addPrefix("Service", validator{[]field{
 		{ID: ".Name"},
 		{ID: ".Build.Dockerfile"},
 		{ID: ".Build.Context"},
@@ -46,39 +39,85 @@ func validateServices(services types.Services) []string {
 		{ID: ".Build.Target"},
 		{ID: ".Build.Labels"},
 		{ID: ".Build.CacheFrom"},
+		{ID: ".Build.ExtraHosts"},
+		{ID: ".Build.Network"},
+		{ID: ".CapAdd"},
+		{ID: ".CapDrop"},
 		{ID: ".Command"},
+		{ID: ".Configs"},
+		{ID: ".CPUS"},
+		{ID: ".Deploy.Replicas"},
+		{ID: ".Deploy.Resources.Limits.NanoCPUs"},
+		{ID: ".Deploy.Resources.Limits.MemoryBytes"},
+		{ID: ".Deploy.Resources.Reservations.NanoCPUs"},
+		{ID: ".Deploy.Resources.Reservations.MemoryBytes"},
 		{ID: ".ContainerName"},
 		{ID: ".Entrypoint"},
 		{ID: ".Extends"},
 		{ID: ".DependsOn"},
+		{ID: ".DNS"},
+		{ID: ".DNSOpts"},
+		{ID: ".DNSSearch"},
 		{ID: ".Environment"},
 		{ID: ".EnvFile"},
 		{ID: ".ExtraHosts"},
 		{ID: ".Hostname"},
 		{ID: ".HealthCheck"},
 		{ID: ".Image"},
+		{ID: ".Init"},
 		{ID: ".Links"},
+		{ID: ".MemLimit"},
+		{ID: ".MemReservation"},
 		{ID: ".Networks.Aliases"},
+		{ID: ".NetworkMode"},
 		{ID: ".Ports.HostIP"},
 		{ID: ".Ports.Target"},
 		{ID: ".Ports.Published"},
-		{ID: ".Ports.Protocol", AllowedValues: []interface{}{"tcp"}},
+		{ID: ".Ports.Protocol", AllowedValues: []interface{}{"tcp", "udp"}},
 		{ID: ".Ports.Mode", AllowedValues: []interface{}{"ingress"}},
+		{ID: ".Scale"},
+		{ID: ".Pid"},
+		{ID: ".Privileged"},
+		{ID: ".ReadOnly"},
 		{ID: ".Restart", AllowedValues: []interface{}{"no", "always", "unless-stopped", "on-failure"}},
+		{ID: ".Secrets"},
+		{ID: ".SecurityOpt"},
+		{ID: ".ShmSize"},
 		{ID: ".StdinOpen"},
+		{ID: ".StopGracePeriod"},
+		{ID: ".StopSignal"},
+		{ID: ".Sysctls"},
 		{ID: ".Tty"},
-		{ID: ".Volumes.Type", AllowedValues: []interface{}{types.VolumeTypeBind, types.VolumeTypeVolume}},
+		{ID: ".Ulimits"},
+		{ID: ".Tmpfs"},
+		{ID: ".Volumes.Type", AllowedValues: []interface{}{
+			types.VolumeTypeBind, types.VolumeTypeVolume, types.VolumeTypeTmpfs}},
 		{ID: ".Volumes.Source"},
 		{ID: ".Volumes.Target"},
+		{ID: ".Volumes.Tmpfs.Size"},
 		{ID: ".WorkingDir"},
 		{ID: ".User"},
+		// Only the x-blimp block is sent to the cluster.
+		{ID: ".Extensions"},
 
-		// Meaningless.
+		// Copied to the pod's labels and annotations.
 		{ID: ".Labels"},
 		// Containers can access all ports on other services by default.
 		{ID: ".Expose"},
 		{ID: ".Extras"},
 	}}.GetUnsupportedFields(services))
+
+	// Services can only share the namespaces of other services, since they're
+	// run in the same pod.
+	for _, svc := range services {
+		if svc.NetworkMode != "" && !strings.HasPrefix(svc.NetworkMode, "service:") {
+			messages = append(messages, "Service.NetworkMode")
+		}
+		if svc.Pid != "" && !strings.HasPrefix(svc.Pid, "service:") {
+			messages = append(messages, "Service.Pid")
+		}
+	}
+	return messages
 }
 
 func validateVolumes(volumes map[string]types.VolumeConfig) []string {
@@ -36,14 +36,14 @@ func TestValidateFeatures(t *testing.T) {
 			cfg: types.Project{
 				Services: types.Services([]types.ServiceConfig{
 					{
-						Name:     "test",
-						Image:    "alpine",
-						Command:  types.ShellCommand([]string{"echo", "hello world"}),
-						ReadOnly: true,
+						Name:       "test",
+						Image:      "alpine",
+						Command:    types.ShellCommand([]string{"echo", "hello world"}),
+						MacAddress: "02:42:ac:11:65:43",
 					},
 				}),
 			},
-			exp: []string{"Service.ReadOnly"},
+			exp: []string{"Service.MacAddress"},
 		},
 
 		// Using a supported value for ports.
@@ -70,7 +70,7 @@ func TestValidateFeatures(t *testing.T) {
 						Name:  "test",
 						Image: "alpine",
 						Ports: []types.ServicePortConfig{
-							{Protocol: "udp"},
+							{Protocol: "sctp"},
 						},
 					},
 				}),
@@ -78,6 +78,56 @@ func TestValidateFeatures(t *testing.T) {
 			exp: []string{"Service.Ports.Protocol"},
 		},
 
+		// Sharing namespaces is only supported between services.
+		{
+			cfg: types.Project{
+				Services: types.Services([]types.ServiceConfig{
+					{
+						Name:  "vpn",
+						Image: "vpn",
+					},
+					{
+						Name:        "app",
+						Image:       "app",
+						NetworkMode: "service:vpn",
+						Pid:         "service:vpn",
+					},
+					{
+						Name:        "host",
+						Image:       "alpine",
+						NetworkMode: "host",
+						Pid:         "host",
+					},
+				}),
+			},
+			exp: []string{"Service.NetworkMode", "Service.Pid"},
+		},
+
+		// Using secrets and configs.
+		{
+			cfg: types.Project{
+				Services: types.Services([]types.ServiceConfig{
+					{
+						Name:  "test",
+						Image: "alpine",
+						Secrets: []types.ServiceSecretConfig{
+							{Source: "password", Target: "db-password"},
+						},
+						Configs: []types.ServiceConfigObjConfig{
+							{Source: "nginx", Target: "/etc/nginx/nginx.conf"},
+						},
+					},
+				}),
+				Secrets: map[string]types.SecretConfig{
+					"password": {File: "/home/user/password.txt"},
+				},
+				Configs: map[string]types.ConfigObjConfig{
+					"nginx": {File: "/home/user/nginx.conf"},
+				},
+			},
+			exp: nil,
+		},
+
 		// Using a supported field in volumes.
 		{
 			cfg: types.Project{
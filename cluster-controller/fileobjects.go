@@ -0,0 +1,306 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kelda/blimp/pkg/errors"
+	"github.com/kelda/blimp/pkg/kube"
+	"github.com/kelda/blimp/pkg/names"
+	"github.com/kelda/blimp/pkg/version"
+)
+
+// fileObjectKey is the key within each Kubernetes Secret or ConfigMap that
+// holds the contents of the Compose secret or config.
+const fileObjectKey = "contents"
+
+// fileObjectsHashKey is the pod annotation containing a hash of the contents
+// of the secrets and configs mounted into the pod. Kubernetes doesn't update
+// files that are mounted with a SubPath, so changing the hash causes the pod
+// to be recreated with the new contents.
+const fileObjectsHashKey = "blimp.fileObjectsHash"
+
+// secretName returns the name of the Kubernetes Secret that holds the
+// contents of the given Compose secret.
+func secretName(composeName string) string {
+	return names.ToDNS1123("secret-" + composeName)
+}
+
+// configName returns the name of the Kubernetes ConfigMap that holds the
+// contents of the given Compose config.
+func configName(composeName string) string {
+	return names.ToDNS1123("config-" + composeName)
+}
+
+// deploySecrets creates a Kubernetes Secret for each Compose secret, and
+// removes any secrets that are no longer referenced by the Compose file.
+func (s *server) deploySecrets(namespace string, secrets map[string][]byte) error {
+	desiredNames := map[string]struct{}{}
+	for name, contents := range secrets {
+		secret := corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName(name),
+				Namespace: namespace,
+				Labels:    map[string]string{"blimp.composeSecret": "true"},
+			},
+			Data: map[string][]byte{fileObjectKey: contents},
+		}
+		if err := kube.DeploySecret(s.kubeClient, secret); err != nil {
+			return errors.WithContext(fmt.Sprintf("deploy secret %s", name), err)
+		}
+		desiredNames[secret.Name] = struct{}{}
+	}
+
+	secretClient := s.kubeClient.CoreV1().Secrets(namespace)
+	currSecrets, err := secretClient.List(metav1.ListOptions{
+		LabelSelector: "blimp.composeSecret=true",
+	})
+	if err != nil {
+		return errors.WithContext("list secrets", err)
+	}
+
+	for _, secret := range currSecrets.Items {
+		if _, ok := desiredNames[secret.Name]; ok {
+			continue
+		}
+
+		if err := secretClient.Delete(secret.Name, nil); err != nil {
+			return errors.WithContext("delete stale secret", err)
+		}
+	}
+	return nil
+}
+
+// getSecrets returns the contents of the deployed Kubernetes Secrets for the
+// given Compose secrets. Secrets that haven't been deployed are ignored.
+func (s *server) getSecrets(namespace string, secrets map[string]composeTypes.SecretConfig) (
+	map[string][]byte, error) {
+	secretClient := s.kubeClient.CoreV1().Secrets(namespace)
+	contents := map[string][]byte{}
+	for name := range secrets {
+		secret, err := secretClient.Get(secretName(name), metav1.GetOptions{})
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return nil, errors.WithContext(fmt.Sprintf("get secret %s", name), err)
+		}
+		contents[name] = secret.Data[fileObjectKey]
+	}
+	return contents, nil
+}
+
+// deployConfigs creates a ConfigMap for each Compose config, and removes any
+// configs that are no longer referenced by the Compose file.
+func (s *server) deployConfigs(namespace string, configs map[string][]byte) error {
+	desiredNames := map[string]struct{}{}
+	for name, contents := range configs {
+		configMap := corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      configName(name),
+				Namespace: namespace,
+				Labels:    map[string]string{"blimp.composeConfig": "true"},
+			},
+			BinaryData: map[string][]byte{fileObjectKey: contents},
+		}
+		if err := kube.DeployConfigMap(s.kubeClient, configMap); err != nil {
+			return errors.WithContext(fmt.Sprintf("deploy config %s", name), err)
+		}
+		desiredNames[configMap.Name] = struct{}{}
+	}
+
+	configMapClient := s.kubeClient.CoreV1().ConfigMaps(namespace)
+	currConfigMaps, err := configMapClient.List(metav1.ListOptions{
+		LabelSelector: "blimp.composeConfig=true",
+	})
+	if err != nil {
+		return errors.WithContext("list configs", err)
+	}
+
+	for _, configMap := range currConfigMaps.Items {
+		if _, ok := desiredNames[configMap.Name]; ok {
+			continue
+		}
+
+		if err := configMapClient.Delete(configMap.Name, nil); err != nil {
+			return errors.WithContext("delete stale config", err)
+		}
+	}
+	return nil
+}
+
+// addSecrets mounts the secrets referenced by a service, and returns the
+// mounts that should be added to the service's container.
+func (p *podSpec) addSecrets(svc composeTypes.ServiceConfig) ([]corev1.VolumeMount, error) {
+	var refs []composeTypes.FileReferenceConfig
+	for _, ref := range svc.Secrets {
+		refs = append(refs, composeTypes.FileReferenceConfig(ref))
+	}
+
+	return p.addFileReferences(fileReferences{
+		kind:          "secret",
+		refs:          refs,
+		defaultDir:    "/run/secrets",
+		copyContainer: kube.ContainerNameCopySecrets,
+		volumeSource: func(name string, items []corev1.KeyToPath) corev1.VolumeSource {
+			return corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secretName(name),
+					Items:      items,
+				},
+			}
+		},
+	}, svc.Name)
+}
+
+// addConfigs mounts the configs referenced by a service, and returns the
+// mounts that should be added to the service's container.
+func (p *podSpec) addConfigs(svc composeTypes.ServiceConfig) ([]corev1.VolumeMount, error) {
+	var refs []composeTypes.FileReferenceConfig
+	for _, ref := range svc.Configs {
+		refs = append(refs, composeTypes.FileReferenceConfig(ref))
+	}
+
+	return p.addFileReferences(fileReferences{
+		kind:          "config",
+		refs:          refs,
+		defaultDir:    "/",
+		copyContainer: kube.ContainerNameCopyConfigs,
+		volumeSource: func(name string, items []corev1.KeyToPath) corev1.VolumeSource {
+			return corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: configName(name),
+					},
+					Items: items,
+				},
+			}
+		},
+	}, svc.Name)
+}
+
+// fileReferences describes how to mount the secrets or configs referenced by
+// a service.
+type fileReferences struct {
+	// kind is either "secret" or "config".
+	kind string
+	refs []composeTypes.FileReferenceConfig
+
+	// defaultDir is the directory that the objects are mounted into if their
+	// target isn't an absolute path.
+	defaultDir string
+
+	// copyContainer is the name of the init container used to set the owner
+	// of the mounted files.
+	copyContainer string
+
+	// volumeSource returns the volume that contains the contents of the
+	// given object.
+	volumeSource func(name string, items []corev1.KeyToPath) corev1.VolumeSource
+}
+
+func (p *podSpec) addFileReferences(f fileReferences, svcName string) ([]corev1.VolumeMount, error) {
+	copyVolume := f.kind + "s"
+	var mounts []corev1.VolumeMount
+	var copyCommands []string
+	var copyMounts []corev1.VolumeMount
+	for i, ref := range f.refs {
+		target := ref.Target
+		if target == "" {
+			target = ref.Source
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(f.defaultDir, target)
+		}
+
+		// Docker defaults to making the files world readable.
+		mode := int32(0444)
+		if ref.Mode != nil {
+			mode = int32(*ref.Mode)
+		}
+
+		objVolume := corev1.Volume{
+			Name: fmt.Sprintf("%s-%d", f.kind, i),
+			VolumeSource: f.volumeSource(ref.Source, []corev1.KeyToPath{
+				{Key: fileObjectKey, Path: fileObjectKey, Mode: &mode},
+			}),
+		}
+		p.addVolume(objVolume)
+
+		if ref.UID == "" && ref.GID == "" {
+			mounts = append(mounts, corev1.VolumeMount{
+				Name:      objVolume.Name,
+				MountPath: target,
+				SubPath:   fileObjectKey,
+				ReadOnly:  true,
+			})
+			continue
+		}
+
+		// Kubernetes doesn't support setting the owner of files in secret or
+		// ConfigMap volumes, so we copy the file into a separate volume and
+		// chown it from an init container.
+		uid, gid := "0", "0"
+		if ref.UID != "" {
+			uid = ref.UID
+		}
+		if ref.GID != "" {
+			gid = ref.GID
+		}
+		for _, id := range []string{uid, gid} {
+			if _, err := strconv.Atoi(id); err != nil {
+				return nil, errors.NewFriendlyError("Invalid uid or gid (%s) for %s %s in service %s.\n"+
+					"Only numeric IDs are allowed.", id, f.kind, ref.Source, svcName)
+			}
+		}
+
+		src := fmt.Sprintf("/%s-src/%d", copyVolume, i)
+		dst := fmt.Sprintf("/%s/%d", copyVolume, i)
+		copyMounts = append(copyMounts, corev1.VolumeMount{
+			Name:      objVolume.Name,
+			MountPath: src,
+		})
+		copyCommands = append(copyCommands, fmt.Sprintf("cp %s/%s %s && chown %s:%s %s && chmod %o %s",
+			src, fileObjectKey, dst, uid, gid, dst, mode, dst))
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      copyVolume,
+			MountPath: target,
+			SubPath:   strconv.Itoa(i),
+			ReadOnly:  true,
+		})
+	}
+
+	if len(copyCommands) != 0 {
+		p.addVolume(corev1.Volume{
+			Name: copyVolume,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{
+					Medium: corev1.StorageMediumMemory,
+				},
+			},
+		})
+
+		root := int64(0)
+		p.addInitContainers(corev1.Container{
+			Name:    f.copyContainer,
+			Image:   version.InitImage,
+			Command: []string{"sh", "-c", strings.Join(copyCommands, " && ")},
+			SecurityContext: &corev1.SecurityContext{
+				RunAsUser: &root,
+			},
+			VolumeMounts: append(copyMounts, corev1.VolumeMount{
+				Name:      copyVolume,
+				MountPath: "/" + copyVolume,
+			}),
+		})
+	}
+
+	return mounts, nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"testing"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kelda/blimp/pkg/auth"
+	"github.com/kelda/blimp/pkg/kube"
+	"github.com/kelda/blimp/pkg/version"
+)
+
+func TestAddSecrets(t *testing.T) {
+	mode := uint32(0400)
+	readOnly := int32(0444)
+	ownerOnly := int32(0400)
+
+	tests := []struct {
+		name              string
+		secrets           []composeTypes.ServiceSecretConfig
+		expMounts         []corev1.VolumeMount
+		expVolumes        []corev1.Volume
+		expInitContainers []corev1.Container
+		expErr            bool
+	}{
+		{
+			name: "default target",
+			secrets: []composeTypes.ServiceSecretConfig{
+				{Source: "token"},
+				{Source: "cert", Target: "/etc/ssl/cert.pem"},
+			},
+			expMounts: []corev1.VolumeMount{
+				{Name: "secret-0", MountPath: "/run/secrets/token", SubPath: fileObjectKey, ReadOnly: true},
+				{Name: "secret-1", MountPath: "/etc/ssl/cert.pem", SubPath: fileObjectKey, ReadOnly: true},
+			},
+			expVolumes: []corev1.Volume{
+				secretVolume("secret-0", secretName("token"), readOnly),
+				secretVolume("secret-1", secretName("cert"), readOnly),
+			},
+		},
+		{
+			name: "owner",
+			secrets: []composeTypes.ServiceSecretConfig{
+				{Source: "token", UID: "1000", Mode: &mode},
+			},
+			expMounts: []corev1.VolumeMount{
+				{Name: "secrets", MountPath: "/run/secrets/token", SubPath: "0", ReadOnly: true},
+			},
+			expVolumes: []corev1.Volume{
+				secretVolume("secret-0", secretName("token"), ownerOnly),
+				{
+					Name: "secrets",
+					VolumeSource: corev1.VolumeSource{
+						EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
+					},
+				},
+			},
+			expInitContainers: []corev1.Container{
+				{
+					Name:  kube.ContainerNameCopySecrets,
+					Image: version.InitImage,
+					Command: []string{"sh", "-c",
+						"cp /secrets-src/0/contents /secrets/0 && chown 1000:0 /secrets/0 && chmod 400 /secrets/0"},
+					SecurityContext: &corev1.SecurityContext{RunAsUser: func() *int64 {
+						root := int64(0)
+						return &root
+					}()},
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "secret-0", MountPath: "/secrets-src/0"},
+						{Name: "secrets", MountPath: "/secrets"},
+					},
+				},
+			},
+		},
+		{
+			name: "non-numeric owner",
+			secrets: []composeTypes.ServiceSecretConfig{
+				{Source: "token", UID: "app"},
+			},
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var spec podSpec
+			mounts, err := spec.addSecrets(composeTypes.ServiceConfig{Name: "web", Secrets: test.secrets})
+			if test.expErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expMounts, mounts)
+			assert.Equal(t, test.expVolumes, spec.pod.Spec.Volumes)
+			assert.Equal(t, test.expInitContainers, spec.pod.Spec.InitContainers)
+		})
+	}
+}
+
+func TestFileObjectsHash(t *testing.T) {
+	cfg := composeTypes.Project{
+		Services: composeTypes.Services{
+			{
+				Name:    "web",
+				Image:   "web",
+				Secrets: []composeTypes.ServiceSecretConfig{{Source: "token"}},
+				Configs: []composeTypes.ServiceConfigObjConfig{{Source: "nginx"}},
+			},
+			{
+				Name:  "db",
+				Image: "postgres",
+			},
+		},
+	}
+
+	getAnnotations := func(configs, secrets map[string][]byte) (string, string) {
+		pods, _, err := toPods(auth.User{Namespace: "namespace"}, "10.0.0.10", "10.0.0.11",
+			cfg, nil, configs, secrets, securityPolicy{}, fakeImageInspector{})
+		require.NoError(t, err)
+		require.Len(t, pods, 2)
+		return pods[0].Annotations[fileObjectsHashKey], pods[1].Annotations[fileObjectsHashKey]
+	}
+
+	configs := map[string][]byte{"nginx": []byte("config")}
+	secrets := map[string][]byte{"token": []byte("secret")}
+	webHash, dbHash := getAnnotations(configs, secrets)
+	assert.NotEmpty(t, webHash)
+	assert.Empty(t, dbHash)
+
+	changedSecretHash, _ := getAnnotations(configs, map[string][]byte{"token": []byte("changed")})
+	assert.NotEqual(t, webHash, changedSecretHash)
+
+	changedConfigHash, _ := getAnnotations(map[string][]byte{"nginx": []byte("changed")}, secrets)
+	assert.NotEqual(t, webHash, changedConfigHash)
+	assert.NotEqual(t, changedSecretHash, changedConfigHash)
+}
+
+func secretVolume(name, secret string, mode int32) corev1.Volume {
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: secret,
+				Items:      []corev1.KeyToPath{{Key: fileObjectKey, Path: fileObjectKey, Mode: &mode}},
+			},
+		},
+	}
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kelda/blimp/pkg/errors"
+	"github.com/kelda/blimp/pkg/proto/cluster"
+)
+
+// imageInspector fetches information about the images that services run.
+type imageInspector interface {
+	// Config returns the config (e.g. the entrypoint and command) of the
+	// given image.
+	Config(image string) (v1.Config, error)
+
+	// ReadFiles returns the contents of the given files in the image's
+	// filesystem. Files that don't exist in the image are omitted.
+	ReadFiles(image string, paths ...string) (map[string][]byte, error)
+}
+
+// maxCachedImages is the maximum number of entries in each of the imageCache
+// maps.
+const maxCachedImages = 1000
+
+// imageCache caches image configs and files by the image's digest, so that
+// they're only fetched from the registry the first time an image is
+// deployed, rather than on every deploy. It's shared between sandboxes since
+// the digest identifies the image's contents. Sandboxes still resolve the
+// digest with their own credentials, so they can only read cached images
+// that they have access to.
+type imageCache struct {
+	lock    sync.Mutex
+	configs map[v1.Hash]v1.Config
+	files   map[string]map[string][]byte
+}
+
+func newImageCache() *imageCache {
+	return &imageCache{
+		configs: map[v1.Hash]v1.Config{},
+		files:   map[string]map[string][]byte{},
+	}
+}
+
+func (cache *imageCache) getConfig(digest v1.Hash) (v1.Config, bool) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	config, ok := cache.configs[digest]
+	return config, ok
+}
+
+func (cache *imageCache) putConfig(digest v1.Hash, config v1.Config) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	if len(cache.configs) >= maxCachedImages {
+		for key := range cache.configs {
+			delete(cache.configs, key)
+			break
+		}
+	}
+	cache.configs[digest] = config
+}
+
+func (cache *imageCache) getFiles(key string) (map[string][]byte, bool) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	files, ok := cache.files[key]
+	return files, ok
+}
+
+func (cache *imageCache) putFiles(key string, files map[string][]byte) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	if len(cache.files) >= maxCachedImages {
+		for key := range cache.files {
+			delete(cache.files, key)
+			break
+		}
+	}
+	cache.files[key] = files
+}
+
+// registryImageInspector is an imageInspector that pulls images with the
+// registry credentials for a sandbox. The credentials are only fetched when
+// the first image is requested. Each image's manifest is fetched once per
+// inspector to resolve its digest, and the config and files are only fetched
+// if they aren't already in the shared cache.
+type registryImageInspector struct {
+	getRegistryCredentials func() (map[string]*cluster.RegistryCredential, error)
+	cache                  *imageCache
+
+	lock        sync.Mutex
+	creds       map[string]*cluster.RegistryCredential
+	descriptors map[string]*remote.Descriptor
+	images      map[string]v1.Image
+}
+
+func (s *server) newImageInspector(namespace string) *registryImageInspector {
+	return newRegistryImageInspector(s.imageCache, func() (map[string]*cluster.RegistryCredential, error) {
+		return s.getRegistryCredentials(namespace)
+	})
+}
+
+func newRegistryImageInspector(cache *imageCache,
+	getRegistryCredentials func() (map[string]*cluster.RegistryCredential, error)) *registryImageInspector {
+	return &registryImageInspector{
+		getRegistryCredentials: getRegistryCredentials,
+		cache:                  cache,
+		descriptors:            map[string]*remote.Descriptor{},
+		images:                 map[string]v1.Image{},
+	}
+}
+
+func (inspector *registryImageInspector) Config(image string) (v1.Config, error) {
+	inspector.lock.Lock()
+	defer inspector.lock.Unlock()
+
+	desc, err := inspector.getDescriptor(image)
+	if err != nil {
+		return v1.Config{}, err
+	}
+
+	if config, ok := inspector.cache.getConfig(desc.Digest); ok {
+		return config, nil
+	}
+
+	img, err := inspector.getImage(image, desc)
+	if err != nil {
+		return v1.Config{}, err
+	}
+
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return v1.Config{}, errors.WithContext("get image config", err)
+	}
+
+	inspector.cache.putConfig(desc.Digest, configFile.Config)
+	return configFile.Config, nil
+}
+
+func (inspector *registryImageInspector) ReadFiles(image string, paths ...string) (map[string][]byte, error) {
+	inspector.lock.Lock()
+	defer inspector.lock.Unlock()
+
+	desc, err := inspector.getDescriptor(image)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := desc.Digest.String() + "\x00" + strings.Join(paths, "\x00")
+	if files, ok := inspector.cache.getFiles(cacheKey); ok {
+		return files, nil
+	}
+
+	img, err := inspector.getImage(image, desc)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := readImageFiles(img, paths)
+	if err != nil {
+		return nil, errors.WithContext("read image files", err)
+	}
+
+	inspector.cache.putFiles(cacheKey, files)
+	return files, nil
+}
+
+// getDescriptor fetches the manifest of the given image, which identifies
+// the image's digest. The caller must hold the lock.
+func (inspector *registryImageInspector) getDescriptor(image string) (*remote.Descriptor, error) {
+	if desc, ok := inspector.descriptors[image]; ok {
+		return desc, nil
+	}
+
+	if inspector.creds == nil {
+		creds, err := inspector.getRegistryCredentials()
+		if err != nil {
+			return nil, errors.WithContext("get registry credentials", err)
+		}
+		inspector.creds = creds
+	}
+
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return nil, errors.WithContext("parse image reference", err)
+	}
+
+	desc, err := remote.Get(ref, remote.WithAuth(registryAuthenticator(ref, inspector.creds)))
+	if err != nil {
+		return nil, errors.WithContext("get image", err)
+	}
+
+	inspector.descriptors[image] = desc
+	return desc, nil
+}
+
+// getImage returns a handle to the image described by `desc`. The caller
+// must hold the lock.
+func (inspector *registryImageInspector) getImage(image string, desc *remote.Descriptor) (v1.Image, error) {
+	if img, ok := inspector.images[image]; ok {
+		return img, nil
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return nil, errors.WithContext("get image", err)
+	}
+
+	inspector.images[image] = img
+	return img, nil
+}
+
+// readImageFiles returns the contents of the given files in the image's
+// filesystem. It searches the layers starting from the top, so that it only
+// has to pull the layers above the ones that last modified the files.
+func readImageFiles(img v1.Image, paths []string) (map[string][]byte, error) {
+	// Paths within layers are relative to the root.
+	remaining := map[string]string{}
+	for _, p := range paths {
+		remaining[layerPath(p)] = p
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, errors.WithContext("get layers", err)
+	}
+
+	files := map[string][]byte{}
+	for i := len(layers) - 1; i >= 0 && len(remaining) != 0; i-- {
+		if err := readLayerFiles(layers[i], remaining, files); err != nil {
+			return nil, errors.WithContext("read layer", err)
+		}
+	}
+	return files, nil
+}
+
+// readLayerFiles reads the files in `remaining` from the layer into `files`.
+// Files that are found, or deleted by the layer, are removed from
+// `remaining` so that they aren't read from lower layers.
+func readLayerFiles(layer v1.Layer, remaining map[string]string, files map[string][]byte) error {
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// Apply deletions after reading the entire layer since whiteouts only
+	// apply to lower layers.
+	var deleted []string
+	tr := tar.NewReader(rc)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		entryPath := layerPath(header.Name)
+		dir, base := path.Split(entryPath)
+		switch {
+		case base == ".wh..wh..opq":
+			// An opaque whiteout hides everything in the directory from lower
+			// layers.
+			for p := range remaining {
+				if strings.HasPrefix(p, dir) {
+					deleted = append(deleted, p)
+				}
+			}
+		case strings.HasPrefix(base, ".wh."):
+			deleted = append(deleted, dir+strings.TrimPrefix(base, ".wh."))
+		default:
+			origPath, ok := remaining[entryPath]
+			if !ok {
+				continue
+			}
+
+			delete(remaining, entryPath)
+			if header.Typeflag != tar.TypeReg {
+				// We don't follow links, so treat them as missing.
+				continue
+			}
+
+			contents, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+			files[origPath] = contents
+		}
+	}
+
+	for _, p := range deleted {
+		delete(remaining, p)
+	}
+	return nil
+}
+
+func layerPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+// getRegistryCredentials returns the credentials that are used to pull
+// images in the given sandbox.
+func (s *server) getRegistryCredentials(namespace string) (map[string]*cluster.RegistryCredential, error) {
+	secret, err := s.kubeClient.CoreV1().Secrets(namespace).Get("registry-auth", metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.WithContext("get regcred secret", err)
+	}
+
+	var dockerConfig struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &dockerConfig); err != nil {
+		return nil, errors.WithContext("parse regcred secret", err)
+	}
+
+	creds := map[string]*cluster.RegistryCredential{}
+	for host, cred := range dockerConfig.Auths {
+		creds[host] = &cluster.RegistryCredential{
+			Username: cred.Username,
+			Password: cred.Password,
+		}
+	}
+	return creds, nil
+}
+
+// registryAuthenticator picks the credential to use when pulling the given
+// image.
+func registryAuthenticator(ref name.Reference, creds map[string]*cluster.RegistryCredential) authn.Authenticator {
+	for registry, cred := range creds {
+		if cred.GetUsername() == "" && cred.GetPassword() == "" {
+			continue
+		}
+
+		refRegistry := ref.Context().Registry
+
+		// Specially handle index.docker.io.
+		// This doesn't actually make sense, but is correct. See
+		// https://github.com/google/go-containerregistry/pull/456#issuecomment-499233027.
+		if registry == authn.DefaultAuthKey && refRegistry.Name() == name.DefaultRegistry {
+			return &authn.Basic{
+				Username: cred.GetUsername(),
+				Password: cred.GetPassword(),
+			}
+		}
+
+		// Usually hostnames from auth config do not have a scheme (http/https)
+		// specified, but it seems like sometimes they do. We try both.
+		if registry == refRegistry.Name() ||
+			registry == fmt.Sprintf("%s://%s", refRegistry.Scheme(), refRegistry.Name()) {
+			return &authn.Basic{
+				Username: cred.GetUsername(),
+				Password: cred.GetPassword(),
+			}
+		}
+	}
+	return authn.Anonymous
+}
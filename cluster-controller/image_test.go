@@ -0,0 +1,188 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kelda/blimp/pkg/proto/cluster"
+)
+
+func TestReadImageFiles(t *testing.T) {
+	// makeLayer creates a layer containing the given files. Files with nil
+	// contents are created as symlinks.
+	makeLayer := func(files map[string][]byte) v1.Layer {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for name, contents := range files {
+			header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))}
+			if contents == nil {
+				header.Typeflag = tar.TypeSymlink
+				header.Linkname = "/somewhere"
+			}
+			require.NoError(t, tw.WriteHeader(header))
+			_, err := tw.Write(contents)
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+
+		layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(buf.Bytes())), nil
+		})
+		require.NoError(t, err)
+		return layer
+	}
+
+	tests := []struct {
+		name   string
+		layers []map[string][]byte
+		exp    map[string][]byte
+	}{
+		{
+			name: "files in different layers",
+			layers: []map[string][]byte{
+				{"etc/passwd": []byte("base"), "etc/group": []byte("group")},
+				{"./etc/passwd": []byte("overwritten")},
+				{"usr/bin/app": []byte("app")},
+			},
+			exp: map[string][]byte{
+				"/etc/passwd": []byte("overwritten"),
+				"/etc/group":  []byte("group"),
+			},
+		},
+		{
+			name: "missing file",
+			layers: []map[string][]byte{
+				{"etc/passwd": []byte("passwd")},
+			},
+			exp: map[string][]byte{
+				"/etc/passwd": []byte("passwd"),
+			},
+		},
+		{
+			name: "whiteout",
+			layers: []map[string][]byte{
+				{"etc/passwd": []byte("passwd"), "etc/group": []byte("group")},
+				{"etc/.wh.passwd": []byte{}},
+			},
+			exp: map[string][]byte{
+				"/etc/group": []byte("group"),
+			},
+		},
+		{
+			name: "opaque whiteout",
+			layers: []map[string][]byte{
+				{"etc/passwd": []byte("passwd"), "etc/group": []byte("group")},
+				{"etc/.wh..wh..opq": []byte{}, "etc/group": []byte("new group")},
+			},
+			exp: map[string][]byte{
+				"/etc/group": []byte("new group"),
+			},
+		},
+		{
+			name: "symlink",
+			layers: []map[string][]byte{
+				{"etc/passwd": []byte("passwd")},
+				{"etc/passwd": nil},
+			},
+			exp: map[string][]byte{},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var layers []v1.Layer
+			for _, files := range test.layers {
+				layers = append(layers, makeLayer(files))
+			}
+
+			img, err := mutate.AppendLayers(empty.Image, layers...)
+			require.NoError(t, err)
+
+			files, err := readImageFiles(img, []string{passwdPath, groupPath})
+			assert.NoError(t, err)
+			assert.Equal(t, test.exp, files)
+		})
+	}
+}
+
+func TestRegistryImageInspectorCache(t *testing.T) {
+	// Count the blobs (configs and layers) that are fetched from the
+	// registry.
+	var blobFetches int32
+	reg := registry.New(registry.Logger(log.New(ioutil.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/blobs/") {
+			atomic.AddInt32(&blobFetches, 1)
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	image := strings.TrimPrefix(server.URL, "http://") + "/app:latest"
+	push := func(entrypoint string) {
+		img, err := random.Image(64, 1)
+		require.NoError(t, err)
+		img, err = mutate.Config(img, v1.Config{Entrypoint: []string{entrypoint}})
+		require.NoError(t, err)
+
+		ref, err := name.ParseReference(image)
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(ref, img))
+	}
+
+	cache := newImageCache()
+	newInspector := func() *registryImageInspector {
+		return newRegistryImageInspector(cache, func() (map[string]*cluster.RegistryCredential, error) {
+			return map[string]*cluster.RegistryCredential{}, nil
+		})
+	}
+
+	push("v1")
+	atomic.StoreInt32(&blobFetches, 0)
+
+	// The first deploy fetches the config and layer.
+	first := newInspector()
+	config, err := first.Config(image)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1"}, config.Entrypoint)
+	_, err = first.ReadFiles(image, passwdPath)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&blobFetches))
+
+	// Later deploys of the same image are served from the cache.
+	second := newInspector()
+	config, err = second.Config(image)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1"}, config.Entrypoint)
+	_, err = second.ReadFiles(image, passwdPath)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&blobFetches))
+
+	// If the tag is updated, the new image is fetched.
+	push("v2")
+	atomic.StoreInt32(&blobFetches, 0)
+	config, err = newInspector().Config(image)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v2"}, config.Entrypoint)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&blobFetches))
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/kelda/blimp/pkg/metadata"
+)
+
+// reservedLabelPrefixes are the prefixes of the pod labels and annotations
+// that Blimp interprets. Service labels with these prefixes aren't copied to
+// pods since they could break Blimp.
+var reservedLabelPrefixes = []string{
+	"blimp.",
+	"io.kelda.blimp",
+	metadata.NetworkLabelPrefix,
+	metadata.ServiceLabelPrefix,
+}
+
+// reservedLabelDomains are the label prefixes that are reserved for
+// Kubernetes. Service labels under these domains, or their subdomains, aren't
+// copied to pods since they could bypass the security policy (e.g. by
+// disabling the container's AppArmor profile), or change how the cluster
+// manages the pod.
+var reservedLabelDomains = []string{"kubernetes.io", "k8s.io"}
+
+var (
+	invalidLabelPrefixChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+	invalidLabelNameChars   = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// toPodMetadata converts the service's labels into pod labels. Unprefixed
+// labels with values that aren't valid Kubernetes label values (e.g. because
+// they contain spaces) are converted into annotations instead, so that
+// they're still visible to tools that inspect the pod. Prefixed labels aren't
+// converted since cluster add-ons, such as CNI plugins, act on prefixed
+// annotations.
+func toPodMetadata(svc composeTypes.ServiceConfig) (labels, annotations map[string]string) {
+	labels = map[string]string{}
+	annotations = map[string]string{}
+	for key, value := range svc.Labels {
+		sanitized, ok := sanitizeLabelKey(key)
+		if !ok {
+			log.WithField("service", svc.Name).WithField("label", key).
+				Warn("Ignoring label that can't be converted to a Kubernetes label")
+			continue
+		}
+
+		if isReservedLabel(sanitized) {
+			continue
+		}
+
+		switch {
+		case len(validation.IsValidLabelValue(value)) == 0:
+			labels[sanitized] = value
+		case !strings.Contains(sanitized, "/"):
+			annotations[sanitized] = value
+		default:
+			log.WithField("service", svc.Name).WithField("label", key).
+				Warn("Ignoring prefixed label with a value that isn't a valid Kubernetes label value")
+		}
+	}
+	return labels, annotations
+}
+
+// sanitizeLabelKey converts the Docker label key into a valid Kubernetes
+// label key by replacing invalid characters with dashes.
+func sanitizeLabelKey(key string) (string, bool) {
+	if len(validation.IsQualifiedName(key)) == 0 {
+		return key, true
+	}
+
+	var prefix, name string
+	if parts := strings.SplitN(key, "/", 2); len(parts) == 2 {
+		prefix, name = parts[0], parts[1]
+	} else {
+		name = parts[0]
+	}
+
+	trim := func(str string, maxLen int) string {
+		if len(str) > maxLen {
+			str = str[:maxLen]
+		}
+		return strings.Trim(str, "-_.")
+	}
+
+	name = trim(invalidLabelNameChars.ReplaceAllString(name, "-"), validation.DNS1123LabelMaxLength)
+	if prefix != "" {
+		prefix = trim(invalidLabelPrefixChars.ReplaceAllString(strings.ToLower(prefix), "-"),
+			validation.DNS1123SubdomainMaxLength)
+		name = prefix + "/" + name
+	}
+
+	if len(validation.IsQualifiedName(name)) != 0 {
+		return "", false
+	}
+	return name, true
+}
+
+func isReservedLabel(key string) bool {
+	for _, prefix := range reservedLabelPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 {
+		return false
+	}
+
+	domain := parts[0]
+	for _, reserved := range reservedLabelDomains {
+		if domain == reserved || strings.HasSuffix(domain, "."+reserved) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"testing"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToPodMetadata(t *testing.T) {
+	tests := []struct {
+		name           string
+		labels         composeTypes.Labels
+		expLabels      map[string]string
+		expAnnotations map[string]string
+	}{
+		{
+			name: "valid labels",
+			labels: composeTypes.Labels{
+				"com.example.team": "payments",
+				"example.com/role": "api",
+				"empty":            "",
+			},
+			expLabels: map[string]string{
+				"com.example.team": "payments",
+				"example.com/role": "api",
+				"empty":            "",
+			},
+			expAnnotations: map[string]string{},
+		},
+		{
+			name: "invalid values become annotations",
+			labels: composeTypes.Labels{
+				"com.example.description": "The payments API",
+			},
+			expLabels: map[string]string{},
+			expAnnotations: map[string]string{
+				"com.example.description": "The payments API",
+			},
+		},
+		{
+			name: "prefixed labels with invalid values are ignored",
+			labels: composeTypes.Labels{
+				"example.com/description":     "The payments API",
+				"k8s.v1.cni.cncf.io/networks": `[{"name": "macvlan"}]`,
+			},
+			expLabels:      map[string]string{},
+			expAnnotations: map[string]string{},
+		},
+		{
+			name: "invalid keys are sanitized",
+			labels: composeTypes.Labels{
+				"traefik.http.routers.web.rule":   "Host(`example.com`)",
+				"com.example.owner email":         "dev",
+				"Example.COM/some key":            "value",
+				"com.example/-leading-and-trail-": "value",
+			},
+			expLabels: map[string]string{
+				"com.example.owner-email":       "dev",
+				"example.com/some-key":          "value",
+				"com.example/leading-and-trail": "value",
+			},
+			expAnnotations: map[string]string{
+				"traefik.http.routers.web.rule": "Host(`example.com`)",
+			},
+		},
+		{
+			name: "reserved labels are ignored",
+			labels: composeTypes.Labels{
+				"blimp.service":             "other",
+				"io.kelda.blimp.restart":    "true",
+				"network.blimp/default":     "false",
+				"io.kelda.blimp/aliases":    "evil",
+				"com.example.blimp.service": "ok",
+			},
+			expLabels: map[string]string{
+				"com.example.blimp.service": "ok",
+			},
+			expAnnotations: map[string]string{},
+		},
+		{
+			name: "kubernetes labels are ignored",
+			labels: composeTypes.Labels{
+				"container.apparmor.security.beta.kubernetes.io/web": "localhost/unconfined",
+				"container.seccomp.security.alpha.kubernetes.io/web": "unconfined",
+				"seccomp.security.alpha.kubernetes.io/pod":           "unconfined",
+				"kubernetes.io/egress-bandwidth":                     "10M",
+				"cluster-autoscaler.kubernetes.io/safe-to-evict":     "false",
+				"k8s.io/example":               "value",
+				"example.k8s.io/example":       "value",
+				"io.kubernetes.example":        "ok",
+				"example.com/kubernetes.io":    "ok",
+				"kubernetes.io.example.com/ok": "ok",
+			},
+			expLabels: map[string]string{
+				"io.kubernetes.example":        "ok",
+				"example.com/kubernetes.io":    "ok",
+				"kubernetes.io.example.com/ok": "ok",
+			},
+			expAnnotations: map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			labels, annotations := toPodMetadata(composeTypes.ServiceConfig{Name: "web", Labels: test.labels})
+			assert.Equal(t, test.expLabels, labels)
+			assert.Equal(t, test.expAnnotations, annotations)
+		})
+	}
+}
@@ -15,7 +15,6 @@ import (
 
 	"github.com/Masterminds/semver"
 	"github.com/golang/protobuf/proto"
-	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	composeTypes "github.com/kelda/compose-go/types"
@@ -23,7 +22,6 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	corev1 "k8s.io/api/core/v1"
-	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -44,7 +42,6 @@ import (
 	"github.com/kelda/blimp/pkg/kube"
 	"github.com/kelda/blimp/pkg/kubewait"
 	"github.com/kelda/blimp/pkg/metadata"
-	"github.com/kelda/blimp/pkg/names"
 	"github.com/kelda/blimp/pkg/ports"
 	protoAuth "github.com/kelda/blimp/pkg/proto/auth"
 	"github.com/kelda/blimp/pkg/proto/cluster"
@@ -65,6 +62,8 @@ type server struct {
 	statusFetcher     *statusFetcher
 	certPath, keyPath string
 	maxSandboxes      int
+	securityPolicy    securityPolicy
+	imageCache        *imageCache
 }
 
 var (
@@ -117,12 +116,14 @@ func main() {
 	log.Infof("Capping maximum concurrent sandboxes to %d", maxSandboxes)
 
 	s := &server{
-		statusFetcher: newStatusFetcher(kubeClient),
-		kubeClient:    kubeClient,
-		restConfig:    restConfig,
-		certPath:      *certPath,
-		keyPath:       *keyPath,
-		maxSandboxes:  maxSandboxes,
+		statusFetcher:  newStatusFetcher(kubeClient),
+		kubeClient:     kubeClient,
+		restConfig:     restConfig,
+		certPath:       *certPath,
+		keyPath:        *keyPath,
+		maxSandboxes:   maxSandboxes,
+		securityPolicy: securityPolicyFromEnv(),
+		imageCache:     newImageCache(),
 	}
 	s.statusFetcher.Start(nil)
 
@@ -310,7 +311,7 @@ func (s *server) GetBuildkit(ctx context.Context, req *cluster.GetBuildkitReques
 		}
 	}
 
-	if err := createBuildkitd(s.kubeClient, user.Namespace); err != nil {
+	if err := createBuildkitd(ctx, s.kubeClient, user.Namespace); err != nil {
 		return &cluster.GetBuildkitResponse{}, errors.WithContext("deploy buildkitd", err)
 	}
 
@@ -396,6 +397,10 @@ func (s *server) CreateSandbox(ctx context.Context, req *cluster.CreateSandboxRe
 		return &cluster.CreateSandboxResponse{}, errors.WithContext("create namespace", err)
 	}
 
+	if err := s.deploySecrets(namespace, req.GetSecrets()); err != nil {
+		return &cluster.CreateSandboxResponse{}, errors.WithContext("deploy secrets", err)
+	}
+
 	// If customer pods are already present in the namespace, don't worry about
 	// creating a reservation pod.
 	customerPods, err := s.statusFetcher.podLister.Pods(namespace).
@@ -409,7 +414,7 @@ func (s *server) CreateSandbox(ctx context.Context, req *cluster.CreateSandboxRe
 		// will ultimately be deployed, to make sure that the namespace is
 		// scheduled on a node that ultimately will be able to handle the
 		// workload.
-		if err := s.createReservation(user, len(dcCfg.Services)); err != nil {
+		if err := s.createReservation(user, dcCfg.Services); err != nil {
 			return &cluster.CreateSandboxResponse{}, errors.WithContext("deploy reservation", err)
 		}
 
@@ -431,7 +436,7 @@ func (s *server) CreateSandbox(ctx context.Context, req *cluster.CreateSandboxRe
 		return &cluster.CreateSandboxResponse{}, errors.WithContext("deploy syncthing", err)
 	}
 
-	if err := createBuildkitd(s.kubeClient, namespace); err != nil {
+	if err := createBuildkitd(ctx, s.kubeClient, namespace); err != nil {
 		return &cluster.CreateSandboxResponse{}, errors.WithContext("deploy buildkitd", err)
 	}
 
@@ -508,6 +513,8 @@ func (s *server) DeployToSandbox(ctx context.Context, req *cluster.DeployRequest
 	if err != nil {
 		return &cluster.DeployResponse{}, err
 	}
+	// The project name isn't included in the serialized Compose file.
+	dcCfg.Name = req.GetProjectName()
 
 	namespace := user.Namespace
 	dnsPod, err := s.getPod(ctx, namespace, "dns", podIsReady)
@@ -520,7 +527,33 @@ func (s *server) DeployToSandbox(ctx context.Context, req *cluster.DeployRequest
 		return &cluster.DeployResponse{}, errors.WithContext("get node controller's IP", err)
 	}
 
-	customerPods, configMaps, err := toPods(user, dnsPod.Status.PodIP, nodeControllerIP, dcCfg, req.BuiltImages)
+	// Services whose images failed to build aren't updated, and are marked
+	// as failed in the sandbox's status instead. Their networks are still
+	// deployed since their pods from the last successful build keep running.
+	allServices := dcCfg.Services
+	var buildErrors map[string]string
+	dcCfg.Services, buildErrors = withoutFailedBuilds(dcCfg.Services, req.GetBuildErrors())
+	if err := s.setBuildErrors(namespace, buildErrors); err != nil {
+		return &cluster.DeployResponse{}, errors.WithContext("record build errors", err)
+	}
+
+	if err := s.deployConfigs(namespace, req.GetConfigs()); err != nil {
+		return &cluster.DeployResponse{}, errors.WithContext("deploy configs", err)
+	}
+
+	if err := s.deployNetworkPolicies(namespace, allServices); err != nil {
+		return &cluster.DeployResponse{}, errors.WithContext("deploy networks", err)
+	}
+
+	// Secrets are sent when the sandbox is created, so read them back to
+	// check whether their contents changed.
+	secrets, err := s.getSecrets(namespace, dcCfg.Secrets)
+	if err != nil {
+		return &cluster.DeployResponse{}, errors.WithContext("get secrets", err)
+	}
+
+	customerPods, configMaps, err := toPods(user, dnsPod.Status.PodIP, nodeControllerIP, dcCfg,
+		req.BuiltImages, req.GetConfigs(), secrets, s.securityPolicy, s.newImageInspector(namespace))
 	if err != nil {
 		return &cluster.DeployResponse{}, errors.WithContext("make pod specs", err)
 	}
@@ -535,10 +568,11 @@ func (s *server) DeployToSandbox(ctx context.Context, req *cluster.DeployRequest
 	log.WithField("namespace", namespace).
 		WithField("numPods", len(customerPods)).
 		Info("Deploying customer pods")
-	if err := s.deployCustomerPods(namespace, customerPods); err != nil {
+	changes, err := s.deployCustomerPods(namespace, customerPods, buildErrors)
+	if err != nil {
 		return &cluster.DeployResponse{}, errors.WithContext("boot customer pods", err)
 	}
-	return &cluster.DeployResponse{}, nil
+	return &cluster.DeployResponse{Changes: changes}, nil
 }
 
 func (s *server) createNamespace(ctx context.Context, namespace string) error {
@@ -553,47 +587,6 @@ func (s *server) createNamespace(ctx context.Context, namespace string) error {
 		},
 	}
 
-	policy := &networkingv1.NetworkPolicy{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: ns.Name,
-			Name:      "namespace",
-		},
-		Spec: networkingv1.NetworkPolicySpec{
-			// TODO: Restrict Egress as well.
-			Ingress: []networkingv1.NetworkPolicyIngressRule{
-				{
-					From: []networkingv1.NetworkPolicyPeer{
-
-						// Allow traffic any pod in the same namespace.
-						// Pods in this namespace can also communicate with the
-						// node controller since we don't have an ingress rule
-						// for the blimp-system namespace.
-						{
-							NamespaceSelector: &metav1.LabelSelector{
-								MatchLabels: map[string]string{
-									"namespace": ns.Name,
-								},
-							},
-						},
-
-						// Allow the node controllers to forward traffic to
-						// customer pods.
-						{
-							NamespaceSelector: &metav1.LabelSelector{
-								MatchLabels: map[string]string{
-									"namespace": node.NodeControllerNamespace,
-								},
-							},
-						},
-					},
-				},
-			},
-			PolicyTypes: []networkingv1.PolicyType{
-				networkingv1.PolicyTypeIngress,
-			},
-		},
-	}
-
 	namespaceClient := s.kubeClient.CoreV1().Namespaces()
 	existingNs, err := namespaceClient.Get(ns.Name, metav1.GetOptions{})
 	switch {
@@ -613,13 +606,10 @@ func (s *server) createNamespace(ctx context.Context, namespace string) error {
 		}
 	}
 
-	networkingClient := s.kubeClient.NetworkingV1().NetworkPolicies(ns.Name)
-	if _, err := networkingClient.Get(policy.Name, metav1.GetOptions{}); kerrors.IsNotFound(err) {
-		if _, err := networkingClient.Create(policy); err != nil {
-			return errors.WithContext("create network policy", err)
+	for _, policy := range sandboxNetworkPolicies(ns.Name) {
+		if err := kube.DeployNetworkPolicy(s.kubeClient, policy); err != nil {
+			return errors.WithContext("deploy network policy", err)
 		}
-	} else if err != nil {
-		return errors.WithContext("get network policy", err)
 	}
 
 	if err := volume.CreatePVC(ctx, s.kubeClient, namespace); err != nil {
@@ -657,11 +647,21 @@ func (s *server) getPod(ctx context.Context, namespace, name string, cond podCon
 	return pod, nil
 }
 
-func (s *server) createReservation(user auth.User, numServices int) error {
-	cpu := resource.MustParse(
-		fmt.Sprintf("%d%s", cpuRequest*numServices, cpuRequestUnits))
-	memory := resource.MustParse(
-		fmt.Sprintf("%d%s", memoryRequest*numServices, memoryRequestUnits))
+func (s *server) createReservation(user auth.User, services []composeTypes.ServiceConfig) error {
+	// Request the sum of the resources that will be requested by the
+	// services.
+	var cpu, memory resource.Quantity
+	for _, svc := range services {
+		resources, err := toResourceRequirements(svc)
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < numReplicas(svc); i++ {
+			cpu.Add(resources.Requests[corev1.ResourceCPU])
+			memory.Add(resources.Requests[corev1.ResourceMemory])
+		}
+	}
 
 	pod := corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -965,38 +965,28 @@ func (s *server) createPodRunnerServiceAccount(namespace string,
 	return kube.DeployServiceAccount(s.kubeClient, serviceAccount)
 }
 
-func (s *server) deployCustomerPods(namespace string, desired []corev1.Pod) error {
+// deployCustomerPods creates or updates the desired customer pods, and
+// deletes the customer pods that are no longer desired. The pods of the
+// services that failed to build are left as is. It returns how each service's
+// pods changed.
+func (s *server) deployCustomerPods(namespace string, desired []corev1.Pod, buildErrors map[string]string) (
+	map[string]cluster.DeployChange, error) {
 	currPods, err := s.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{
 		LabelSelector: "blimp.customerPod=true",
 	})
 	if err != nil {
-		return errors.WithContext("list", err)
+		return nil, errors.WithContext("list", err)
 	}
 
-	// TODO: Parallelize
-	desiredNames := map[string]struct{}{}
-	for _, pod := range desired {
-		opts := kube.DeployPodOptions{
-			Sanitizers: []kube.Sanitizer{
-				kube.SanitizeIgnoreInitContainerImages,
-				kube.SanitizeIgnoreNodeAffinity,
-			},
-		}
-		if err := kube.DeployPod(s.kubeClient, pod, opts); err != nil {
-			return errors.WithContext("create", err)
-		}
-		desiredNames[pod.Name] = struct{}{}
+	changes, err := diffPods(currPods.Items, desired, buildErrors)
+	if err != nil {
+		return nil, errors.WithContext("diff", err)
 	}
 
-	// Delete any stale pods.
-	for _, pod := range currPods.Items {
-		if _, ok := desiredNames[pod.Name]; !ok {
-			if err := kube.DeletePod(s.kubeClient, pod.Namespace, pod.Name); err != nil {
-				return errors.WithContext("delete", err)
-			}
-		}
+	if err := s.applyPodChanges(changes); err != nil {
+		return nil, err
 	}
-	return nil
+	return summarizePodChanges(changes), nil
 }
 
 func (s *server) DeleteSandbox(ctx context.Context, req *cluster.DeleteSandboxRequest) (
@@ -1018,6 +1008,10 @@ func (s *server) DeleteSandbox(ctx context.Context, req *cluster.DeleteSandboxRe
 		if err := volume.PermanentlyDeletePVC(s.kubeClient, user.Namespace); err != nil {
 			return &cluster.DeleteSandboxResponse{}, errors.WithContext("delete persistent volume", err)
 		}
+
+		if err := volume.PermanentlyDeleteBuildCachePVC(s.kubeClient, user.Namespace); err != nil {
+			return &cluster.DeleteSandboxResponse{}, errors.WithContext("delete build cache", err)
+		}
 	}
 
 	// Give the pods 10 seconds to shut down (rather than the default of 30
@@ -1090,34 +1084,48 @@ func (s *server) Restart(ctx context.Context, req *cluster.RestartRequest) (*clu
 		return &cluster.RestartResponse{}, err
 	}
 
-	podName := names.ToDNS1123(req.GetService())
-	currPod, err := s.kubeClient.CoreV1().Pods(user.Namespace).
-		Get(podName, metav1.GetOptions{})
+	// Restart all the replicas of the service.
+	podLister := s.statusFetcher.podLister.Pods(user.Namespace)
+	currPods, err := podLister.List(
+		labels.Set{"blimp.service": req.GetService(), "blimp.customerPod": "true"}.AsSelector())
 	if err != nil {
-		return &cluster.RestartResponse{}, errors.WithContext("get current pod", err)
+		return &cluster.RestartResponse{}, errors.WithContext("get current pods", err)
 	}
 
-	newPod := corev1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      podName,
-			Namespace: user.Namespace,
-			Labels:    currPod.Labels,
-		},
-		Spec: currPod.Spec,
-	}
-	for k, v := range currPod.Annotations {
-		if contains(metadata.CustomPodAnnotations, k) {
-			if newPod.Annotations == nil {
-				newPod.Annotations = map[string]string{}
-			}
-			newPod.Annotations[k] = v
+	// If the service shares a namespace with another service, it runs in the
+	// other service's pod, so the entire pod gets restarted.
+	if len(currPods) == 0 {
+		selector, err := labels.Parse(fmt.Sprintf("blimp.customerPod=true,%s", metadata.ServiceLabel(req.GetService())))
+		if err != nil {
+			return &cluster.RestartResponse{}, errors.WithContext("parse selector", err)
+		}
+
+		currPods, err = podLister.List(selector)
+		if err != nil {
+			return &cluster.RestartResponse{}, errors.WithContext("get current pods", err)
 		}
 	}
+	if len(currPods) == 0 {
+		return &cluster.RestartResponse{}, errors.NewFriendlyError(
+			"Service %s doesn't exist.", req.GetService())
+	}
 
-	// Since we are setting ForceRestart, we don't both adding any Sanitizers here.
-	err = kube.DeployPod(s.kubeClient, newPod, kube.DeployPodOptions{ForceRestart: true})
-	if err != nil {
-		return &cluster.RestartResponse{}, errors.WithContext("deploy new pod", err)
+	for _, currPod := range currPods {
+		newPod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      currPod.Name,
+				Namespace: user.Namespace,
+				Labels:    currPod.Labels,
+			},
+			Spec: currPod.Spec,
+		}
+		newPod.Annotations = metadata.FilterCustomPodAnnotations(currPod.Annotations)
+
+		// Since we are setting ForceRestart, we don't both adding any Sanitizers here.
+		err = kube.DeployPod(s.kubeClient, newPod, kube.DeployPodOptions{ForceRestart: true})
+		if err != nil {
+			return &cluster.RestartResponse{}, errors.WithContext("deploy new pod", err)
+		}
 	}
 
 	return &cluster.RestartResponse{}, nil
@@ -1201,38 +1209,7 @@ func (s *server) pushImage(oldName, newName string, blimpAuth *protoAuth.BlimpAu
 		return errors.WithContext("parse image reference", err)
 	}
 
-	regCred := authn.Anonymous
-	// We have to pick the right auth to use.
-	for registry, cred := range creds {
-		if cred.GetUsername() == "" && cred.GetPassword() == "" {
-			continue
-		}
-
-		refRegistry := ref.Context().Registry
-
-		// Specially handle index.docker.io.
-		// This doesn't actually make sense, but is correct. See
-		// https://github.com/google/go-containerregistry/pull/456#issuecomment-499233027.
-		if registry == authn.DefaultAuthKey && refRegistry.Name() == name.DefaultRegistry {
-			regCred = &authn.Basic{
-				Username: cred.GetUsername(),
-				Password: cred.GetPassword(),
-			}
-			break
-		}
-
-		// Usually hostnames from auth config do not have a scheme (http/https)
-		// specified, but it seems like sometimes they do. We try both.
-		if registry == refRegistry.Name() ||
-			registry == fmt.Sprintf("%s://%s", refRegistry.Scheme(), refRegistry.Name()) {
-			regCred = &authn.Basic{
-				Username: cred.GetUsername(),
-				Password: cred.GetPassword(),
-			}
-			break
-		}
-	}
-
+	regCred := registryAuthenticator(ref, creds)
 	image, err := remote.Image(ref, remote.WithAuth(regCred))
 	if err != nil {
 		return errors.WithContext("creating pull image ref", err)
@@ -1373,6 +1350,10 @@ func toPods(
 	nodeControllerIP string,
 	cfg composeTypes.Project,
 	builtImages map[string]string,
+	configs,
+	secrets map[string][]byte,
+	policy securityPolicy,
+	images imageInspector,
 ) (
 	pods []corev1.Pod,
 	configMaps []corev1.ConfigMap,
@@ -1384,19 +1365,50 @@ func toPods(
 			MaxServices, len(cfg.Services))
 	}
 
-	b, err := newPodBuilder(user, dnsIP, nodeControllerIP, builtImages, cfg.Services, cfg.Volumes)
+	b, err := newPodBuilder(user, cfg.Name, dnsIP, nodeControllerIP, builtImages, cfg.Services, cfg.Volumes,
+		configs, secrets, policy, images)
 	if err != nil {
 		return nil, nil, errors.WithContext("make pod builder", err)
 	}
 
+	// Services that share a namespace with another service are added to the
+	// other service's pod as additional containers.
+	colocated := map[string][]composeTypes.ServiceConfig{}
 	for _, svc := range cfg.Services {
-		p, cm, err := b.ToPod(svc)
-		if err != nil {
-			return nil, nil, err
+		if owner, ok := b.owners[svc.Name]; ok {
+			colocated[owner] = append(colocated[owner], svc)
+		}
+	}
+
+	for _, svc := range cfg.Services {
+		if _, ok := b.owners[svc.Name]; ok {
+			continue
+		}
+
+		for replica := 0; replica < numReplicas(svc); replica++ {
+			p, cm, err := b.ToPod(svc, replica)
+			if err != nil {
+				return nil, nil, err
+			}
+			configMaps = append(configMaps, cm...)
+
+			for i, member := range colocated[svc.Name] {
+				memberPod, memberCM, err := b.ToPod(member, replica)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				addColocatedService(&p, member, memberPod, i+1)
+				configMaps = append(configMaps, memberCM...)
+			}
+			pods = append(pods, p)
 		}
+	}
 
-		pods = append(pods, p)
-		configMaps = append(configMaps, cm...)
+	if len(pods) > MaxServices {
+		return nil, nil, errors.NewFriendlyError(
+			"Blimp supports a maximum of %d containers, but %d are defined "+
+				"after accounting for replicas.", MaxServices, len(pods))
 	}
 
 	return pods, configMaps, nil
@@ -1416,12 +1428,3 @@ func podIsReady(pod *corev1.Pod) bool {
 func podIsScheduled(pod *corev1.Pod) bool {
 	return pod.Spec.NodeName != ""
 }
-
-func contains(slc []string, exp string) bool {
-	for _, x := range slc {
-		if x == exp {
-			return true
-		}
-	}
-	return false
-}
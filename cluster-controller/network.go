@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/kelda/blimp/cluster-controller/node"
+	"github.com/kelda/blimp/pkg/errors"
+	"github.com/kelda/blimp/pkg/kube"
+	"github.com/kelda/blimp/pkg/metadata"
+	"github.com/kelda/blimp/pkg/names"
+)
+
+// defaultNetwork is the network that Docker Compose connects services to if
+// they don't specify any networks.
+const defaultNetwork = "default"
+
+// serviceNetworks returns the names of the Compose networks that the service
+// is connected to. Services that share another service's network namespace
+// aren't connected to any networks of their own.
+func serviceNetworks(svc composeTypes.ServiceConfig) []string {
+	if _, ok := sharedNamespaceService(svc.NetworkMode); ok {
+		return nil
+	}
+
+	if len(svc.Networks) == 0 {
+		return []string{defaultNetwork}
+	}
+
+	var networks []string
+	for name := range svc.Networks {
+		networks = append(networks, name)
+	}
+	sort.Strings(networks)
+	return networks
+}
+
+// addDNSConfig configures the pod to resolve hostnames via the sandbox's DNS
+// server, according to the service's `dns`, `dns_search`, and `dns_opt`
+// settings.
+func (p *podSpec) addDNSConfig(svc composeTypes.ServiceConfig, dnsIP, project string) error {
+	// Always use the sandbox's DNS server so that other services can be
+	// resolved. It forwards queries for external hostnames to the service's
+	// DNS servers.
+	var upstreams []string
+	for _, server := range svc.DNS {
+		ip := net.ParseIP(server)
+		if ip == nil {
+			return errors.NewFriendlyError("Invalid dns server (%s) for service %s.\n"+
+				"DNS servers must be IP addresses.", server, svc.Name)
+		}
+		upstreams = append(upstreams, net.JoinHostPort(ip.String(), "53"))
+	}
+	if len(upstreams) != 0 {
+		p.pod.Annotations[metadata.DNSServersKey] = strings.Join(upstreams, ",")
+	}
+
+	// Search the project's domain first so that `<service>` resolves to
+	// `<service>.<project>` without going to the external DNS servers.
+	// The project name may contain characters, such as underscores, that
+	// aren't allowed in search domains.
+	var searches []string
+	if project != "" {
+		domain := names.ToDNS1123(project)
+		p.pod.Annotations[metadata.ProjectKey] = domain
+		searches = append(searches, domain)
+	}
+	for _, search := range svc.DNSSearch {
+		if errs := validation.IsDNS1123Subdomain(strings.TrimSuffix(search, ".")); len(errs) != 0 {
+			return errors.NewFriendlyError("Invalid dns_search domain (%s) for service %s.\n"+
+				"Search domains must be valid DNS names: %s", search, svc.Name, strings.Join(errs, "; "))
+		}
+		searches = append(searches, search)
+	}
+
+	var options []corev1.PodDNSConfigOption
+	for _, opt := range svc.DNSOpts {
+		// Options are either flags (e.g. `rotate`), or have a value (e.g.
+		// `ndots:2`).
+		option := corev1.PodDNSConfigOption{Name: opt}
+		if parts := strings.SplitN(opt, ":", 2); len(parts) == 2 {
+			value := parts[1]
+			option = corev1.PodDNSConfigOption{Name: parts[0], Value: &value}
+		}
+		options = append(options, option)
+	}
+
+	p.pod.Spec.DNSPolicy = corev1.DNSNone
+	p.pod.Spec.DNSConfig = &corev1.PodDNSConfig{
+		Nameservers: []string{dnsIP},
+		Searches:    searches,
+		Options:     options,
+	}
+	return nil
+}
+
+// sandboxNetworkPolicies returns the network policies that apply to all
+// pods in the sandbox, regardless of the Compose file.
+func sandboxNetworkPolicies(namespace string) []networkingv1.NetworkPolicy {
+	return []networkingv1.NetworkPolicy{
+		// Allow pods in the namespace to access the Blimp system pods, such as
+		// the DNS server. Customer pods are only accessible according to
+		// their Compose networks.
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      "namespace",
+			},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{
+							Key:      "blimp.customerPod",
+							Operator: metav1.LabelSelectorOpDoesNotExist,
+						},
+					},
+				},
+				// TODO: Restrict Egress as well.
+				Ingress: []networkingv1.NetworkPolicyIngressRule{
+					{
+						// Pods in this namespace can also communicate with the
+						// node controller since we don't have an ingress rule
+						// for the blimp-system namespace.
+						From: []networkingv1.NetworkPolicyPeer{
+							{
+								NamespaceSelector: &metav1.LabelSelector{
+									MatchLabels: map[string]string{
+										"namespace": namespace,
+									},
+								},
+							},
+						},
+					},
+				},
+				PolicyTypes: []networkingv1.PolicyType{
+					networkingv1.PolicyTypeIngress,
+				},
+			},
+		},
+
+		// Allow the node controllers to forward traffic to all pods.
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      "node-controller",
+			},
+			Spec: networkingv1.NetworkPolicySpec{
+				Ingress: []networkingv1.NetworkPolicyIngressRule{
+					{
+						From: []networkingv1.NetworkPolicyPeer{
+							{
+								NamespaceSelector: &metav1.LabelSelector{
+									MatchLabels: map[string]string{
+										"namespace": node.NodeControllerNamespace,
+									},
+								},
+							},
+						},
+					},
+				},
+				PolicyTypes: []networkingv1.PolicyType{
+					networkingv1.PolicyTypeIngress,
+				},
+			},
+		},
+	}
+}
+
+// networkPolicyName returns the name of the NetworkPolicy that allows traffic
+// between pods on the given Compose network.
+func networkPolicyName(network string) string {
+	return names.ToDNS1123("network-" + network)
+}
+
+// deployNetworkPolicies creates a NetworkPolicy for each Compose network that
+// allows pods on the network to communicate with each other, and removes the
+// policies for networks that are no longer used.
+func (s *server) deployNetworkPolicies(namespace string, services []composeTypes.ServiceConfig) error {
+	networks := map[string]struct{}{}
+	for _, svc := range services {
+		for _, network := range serviceNetworks(svc) {
+			networks[network] = struct{}{}
+		}
+	}
+
+	desiredNames := map[string]struct{}{}
+	for network := range networks {
+		selector := metav1.LabelSelector{
+			MatchLabels: map[string]string{metadata.NetworkLabel(network): "true"},
+		}
+		policy := networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      networkPolicyName(network),
+				Namespace: namespace,
+				Labels:    map[string]string{"blimp.composeNetwork": "true"},
+			},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: selector,
+				Ingress: []networkingv1.NetworkPolicyIngressRule{
+					{
+						From: []networkingv1.NetworkPolicyPeer{
+							{PodSelector: &selector},
+						},
+					},
+				},
+				PolicyTypes: []networkingv1.PolicyType{
+					networkingv1.PolicyTypeIngress,
+				},
+			},
+		}
+		if err := kube.DeployNetworkPolicy(s.kubeClient, policy); err != nil {
+			return errors.WithContext(fmt.Sprintf("deploy network %s", network), err)
+		}
+		desiredNames[policy.Name] = struct{}{}
+	}
+
+	policyClient := s.kubeClient.NetworkingV1().NetworkPolicies(namespace)
+	currPolicies, err := policyClient.List(metav1.ListOptions{
+		LabelSelector: "blimp.composeNetwork=true",
+	})
+	if err != nil {
+		return errors.WithContext("list network policies", err)
+	}
+
+	for _, policy := range currPolicies.Items {
+		if _, ok := desiredNames[policy.Name]; ok {
+			continue
+		}
+
+		if err := policyClient.Delete(policy.Name, nil); err != nil {
+			return errors.WithContext("delete stale network policy", err)
+		}
+	}
+	return nil
+}
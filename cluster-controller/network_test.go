@@ -0,0 +1,187 @@
+package main
+
+import (
+	"sort"
+	"testing"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	fakeKube "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kelda/blimp/pkg/metadata"
+	"github.com/kelda/blimp/pkg/names"
+)
+
+func TestAddDNSConfig(t *testing.T) {
+	ndots := "2"
+	tests := []struct {
+		name           string
+		svc            composeTypes.ServiceConfig
+		project        string
+		expConfig      *corev1.PodDNSConfig
+		expAnnotations map[string]string
+		expErr         string
+	}{
+		{
+			name: "default",
+			svc:  composeTypes.ServiceConfig{Name: "web"},
+			expConfig: &corev1.PodDNSConfig{
+				Nameservers: []string{"10.0.0.10"},
+			},
+			expAnnotations: map[string]string{},
+		},
+		{
+			name: "project search domain",
+			svc: composeTypes.ServiceConfig{
+				Name:      "web",
+				DNSSearch: composeTypes.StringList{"example.com"},
+			},
+			project: "my_app",
+			expConfig: &corev1.PodDNSConfig{
+				Nameservers: []string{"10.0.0.10"},
+				Searches:    []string{names.ToDNS1123("my_app"), "example.com"},
+			},
+			expAnnotations: map[string]string{
+				metadata.ProjectKey: names.ToDNS1123("my_app"),
+			},
+		},
+		{
+			name: "upstream servers and options",
+			svc: composeTypes.ServiceConfig{
+				Name:    "web",
+				DNS:     composeTypes.StringList{"8.8.8.8", "2001:4860:4860::8888"},
+				DNSOpts: []string{"ndots:2", "rotate"},
+			},
+			expConfig: &corev1.PodDNSConfig{
+				Nameservers: []string{"10.0.0.10"},
+				Options: []corev1.PodDNSConfigOption{
+					{Name: "ndots", Value: &ndots},
+					{Name: "rotate"},
+				},
+			},
+			expAnnotations: map[string]string{
+				metadata.DNSServersKey: "8.8.8.8:53,[2001:4860:4860::8888]:53",
+			},
+		},
+		{
+			name: "invalid server",
+			svc: composeTypes.ServiceConfig{
+				Name: "web",
+				DNS:  composeTypes.StringList{"dns.google"},
+			},
+			expErr: "Invalid dns server (dns.google) for service web.\n" +
+				"DNS servers must be IP addresses.",
+		},
+		{
+			name: "invalid search domain",
+			svc: composeTypes.ServiceConfig{
+				Name:      "web",
+				DNSSearch: composeTypes.StringList{"my_domain.com"},
+			},
+			expErr: "Invalid dns_search domain (my_domain.com) for service web.\n" +
+				"Search domains must be valid DNS names: " +
+				validation.IsDNS1123Subdomain("my_domain.com")[0],
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var spec podSpec
+			spec.pod.Annotations = map[string]string{}
+
+			err := spec.addDNSConfig(test.svc, "10.0.0.10", test.project)
+			if test.expErr != "" {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, corev1.DNSNone, spec.pod.Spec.DNSPolicy)
+			assert.Equal(t, test.expConfig, spec.pod.Spec.DNSConfig)
+			assert.Equal(t, test.expAnnotations, spec.pod.Annotations)
+		})
+	}
+}
+
+func TestDeployNetworkPolicies(t *testing.T) {
+	stalePolicy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "network-old",
+			Namespace: "namespace",
+			Labels:    map[string]string{"blimp.composeNetwork": "true"},
+		},
+	}
+	sandboxPolicy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "namespace",
+			Namespace: "namespace",
+		},
+	}
+
+	tests := []struct {
+		name        string
+		mockObjects []runtime.Object
+		services    []composeTypes.ServiceConfig
+		expPolicies []string
+	}{
+		{
+			name:        "default network",
+			services:    []composeTypes.ServiceConfig{{Name: "web"}},
+			expPolicies: []string{networkPolicyName("default")},
+		},
+		{
+			name: "multiple networks",
+			services: []composeTypes.ServiceConfig{
+				{
+					Name: "web",
+					Networks: map[string]*composeTypes.ServiceNetworkConfig{
+						"frontend": nil,
+					},
+				},
+				{
+					Name: "api",
+					Networks: map[string]*composeTypes.ServiceNetworkConfig{
+						"frontend": nil,
+						"backend":  nil,
+					},
+				},
+				{Name: "worker"},
+			},
+			expPolicies: []string{
+				networkPolicyName("backend"),
+				networkPolicyName("default"),
+				networkPolicyName("frontend"),
+			},
+		},
+		{
+			name:        "removes stale networks",
+			mockObjects: []runtime.Object{stalePolicy, sandboxPolicy},
+			services:    []composeTypes.ServiceConfig{{Name: "web"}},
+			expPolicies: []string{"namespace", networkPolicyName("default")},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{kubeClient: fakeKube.NewSimpleClientset(test.mockObjects...)}
+			assert.NoError(t, s.deployNetworkPolicies("namespace", test.services))
+
+			policies, err := s.kubeClient.NetworkingV1().NetworkPolicies("namespace").List(metav1.ListOptions{})
+			assert.NoError(t, err)
+
+			var names []string
+			for _, policy := range policies.Items {
+				names = append(names, policy.Name)
+			}
+			sort.Strings(names)
+			assert.Equal(t, test.expPolicies, names)
+		})
+	}
+}
@@ -2,15 +2,18 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	units "github.com/docker/go-units"
 	"github.com/golang/protobuf/proto"
 	composeTypes "github.com/kelda/compose-go/types"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -30,15 +33,18 @@ import (
 	"github.com/kelda/blimp/pkg/version"
 )
 
+// The resources used for services that don't specify their own limits and
+// reservations.
 const (
-	cpuRequest         = 20
-	cpuRequestUnits    = "m"
-	memoryRequest      = 50
-	memoryRequestUnits = "Mi"
+	defaultCPULimit      = "4"
+	defaultMemoryLimit   = "16Gi"
+	defaultCPURequest    = "20m"
+	defaultMemoryRequest = "50Mi"
 )
 
 type podBuilder struct {
 	user             auth.User
+	project          string
 	dnsIP            string
 	nodeControllerIP string
 	builtImages      map[string]string
@@ -53,18 +59,38 @@ type podBuilder struct {
 	// volumes using DriverOpts. It maps from volume names to source
 	// directories.
 	namedBindVolumes map[string]string
+	// configHashes and secretHashes map config and secret names to a hash
+	// of their contents.
+	configHashes   map[string]string
+	secretHashes   map[string]string
+	securityPolicy securityPolicy
+	images         imageInspector
+	// owners maps services that share a namespace with another service to
+	// the service whose pod they run in.
+	owners map[string]string
 }
 
 type podSpec struct {
-	namespace  string
+	namespace string
+	// service is the name of the service that the pod is for.
+	service string
+	// name is the name of the pod.
+	name       string
+	replica    int
 	image      string
 	pod        corev1.Pod
 	configMaps []corev1.ConfigMap
 }
 
-func newPodBuilder(user auth.User, dnsIP, nodeControllerIP string, builtImages map[string]string,
-	services []composeTypes.ServiceConfig, volumes map[string]composeTypes.VolumeConfig) (
-	podBuilder, error) {
+func newPodBuilder(user auth.User, project, dnsIP, nodeControllerIP string, builtImages map[string]string,
+	services []composeTypes.ServiceConfig, volumes map[string]composeTypes.VolumeConfig,
+	configs, secrets map[string][]byte, policy securityPolicy, images imageInspector) (podBuilder, error) {
+
+	for _, svc := range services {
+		if _, err := dockercompose.ParseBlimpExtension(svc); err != nil {
+			return podBuilder{}, err
+		}
+	}
 
 	serviceToAliases := make(map[string][]string)
 	aliasToService := make(map[string]string)
@@ -121,8 +147,24 @@ func newPodBuilder(user auth.User, dnsIP, nodeControllerIP string, builtImages m
 		}
 	}
 
+	configHashes := map[string]string{}
+	for name, contents := range configs {
+		configHashes[name] = hash.Bytes(contents)
+	}
+
+	secretHashes := map[string]string{}
+	for name, contents := range secrets {
+		secretHashes[name] = hash.Bytes(contents)
+	}
+
+	owners, err := podOwners(services)
+	if err != nil {
+		return podBuilder{}, err
+	}
+
 	return podBuilder{
 		user:              user,
+		project:           project,
 		dnsIP:             dnsIP,
 		nodeControllerIP:  nodeControllerIP,
 		builtImages:       builtImages,
@@ -130,11 +172,22 @@ func newPodBuilder(user auth.User, dnsIP, nodeControllerIP string, builtImages m
 		svcAliasesMapping: serviceToAliases,
 		volumeToServices:  volumeToServices,
 		namedBindVolumes:  namedBindVolumes,
+		configHashes:      configHashes,
+		secretHashes:      secretHashes,
+		securityPolicy:    policy,
+		images:            images,
+		owners:            owners,
 	}, nil
 }
 
-func (b podBuilder) ToPod(svc composeTypes.ServiceConfig) (corev1.Pod, []corev1.ConfigMap, error) {
-	spec := podSpec{namespace: b.user.Namespace}
+// ToPod returns the pod for the given replica of a service.
+func (b podBuilder) ToPod(svc composeTypes.ServiceConfig, replica int) (corev1.Pod, []corev1.ConfigMap, error) {
+	spec := podSpec{
+		namespace: b.user.Namespace,
+		service:   svc.Name,
+		name:      names.PodName(svc.Name, replica),
+		replica:   replica,
+	}
 	spec.pod.Spec.Affinity = affinity.ForUser(b.user)
 
 	if svc.Build != nil {
@@ -164,15 +217,27 @@ func (b podBuilder) ToPod(svc composeTypes.ServiceConfig) (corev1.Pod, []corev1.
 	}
 
 	if len(nativeVolumes) != 0 {
-		spec.addVolumeSeeder(nativeVolumes)
-
+		// Wait for the pods of the other services, rather than the services
+		// themselves, since services that share a namespace run in the same
+		// pod.
 		var servicesSharingVolumes []string
 		for _, volume := range nativeVolumes {
-			servicesSharingVolumes = append(servicesSharingVolumes, b.volumeToServices[volume.Source]...)
+			for _, sharingSvc := range b.volumeToServices[volume.Source] {
+				servicesSharingVolumes = append(servicesSharingVolumes, b.podService(sharingSvc))
+			}
 		}
-		servicesSharingVolumes = remove(strs.Unique(servicesSharingVolumes), svc.Name)
+		servicesSharingVolumes = remove(strs.Unique(servicesSharingVolumes), b.podService(svc.Name))
+
+		// Only the first replica initializes the volumes. The other replicas
+		// wait for it to finish.
+		if replica == 0 {
+			spec.addVolumeSeeder(nativeVolumes)
+		} else {
+			servicesSharingVolumes = append(servicesSharingVolumes, b.podService(svc.Name))
+		}
+
 		if len(servicesSharingVolumes) != 0 {
-			err := spec.addWaiter(b.nodeControllerIP, svc.Name, kube.ContainerNameWaitInitializedVolumes,
+			err := spec.addWaiter(b.nodeControllerIP, kube.ContainerNameWaitInitializedVolumes,
 				wait.WaitSpec{FinishedVolumeInit: servicesSharingVolumes})
 			if err != nil {
 				return corev1.Pod{}, nil, err
@@ -181,24 +246,53 @@ func (b podBuilder) ToPod(svc composeTypes.ServiceConfig) (corev1.Pod, []corev1.
 	}
 
 	if len(svc.DependsOn) != 0 {
-		err := spec.addWaiter(b.nodeControllerIP, svc.Name, kube.ContainerNameWaitDependsOn,
-			wait.WaitSpec{DependsOn: marshalDependencies(svc.DependsOn, svc.Links)})
-		if err != nil {
-			return corev1.Pod{}, nil, err
+		dependencies := b.resolveDependencies(svc.Name, marshalDependencies(svc.DependsOn, svc.Links))
+		if len(dependencies) != 0 {
+			err := spec.addWaiter(b.nodeControllerIP, kube.ContainerNameWaitDependsOn,
+				wait.WaitSpec{DependsOn: dependencies})
+			if err != nil {
+				return corev1.Pod{}, nil, err
+			}
 		}
 	}
 
 	if len(bindVolumes) != 0 {
-		err := spec.addWaiter(b.nodeControllerIP, svc.Name, kube.ContainerNameWaitInitialSync,
+		err := spec.addWaiter(b.nodeControllerIP, kube.ContainerNameWaitInitialSync,
 			wait.WaitSpec{BindVolumes: bindVolumes})
 		if err != nil {
 			return corev1.Pod{}, nil, err
 		}
 	}
 
-	if err := spec.addRuntimeContainer(svc, b.dnsIP, b.svcAliasesMapping, b.namedBindVolumes); err != nil {
+	err := spec.addRuntimeContainer(svc, b.svcAliasesMapping, b.namedBindVolumes, b.securityPolicy)
+	if err != nil {
+		return corev1.Pod{}, nil, err
+	}
+
+	if err := spec.addDNSConfig(svc, b.dnsIP, b.project); err != nil {
 		return corev1.Pod{}, nil, err
 	}
+
+	if err := spec.addUser(svc, b.images); err != nil {
+		return corev1.Pod{}, nil, err
+	}
+
+	if err := spec.addStopBehavior(svc, b.images); err != nil {
+		return corev1.Pod{}, nil, err
+	}
+
+	// Kubernetes doesn't update files that are mounted with a SubPath, so
+	// restart the pod when the contents of its configs or secrets change.
+	var fileObjectHashes []string
+	for _, ref := range svc.Configs {
+		fileObjectHashes = append(fileObjectHashes, "config:"+ref.Source+"="+b.configHashes[ref.Source])
+	}
+	for _, ref := range svc.Secrets {
+		fileObjectHashes = append(fileObjectHashes, "secret:"+ref.Source+"="+b.secretHashes[ref.Source])
+	}
+	if len(fileObjectHashes) != 0 {
+		spec.pod.Annotations[fileObjectsHashKey] = hash.DNSCompliant(strings.Join(fileObjectHashes, ","))
+	}
 	spec.sanitize()
 	return spec.pod, spec.configMaps, nil
 }
@@ -272,19 +366,30 @@ func (p *podSpec) addVolumeSeeder(volumes []composeTypes.ServiceVolumeConfig) {
 	)
 }
 
-func (p *podSpec) addRuntimeContainer(svc composeTypes.ServiceConfig, dnsIP string,
-	svcAliasesMapping map[string][]string, namedBindVolumes map[string]string) error {
+func (p *podSpec) addRuntimeContainer(svc composeTypes.ServiceConfig,
+	svcAliasesMapping map[string][]string, namedBindVolumes map[string]string, policy securityPolicy) error {
 
 	p.pod.Namespace = p.namespace
-	p.pod.Name = names.ToDNS1123(svc.Name)
-	p.pod.Labels = map[string]string{
-		"blimp.service":               svc.Name,
-		"blimp.customerPod":           "true",
-		affinity.ColocateNamespaceKey: p.namespace,
+	p.pod.Name = p.name
+	// Blimp's labels and annotations take precedence over the service's.
+	labels, labelAnnotations := toPodMetadata(svc)
+	p.pod.Labels = labels
+	p.pod.Labels["blimp.service"] = svc.Name
+	p.pod.Labels["blimp.replica"] = strconv.Itoa(p.replica)
+	p.pod.Labels["blimp.customerPod"] = "true"
+	p.pod.Labels[affinity.ColocateNamespaceKey] = p.namespace
+	for _, network := range serviceNetworks(svc) {
+		p.pod.Labels[metadata.NetworkLabel(network)] = "true"
 	}
 
 	var volumeMounts []corev1.VolumeMount
 	for _, v := range svc.Volumes {
+		// Tmpfs volumes are backed by memory rather than the persistent
+		// volume, so they're handled by addMemoryVolumes.
+		if v.Type == composeTypes.VolumeTypeTmpfs {
+			continue
+		}
+
 		var subPath string
 		switch v.Type {
 		case composeTypes.VolumeTypeVolume:
@@ -308,37 +413,44 @@ func (p *podSpec) addRuntimeContainer(svc composeTypes.ServiceConfig, dnsIP stri
 		p.addVolume(volume.PersistentVolume)
 	}
 
-	var securityContext *corev1.SecurityContext
-	if svc.User != "" {
-		securityContext = &corev1.SecurityContext{}
-		var ids []int
-		for _, idStr := range strings.Split(svc.User, ":") {
-			id, err := strconv.Atoi(idStr)
-			if err != nil {
-				return errors.NewFriendlyError("Invalid user field (%s) for service %s.\n"+
-					"Only numeric IDs are allowed. Please convert the user and group name to a numeric ID.",
-					svc.User, svc.Name)
-			}
+	memoryMounts, err := p.addMemoryVolumes(svc)
+	if err != nil {
+		return err
+	}
+	volumeMounts = append(volumeMounts, memoryMounts...)
 
-			ids = append(ids, id)
-		}
+	secretMounts, err := p.addSecrets(svc)
+	if err != nil {
+		return err
+	}
+	volumeMounts = append(volumeMounts, secretMounts...)
 
-		// The first ID is the user, and the second (optional) ID is the group.
-		switch len(ids) {
-		case 1:
-			user := int64(ids[0])
-			securityContext.RunAsUser = &user
-		case 2:
-			user := int64(ids[0])
-			group := int64(ids[1])
-			securityContext.RunAsUser = &user
-			securityContext.RunAsGroup = &group
-		default:
-			return errors.NewFriendlyError("Invalid user field (%s) for service %s.\n"+
-				"Expected at most two values.", svc.User, svc.Name)
-		}
+	configMounts, err := p.addConfigs(svc)
+	if err != nil {
+		return err
+	}
+	volumeMounts = append(volumeMounts, configMounts...)
+
+	containerName := names.ToDNS1123(svc.Name)
+	security, err := policy.apply(svc, containerName)
+	if err != nil {
+		return err
+	}
+
+	securityContext := &security.container
+	// Avoid changing the pod spec for services that don't customize their
+	// security settings.
+	if *securityContext == (corev1.SecurityContext{}) {
+		securityContext = nil
 	}
 
+	resources, err := toResourceRequirements(svc)
+	if err != nil {
+		return err
+	}
+
+	readinessProbe, startupProbe, livenessProbe := toProbes(svc)
+
 	p.pod.Spec.Containers = []corev1.Container{
 		{
 			Args:            svc.Command,
@@ -346,49 +458,59 @@ func (p *podSpec) addRuntimeContainer(svc composeTypes.ServiceConfig, dnsIP stri
 			Env:             toEnvVars(svc.Environment),
 			Image:           p.image,
 			ImagePullPolicy: "Always",
-			Name:            names.ToDNS1123(svc.Name),
+			Name:            containerName,
 			SecurityContext: securityContext,
 			Stdin:           svc.StdinOpen,
 			TTY:             svc.Tty,
 			VolumeMounts:    volumeMounts,
 			WorkingDir:      svc.WorkingDir,
-			ReadinessProbe:  toReadinessProbe(svc.HealthCheck),
-			Resources: corev1.ResourceRequirements{
-				Limits: corev1.ResourceList{
-					"cpu":    resource.MustParse("4"),
-					"memory": resource.MustParse("16Gi"),
-				},
-				// If Requests are not set, they will default to the
-				// same as the Limits, which are too high.
-				Requests: corev1.ResourceList{
-					"cpu": resource.MustParse(
-						fmt.Sprintf("%d%s", cpuRequest, cpuRequestUnits)),
-					"memory": resource.MustParse(
-						fmt.Sprintf("%d%s", memoryRequest, memoryRequestUnits)),
-				},
-			},
+			ReadinessProbe:  readinessProbe,
+			StartupProbe:    startupProbe,
+			LivenessProbe:   livenessProbe,
+			Resources:       resources,
 		},
 	}
 
-	// Blimp doesn't support multiple networks, so just aggregate the
-	// aliases from all of them.
-	var aliases []string
-	for _, network := range svc.Networks {
-		if network == nil {
+	// Network aliases are only visible to services on the same network, while
+	// links and container names are visible on all of the service's
+	// networks.
+	networkAliases := map[string][]string{}
+	for name, network := range svc.Networks {
+		if network == nil || len(network.Aliases) == 0 {
 			continue
 		}
-		aliases = append(aliases, network.Aliases...)
+		id := metadata.NetworkID(name)
+		networkAliases[id] = append(networkAliases[id], network.Aliases...)
 	}
 
-	aliases = append(aliases, svcAliasesMapping[svc.Name]...)
+	aliases := append([]string{}, svcAliasesMapping[svc.Name]...)
 	if svc.ContainerName != "" {
 		aliases = append(aliases, svc.ContainerName)
 	}
 
-	p.pod.Annotations = map[string]string{}
+	p.pod.Annotations = labelAnnotations
+	if len(labelAnnotations) != 0 {
+		var keys []string
+		for key := range labelAnnotations {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		p.pod.Annotations[metadata.LabelAnnotationsKey] = strings.Join(keys, ",")
+	}
 	if len(aliases) > 0 {
 		p.pod.Annotations[metadata.AliasesKey] = metadata.Aliases(aliases)
 	}
+	if len(networkAliases) > 0 {
+		p.pod.Annotations[metadata.NetworkAliasesKey] = metadata.NetworkAliases(networkAliases)
+	}
+	for key, value := range security.annotations {
+		p.pod.Annotations[key] = value
+	}
+	if len(security.sysctls) != 0 {
+		p.pod.Spec.SecurityContext = &corev1.PodSecurityContext{
+			Sysctls: security.sysctls,
+		}
+	}
 
 	// Set the pod's hostname.
 	// Ignore the hostname setting if it's not a valid Kubernetes hostname.
@@ -437,13 +559,6 @@ func (p *podSpec) addRuntimeContainer(svc composeTypes.ServiceConfig, dnsIP stri
 		p.pod.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
 	}
 
-	// Setup DNS.
-	p.pod.Spec.DNSPolicy = corev1.DNSNone
-	p.pod.Spec.DNSConfig = &corev1.PodDNSConfig{
-		Nameservers: []string{dnsIP},
-		// TODO: There's Searches and Options, look into how to replicate these.
-	}
-
 	// Setup image credentials.
 	p.pod.Spec.ImagePullSecrets = []corev1.LocalObjectReference{
 		{Name: "registry-auth"},
@@ -457,6 +572,249 @@ func (p *podSpec) addRuntimeContainer(svc composeTypes.ServiceConfig, dnsIP stri
 	return nil
 }
 
+// tiniPath is the path that the blimp-tini binary is mounted at in service
+// containers.
+const tiniPath = "/blimp-tini/blimp-tini"
+
+// addStopBehavior configures how the service's container is run and stopped
+// according to its `init`, `ulimits`, `stop_signal`, and `stop_grace_period`
+// settings.
+func (p *podSpec) addStopBehavior(svc composeTypes.ServiceConfig, images imageInspector) error {
+	container := &p.pod.Spec.Containers[0]
+
+	if svc.StopGracePeriod != nil {
+		gracePeriod := int64(math.Ceil(time.Duration(*svc.StopGracePeriod).Seconds()))
+		p.pod.Spec.TerminationGracePeriodSeconds = &gracePeriod
+	}
+
+	stopSignal, err := normalizeSignal(svc.StopSignal)
+	if err != nil {
+		return errors.NewFriendlyError("Invalid stop_signal (%s) for service %s.",
+			svc.StopSignal, svc.Name)
+	}
+
+	// Kubernetes always stops containers with SIGTERM.
+	needsStopHook := stopSignal != "" && stopSignal != "SIGTERM"
+	useInit := svc.Init != nil && *svc.Init
+
+	// Kubernetes doesn't support ulimits, so blimp-tini sets them before
+	// running the command.
+	rlimitFlags := toRlimitFlags(svc.Ulimits)
+	wrapCommand := useInit || len(rlimitFlags) != 0
+	if !needsStopHook && !wrapCommand {
+		return nil
+	}
+
+	p.addTini()
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      "blimp-tini",
+		MountPath: filepath.Dir(tiniPath),
+		ReadOnly:  true,
+	})
+
+	// Send the stop signal from a preStop hook. The hook blocks until the
+	// container exits, so the SIGTERM sent by Kubernetes afterwards only gets
+	// delivered if the grace period expires, at which point the container
+	// is killed anyways.
+	if needsStopHook {
+		container.Lifecycle = &corev1.Lifecycle{
+			PreStop: &corev1.Handler{
+				Exec: &corev1.ExecAction{
+					Command: []string{tiniPath, "-signal", stopSignal},
+				},
+			},
+		}
+	}
+
+	if wrapCommand {
+		// We need to know the full command in order to wrap it with
+		// blimp-tini.
+		command, args := svc.Entrypoint, svc.Command
+		if len(command) == 0 {
+			config, err := images.Config(p.image)
+			if err != nil {
+				return errors.WithContext(fmt.Sprintf("get entrypoint for %s", svc.Name), err)
+			}
+
+			command = config.Entrypoint
+			if len(args) == 0 {
+				args = config.Cmd
+			}
+		}
+
+		if len(command) == 0 && len(args) == 0 {
+			setting := "init"
+			if !useInit {
+				setting = "ulimits"
+			}
+			return errors.NewFriendlyError("Service %s uses `%s`, but doesn't have a command.\n"+
+				"Please set its entrypoint or command.", svc.Name, setting)
+		}
+
+		tiniCommand := append([]string{tiniPath}, rlimitFlags...)
+		if !useInit {
+			// Replace blimp-tini with the command once the ulimits are set,
+			// rather than running the command as a child process.
+			tiniCommand = append(tiniCommand, "-exec")
+		}
+		container.Command = append(append(tiniCommand, "--"), command...)
+		container.Args = args
+	}
+	return nil
+}
+
+// toRlimitFlags returns the blimp-tini flags for setting the given ulimits.
+func toRlimitFlags(ulimits map[string]*composeTypes.UlimitsConfig) []string {
+	var names []string
+	for name := range ulimits {
+		names = append(names, name)
+	}
+	// Sort for consistency to avoid unnecessary pod restarts.
+	sort.Strings(names)
+
+	var flags []string
+	for _, name := range names {
+		limit := ulimits[name]
+		soft, hard := limit.Soft, limit.Hard
+		if limit.Single != 0 {
+			soft, hard = limit.Single, limit.Single
+		}
+		flags = append(flags, "-rlimit", fmt.Sprintf("%s=%d:%d", name, soft, hard))
+	}
+	return flags
+}
+
+// addTini adds an init container that copies the blimp-tini binary into a
+// volume so that it can be run from the user's image.
+func (p *podSpec) addTini() {
+	p.addVolume(corev1.Volume{
+		Name: "blimp-tini",
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+
+	p.addInitContainers(corev1.Container{
+		Name:    kube.ContainerNameCopyTini,
+		Image:   version.InitImage,
+		Command: []string{"/bin/cp", "/bin/blimp-tini", tiniPath},
+		VolumeMounts: []corev1.VolumeMount{{
+			Name:      "blimp-tini",
+			MountPath: filepath.Dir(tiniPath),
+		}},
+	})
+}
+
+// normalizeSignal converts a signal in any of the formats accepted by Docker
+// (e.g. "SIGQUIT", "QUIT", or "3") into a format understood by blimp-tini.
+func normalizeSignal(sig string) (string, error) {
+	if sig == "" {
+		return "", nil
+	}
+
+	if num, err := strconv.Atoi(sig); err == nil {
+		if num <= 0 {
+			return "", errors.New("invalid signal number")
+		}
+		if num == int(unix.SIGTERM) {
+			return "SIGTERM", nil
+		}
+		return sig, nil
+	}
+
+	sig = strings.ToUpper(sig)
+	if !strings.HasPrefix(sig, "SIG") {
+		sig = "SIG" + sig
+	}
+	if unix.SignalNum(sig) == 0 {
+		return "", errors.New("unknown signal")
+	}
+	return sig, nil
+}
+
+// addMemoryVolumes creates memory-backed volumes for the service's tmpfs
+// mounts and shared memory, and returns the mounts that should be added to
+// the service's container. Note that Kubernetes counts the contents of these
+// volumes towards the container's memory limit.
+func (p *podSpec) addMemoryVolumes(svc composeTypes.ServiceConfig) ([]corev1.VolumeMount, error) {
+	var mounts []corev1.VolumeMount
+	addMount := func(name, target string, sizeBytes int64) {
+		var sizeLimit *resource.Quantity
+		if sizeBytes > 0 {
+			sizeLimit = resource.NewQuantity(sizeBytes, resource.BinarySI)
+		}
+
+		p.addVolume(corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{
+					Medium:    corev1.StorageMediumMemory,
+					SizeLimit: sizeLimit,
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: target,
+		})
+	}
+
+	var tmpfsCount int
+	nextTmpfsName := func() string {
+		name := fmt.Sprintf("tmpfs-%d", tmpfsCount)
+		tmpfsCount++
+		return name
+	}
+
+	// Tmpfs mounts defined via the `tmpfs` field. They're in the same format
+	// as `docker run --tmpfs`, e.g. `/run:size=64m,mode=1777`.
+	for _, tmpfs := range svc.Tmpfs {
+		parts := strings.SplitN(tmpfs, ":", 2)
+		var sizeBytes int64
+		if len(parts) == 2 {
+			for _, opt := range strings.Split(parts[1], ",") {
+				if !strings.HasPrefix(opt, "size=") {
+					continue
+				}
+
+				var err error
+				sizeBytes, err = units.RAMInBytes(strings.TrimPrefix(opt, "size="))
+				if err != nil {
+					return nil, errors.NewFriendlyError("Invalid tmpfs size (%s) for service %s.\n\n"+
+						"The full error was:\n%s", tmpfs, svc.Name, err)
+				}
+			}
+		}
+		addMount(nextTmpfsName(), parts[0], sizeBytes)
+	}
+
+	// Tmpfs mounts defined via the long volume syntax.
+	for _, v := range svc.Volumes {
+		if v.Type != composeTypes.VolumeTypeTmpfs {
+			continue
+		}
+
+		var sizeBytes int64
+		if v.Tmpfs != nil {
+			sizeBytes = v.Tmpfs.Size
+		}
+		addMount(nextTmpfsName(), v.Target, sizeBytes)
+	}
+
+	// Kubernetes doesn't allow configuring the size of /dev/shm, so we mount
+	// a memory-backed volume over it instead.
+	if svc.ShmSize != "" {
+		sizeBytes, err := units.RAMInBytes(svc.ShmSize)
+		if err != nil {
+			return nil, errors.NewFriendlyError("Invalid shm_size (%s) for service %s.\n\n"+
+				"The full error was:\n%s", svc.ShmSize, svc.Name, err)
+		}
+		addMount("shm", "/dev/shm", sizeBytes)
+	}
+
+	return mounts, nil
+}
+
 func toEnvVars(vars composeTypes.MappingWithEquals) (kubeVars []corev1.EnvVar) {
 	for k, vPtr := range vars {
 		// vPtr may be nil if only the key is specified.
@@ -483,15 +841,141 @@ func toEnvVars(vars composeTypes.MappingWithEquals) (kubeVars []corev1.EnvVar) {
 	return
 }
 
-func toReadinessProbe(healthCheck *composeTypes.HealthCheckConfig) *corev1.Probe {
-	if healthCheck == nil || len(healthCheck.Test) <= 1 {
-		return nil
+// toResourceRequirements translates the resource constraints for a service
+// into the Kubernetes equivalent. The `deploy.resources` settings take
+// precedence over the legacy `cpus`, `mem_limit`, and `mem_reservation`
+// fields.
+func toResourceRequirements(svc composeTypes.ServiceConfig) (corev1.ResourceRequirements, error) {
+	// If Requests are not set, they will default to the same as the Limits,
+	// which are too high.
+	resources := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(defaultCPULimit),
+			corev1.ResourceMemory: resource.MustParse(defaultMemoryLimit),
+		},
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(defaultCPURequest),
+			corev1.ResourceMemory: resource.MustParse(defaultMemoryRequest),
+		},
+	}
+
+	// Track which values were explicitly set so that we know which value to
+	// adjust if the request is larger than the limit.
+	userLimits := map[corev1.ResourceName]bool{}
+	userRequests := map[corev1.ResourceName]bool{}
+	setResource := func(list corev1.ResourceList, userSet map[corev1.ResourceName]bool,
+		name corev1.ResourceName, quantity resource.Quantity) {
+		list[name] = quantity
+		userSet[name] = true
+	}
+
+	if svc.CPUS > 0 {
+		setResource(resources.Limits, userLimits, corev1.ResourceCPU, cpuQuantity(float64(svc.CPUS)))
+	}
+	if svc.MemLimit > 0 {
+		setResource(resources.Limits, userLimits, corev1.ResourceMemory, memoryQuantity(svc.MemLimit))
+	}
+	if svc.MemReservation > 0 {
+		setResource(resources.Requests, userRequests, corev1.ResourceMemory, memoryQuantity(svc.MemReservation))
+	}
+
+	if svc.Deploy != nil {
+		for _, r := range []struct {
+			resource *composeTypes.Resource
+			list     corev1.ResourceList
+			userSet  map[corev1.ResourceName]bool
+		}{
+			{svc.Deploy.Resources.Limits, resources.Limits, userLimits},
+			{svc.Deploy.Resources.Reservations, resources.Requests, userRequests},
+		} {
+			if r.resource == nil {
+				continue
+			}
+
+			if r.resource.NanoCPUs != "" {
+				cpus, err := strconv.ParseFloat(r.resource.NanoCPUs, 64)
+				if err != nil || cpus <= 0 {
+					return corev1.ResourceRequirements{}, errors.NewFriendlyError(
+						"Invalid cpus (%s) in deploy.resources for service %s.\n"+
+							"Expected a positive number, such as 0.5.", r.resource.NanoCPUs, svc.Name)
+				}
+				setResource(r.list, r.userSet, corev1.ResourceCPU, cpuQuantity(cpus))
+			}
+			if r.resource.MemoryBytes > 0 {
+				setResource(r.list, r.userSet, corev1.ResourceMemory, memoryQuantity(r.resource.MemoryBytes))
+			}
+		}
+	}
+
+	// Kubernetes rejects pods whose requests are larger than their limits.
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		limit := resources.Limits[name]
+		request := resources.Requests[name]
+		if request.Cmp(limit) <= 0 {
+			continue
+		}
+
+		switch {
+		case userLimits[name] && userRequests[name]:
+			return corev1.ResourceRequirements{}, errors.NewFriendlyError(
+				"The %s reservation (%s) for service %s is larger than its limit (%s).",
+				name, request.String(), svc.Name, limit.String())
+		case userLimits[name]:
+			resources.Requests[name] = limit
+		default:
+			resources.Limits[name] = request
+		}
+	}
+
+	return resources, nil
+}
+
+// numReplicas returns the number of pods that should be deployed for the
+// service.
+func numReplicas(svc composeTypes.ServiceConfig) int {
+	if svc.Deploy != nil && svc.Deploy.Replicas != nil {
+		return int(*svc.Deploy.Replicas)
+	}
+	if svc.Scale != 0 {
+		return svc.Scale
+	}
+	return 1
+}
+
+func cpuQuantity(cpus float64) resource.Quantity {
+	return *resource.NewMilliQuantity(int64(math.Round(cpus*1000)), resource.DecimalSI)
+}
+
+func memoryQuantity(bytes composeTypes.UnitBytes) resource.Quantity {
+	return *resource.NewQuantity(int64(bytes), resource.BinarySI)
+}
+
+// restartUnhealthy returns whether the service opted into having its
+// container restarted when its healthcheck fails via its x-blimp block.
+func restartUnhealthy(svc composeTypes.ServiceConfig) bool {
+	// The x-blimp block is validated when the pod builder is created.
+	ext, _ := dockercompose.ParseBlimpExtension(svc)
+	return ext.RestartUnhealthy
+}
+
+// toProbes translates a service's healthcheck into Kubernetes probes. The
+// readiness probe tracks whether the service is healthy.
+//
+// Docker never restarts unhealthy containers, so the liveness probe is only
+// created if the service opts in via restartUnhealthy. In that
+// case, a startup probe gives the container its `start_period` to become
+// healthy before the liveness probe takes effect, since Docker doesn't count
+// failures during the start period.
+func toProbes(svc composeTypes.ServiceConfig) (readiness, startup, liveness *corev1.Probe) {
+	healthCheck := svc.HealthCheck
+	if healthCheck == nil || healthCheck.Disable || len(healthCheck.Test) <= 1 {
+		return nil, nil, nil
 	}
 
 	var command []string
 	switch healthCheck.Test[0] {
 	case "NONE":
-		return nil
+		return nil, nil, nil
 	case "CMD":
 		command = healthCheck.Test[1:]
 	case "CMD-SHELL":
@@ -501,46 +985,68 @@ func toReadinessProbe(healthCheck *composeTypes.HealthCheckConfig) *corev1.Probe
 		// healthcheck, but we currently don't have a way of sending warnings
 		// back to the CLI.
 		log.WithField("command", command).Warn("Ignoring healthcheck with unrecognized command")
-		return nil
+		return nil, nil, nil
 	}
 
-	probe := &corev1.Probe{
-		Handler: corev1.Handler{
-			Exec: &corev1.ExecAction{
-				Command: command,
-			},
-		},
-
-		// We use the Docker defaults for the healthcheck settings, rather than
-		// the Kubernetes defaults.
-		// By default, Kubernetes checks more aggressively (e.g. its default
-		// check interval is 10 seconds), which exacerbates Docker performance
-		// issues with exec probes.
-		//
-		// We don't know the root cause of the performance issues, but for some
-		// possible related issues see:
-		// * https://github.com/kubernetes/kubernetes/issues/82440
-		// * https://serverfault.com/questions/973817/google-cloud-kuberbetes-run-away-systemd-100-cpu-usage
-		TimeoutSeconds:      30,
-		PeriodSeconds:       30,
-		InitialDelaySeconds: 0,
-		FailureThreshold:    3,
+	// We use the Docker defaults for the healthcheck settings, rather than
+	// the Kubernetes defaults.
+	// By default, Kubernetes checks more aggressively (e.g. its default
+	// check interval is 10 seconds), which exacerbates Docker performance
+	// issues with exec probes.
+	//
+	// We don't know the root cause of the performance issues, but for some
+	// possible related issues see:
+	// * https://github.com/kubernetes/kubernetes/issues/82440
+	// * https://serverfault.com/questions/973817/google-cloud-kuberbetes-run-away-systemd-100-cpu-usage
+	timeout := durationSeconds(healthCheck.Timeout, 30)
+	interval := durationSeconds(healthCheck.Interval, 30)
+	retries := int32(3)
+	if healthCheck.Retries != nil && *healthCheck.Retries > 0 {
+		retries = int32(*healthCheck.Retries)
 	}
 
-	if healthCheck.Timeout != nil {
-		probe.TimeoutSeconds = int32(time.Duration(*healthCheck.Timeout).Seconds())
+	makeProbe := func(failureThreshold int32) *corev1.Probe {
+		return &corev1.Probe{
+			Handler: corev1.Handler{
+				Exec: &corev1.ExecAction{
+					Command: command,
+				},
+			},
+			TimeoutSeconds:   timeout,
+			PeriodSeconds:    interval,
+			SuccessThreshold: 1,
+			FailureThreshold: failureThreshold,
+		}
 	}
-	if healthCheck.Interval != nil {
-		probe.PeriodSeconds = int32(time.Duration(*healthCheck.Interval).Seconds())
+
+	// The readiness probe doesn't need to account for the start period since
+	// pods start off unready, and become ready as soon as the first check
+	// succeeds.
+	readiness = makeProbe(retries)
+	if !restartUnhealthy(svc) {
+		return readiness, nil, nil
 	}
+
+	liveness = makeProbe(retries)
 	if healthCheck.StartPeriod != nil {
-		probe.InitialDelaySeconds = int32(time.Duration(*healthCheck.StartPeriod).Seconds())
-	}
-	if healthCheck.Retries != nil {
-		probe.FailureThreshold = int32(*healthCheck.Retries)
+		startPeriod := durationSeconds(healthCheck.StartPeriod, 0)
+		startupChecks := int32(math.Ceil(float64(startPeriod) / float64(interval)))
+		startup = makeProbe(startupChecks + retries)
+
+		// Fallback for clusters that don't have the StartupProbe feature
+		// enabled.
+		liveness.InitialDelaySeconds = startPeriod
 	}
+	return readiness, startup, liveness
+}
 
-	return probe
+// durationSeconds rounds the given duration up to the nearest second, since
+// probe settings can't be less than a second.
+func durationSeconds(duration *composeTypes.Duration, defaultSeconds int32) int32 {
+	if duration == nil {
+		return defaultSeconds
+	}
+	return int32(math.Ceil(time.Duration(*duration).Seconds()))
 }
 
 func marshalDependencies(dependsOn composeTypes.DependsOnConfig, links []string) []*wait.ServiceCondition {
@@ -608,16 +1114,22 @@ func (p *podSpec) sanitize() {
 // volume into the pod's init container. The init container passes it
 // to the node controller, which blocks boot until the requirements
 // are met.
-func (p *podSpec) addWaiter(nodeControllerIP, svcName, waitType string, spec wait.WaitSpec) error {
+func (p *podSpec) addWaiter(nodeControllerIP, waitType string, spec wait.WaitSpec) error {
 	waitSpecBytes, err := proto.Marshal(&spec)
 	if err != nil {
 		return errors.WithContext("marshal wait spec", err)
 	}
 
+	// Replicas may have different wait specs, so they each get their own
+	// ConfigMap.
+	waitSpecID := p.service
+	if p.replica != 0 {
+		waitSpecID = p.name
+	}
 	configMap := corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: p.namespace,
-			Name:      names.ToDNS1123(fmt.Sprintf("wait-spec-%s-%s", waitType, svcName)),
+			Name:      names.ToDNS1123(fmt.Sprintf("wait-spec-%s-%s", waitType, waitSpecID)),
 		},
 		BinaryData: map[string][]byte{
 			"wait-spec": waitSpecBytes,
@@ -2,9 +2,14 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	composeTypes "github.com/kelda/compose-go/types"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/kelda/blimp/pkg/proto/wait"
 	"github.com/kelda/blimp/pkg/hash"
@@ -32,3 +37,493 @@ func TestWaitSpecHash(t *testing.T) {
 		}
 	}
 }
+
+func TestToResourceRequirements(t *testing.T) {
+	resources := func(cpuLimit, memLimit, cpuRequest, memRequest string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpuLimit),
+				corev1.ResourceMemory: resource.MustParse(memLimit),
+			},
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpuRequest),
+				corev1.ResourceMemory: resource.MustParse(memRequest),
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		svc    composeTypes.ServiceConfig
+		exp    corev1.ResourceRequirements
+		expErr bool
+	}{
+		{
+			name: "defaults",
+			svc:  composeTypes.ServiceConfig{},
+			exp:  resources("4", "16Gi", "20m", "50Mi"),
+		},
+		{
+			name: "legacy fields",
+			svc: composeTypes.ServiceConfig{
+				CPUS:           0.5,
+				MemLimit:       1 << 30,
+				MemReservation: 512 << 20,
+			},
+			exp: resources("500m", "1Gi", "20m", "512Mi"),
+		},
+		{
+			name: "deploy resources take precedence",
+			svc: composeTypes.ServiceConfig{
+				CPUS: 0.5,
+				Deploy: &composeTypes.DeployConfig{
+					Resources: composeTypes.Resources{
+						Limits: &composeTypes.Resource{
+							NanoCPUs:    "2",
+							MemoryBytes: 4 << 30,
+						},
+						Reservations: &composeTypes.Resource{
+							NanoCPUs:    "0.25",
+							MemoryBytes: 2 << 30,
+						},
+					},
+				},
+			},
+			exp: resources("2", "4Gi", "250m", "2Gi"),
+		},
+		{
+			name: "limit below default request",
+			svc: composeTypes.ServiceConfig{
+				MemLimit: 32 << 20,
+			},
+			exp: resources("4", "32Mi", "20m", "32Mi"),
+		},
+		{
+			name: "reservation above default limit",
+			svc: composeTypes.ServiceConfig{
+				MemReservation: 32 << 30,
+			},
+			exp: resources("4", "32Gi", "20m", "32Gi"),
+		},
+		{
+			name: "reservation above explicit limit",
+			svc: composeTypes.ServiceConfig{
+				MemLimit:       1 << 30,
+				MemReservation: 2 << 30,
+			},
+			expErr: true,
+		},
+		{
+			name: "invalid cpus",
+			svc: composeTypes.ServiceConfig{
+				Deploy: &composeTypes.DeployConfig{
+					Resources: composeTypes.Resources{
+						Limits: &composeTypes.Resource{NanoCPUs: "lots"},
+					},
+				},
+			},
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			res, err := toResourceRequirements(test.svc)
+			if test.expErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+				expLimit, expRequest := test.exp.Limits[name], test.exp.Requests[name]
+				assert.Zero(t, expLimit.Cmp(res.Limits[name]), "%s limit: %s", name, res.Limits[name])
+				assert.Zero(t, expRequest.Cmp(res.Requests[name]), "%s request: %s", name, res.Requests[name])
+			}
+		})
+	}
+}
+
+func TestAddMemoryVolumes(t *testing.T) {
+	memoryVolume := func(name, size string) corev1.Volume {
+		var sizeLimit *resource.Quantity
+		if size != "" {
+			quantity := resource.MustParse(size)
+			sizeLimit = &quantity
+		}
+		return corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{
+					Medium:    corev1.StorageMediumMemory,
+					SizeLimit: sizeLimit,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		svc        composeTypes.ServiceConfig
+		expVolumes []corev1.Volume
+		expMounts  []corev1.VolumeMount
+		expErr     bool
+	}{
+		{
+			name: "none",
+			svc:  composeTypes.ServiceConfig{Name: "web"},
+		},
+		{
+			name: "tmpfs and shm_size",
+			svc: composeTypes.ServiceConfig{
+				Name:  "web",
+				Tmpfs: composeTypes.StringList{"/run", "/tmp:size=64m,mode=1777"},
+				Volumes: []composeTypes.ServiceVolumeConfig{
+					{Type: composeTypes.VolumeTypeVolume, Source: "data", Target: "/data"},
+					{
+						Type:   composeTypes.VolumeTypeTmpfs,
+						Target: "/cache",
+						Tmpfs:  &composeTypes.ServiceVolumeTmpfs{Size: 1024 * 1024},
+					},
+				},
+				ShmSize: "2gb",
+			},
+			expVolumes: []corev1.Volume{
+				memoryVolume("tmpfs-0", ""),
+				memoryVolume("tmpfs-1", "64Mi"),
+				memoryVolume("tmpfs-2", "1Mi"),
+				memoryVolume("shm", "2Gi"),
+			},
+			expMounts: []corev1.VolumeMount{
+				{Name: "tmpfs-0", MountPath: "/run"},
+				{Name: "tmpfs-1", MountPath: "/tmp"},
+				{Name: "tmpfs-2", MountPath: "/cache"},
+				{Name: "shm", MountPath: "/dev/shm"},
+			},
+		},
+		{
+			name: "invalid shm_size",
+			svc: composeTypes.ServiceConfig{
+				Name:    "web",
+				ShmSize: "lots",
+			},
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var spec podSpec
+			mounts, err := spec.addMemoryVolumes(test.svc)
+			if test.expErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expMounts, mounts)
+			assert.Len(t, spec.pod.Spec.Volumes, len(test.expVolumes))
+			for i, exp := range test.expVolumes {
+				actual := spec.pod.Spec.Volumes[i]
+				assert.Equal(t, exp.Name, actual.Name)
+				assert.Equal(t, exp.EmptyDir.Medium, actual.EmptyDir.Medium)
+				if exp.EmptyDir.SizeLimit == nil {
+					assert.Nil(t, actual.EmptyDir.SizeLimit)
+				} else {
+					assert.Zero(t, exp.EmptyDir.SizeLimit.Cmp(*actual.EmptyDir.SizeLimit))
+				}
+			}
+		})
+	}
+}
+
+func TestToProbes(t *testing.T) {
+	duration := func(d time.Duration) *composeTypes.Duration {
+		composeDuration := composeTypes.Duration(d)
+		return &composeDuration
+	}
+	retries := func(n uint64) *uint64 {
+		return &n
+	}
+	probe := func(command []string, timeout, period, failureThreshold int32) *corev1.Probe {
+		return &corev1.Probe{
+			Handler: corev1.Handler{
+				Exec: &corev1.ExecAction{Command: command},
+			},
+			TimeoutSeconds:   timeout,
+			PeriodSeconds:    period,
+			SuccessThreshold: 1,
+			FailureThreshold: failureThreshold,
+		}
+	}
+	shellCheck := []string{"sh", "-c", "curl localhost"}
+	restartUnhealthyExtension := map[string]interface{}{
+		"x-blimp": map[string]interface{}{"restart_unhealthy": true},
+	}
+
+	tests := []struct {
+		name         string
+		svc          composeTypes.ServiceConfig
+		expReadiness *corev1.Probe
+		expStartup   *corev1.Probe
+		expLiveness  *corev1.Probe
+	}{
+		{
+			name: "no healthcheck",
+			svc:  composeTypes.ServiceConfig{},
+		},
+		{
+			name: "disabled",
+			svc: composeTypes.ServiceConfig{
+				HealthCheck: &composeTypes.HealthCheckConfig{
+					Test:    composeTypes.HealthCheckTest{"CMD-SHELL", "curl localhost"},
+					Disable: true,
+				},
+			},
+		},
+		{
+			name: "NONE",
+			svc: composeTypes.ServiceConfig{
+				HealthCheck: &composeTypes.HealthCheckConfig{
+					Test: composeTypes.HealthCheckTest{"NONE", "ignored"},
+				},
+			},
+		},
+		{
+			name: "defaults",
+			svc: composeTypes.ServiceConfig{
+				HealthCheck: &composeTypes.HealthCheckConfig{
+					Test: composeTypes.HealthCheckTest{"CMD", "pg_isready", "-U", "postgres"},
+				},
+			},
+			expReadiness: probe([]string{"pg_isready", "-U", "postgres"}, 30, 30, 3),
+		},
+		{
+			name: "readiness ignores start period",
+			svc: composeTypes.ServiceConfig{
+				HealthCheck: &composeTypes.HealthCheckConfig{
+					Test:        composeTypes.HealthCheckTest{"CMD-SHELL", "curl localhost"},
+					Timeout:     duration(500 * time.Millisecond),
+					Interval:    duration(5 * time.Second),
+					Retries:     retries(5),
+					StartPeriod: duration(time.Minute),
+				},
+			},
+			expReadiness: probe(shellCheck, 1, 5, 5),
+		},
+		{
+			name: "restart unhealthy",
+			svc: composeTypes.ServiceConfig{
+				HealthCheck: &composeTypes.HealthCheckConfig{
+					Test:     composeTypes.HealthCheckTest{"CMD-SHELL", "curl localhost"},
+					Interval: duration(10 * time.Second),
+				},
+				Extensions: restartUnhealthyExtension,
+			},
+			expReadiness: probe(shellCheck, 30, 10, 3),
+			expLiveness:  probe(shellCheck, 30, 10, 3),
+		},
+		{
+			name: "restart unhealthy with start period",
+			svc: composeTypes.ServiceConfig{
+				HealthCheck: &composeTypes.HealthCheckConfig{
+					Test:        composeTypes.HealthCheckTest{"CMD-SHELL", "curl localhost"},
+					Interval:    duration(10 * time.Second),
+					Retries:     retries(2),
+					StartPeriod: duration(45 * time.Second),
+				},
+				Extensions: restartUnhealthyExtension,
+			},
+			expReadiness: probe(shellCheck, 30, 10, 2),
+			expStartup:   probe(shellCheck, 30, 10, 7),
+			expLiveness: func() *corev1.Probe {
+				p := probe(shellCheck, 30, 10, 2)
+				p.InitialDelaySeconds = 45
+				return p
+			}(),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			readiness, startup, liveness := toProbes(test.svc)
+			assert.Equal(t, test.expReadiness, readiness)
+			assert.Equal(t, test.expStartup, startup)
+			assert.Equal(t, test.expLiveness, liveness)
+		})
+	}
+}
+
+func TestAddStopBehavior(t *testing.T) {
+	truePtr := func() *bool {
+		b := true
+		return &b
+	}
+	duration := func(d time.Duration) *composeTypes.Duration {
+		composeDuration := composeTypes.Duration(d)
+		return &composeDuration
+	}
+	images := fakeImageInspector{
+		config: v1.Config{
+			Entrypoint: []string{"docker-entrypoint.sh"},
+			Cmd:        []string{"worker"},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		svc            composeTypes.ServiceConfig
+		expGracePeriod *int64
+		expCommand     []string
+		expArgs        []string
+		expPreStop     []string
+		expTini        bool
+		expErr         bool
+	}{
+		{
+			name: "defaults",
+			svc: composeTypes.ServiceConfig{
+				Command: composeTypes.ShellCommand{"worker"},
+			},
+			expArgs: []string{"worker"},
+		},
+		{
+			name: "stop signal and grace period",
+			svc: composeTypes.ServiceConfig{
+				StopSignal:      "quit",
+				StopGracePeriod: duration(2 * time.Minute),
+			},
+			expGracePeriod: func() *int64 {
+				seconds := int64(120)
+				return &seconds
+			}(),
+			expPreStop: []string{tiniPath, "-signal", "SIGQUIT"},
+			expTini:    true,
+		},
+		{
+			name: "SIGTERM doesn't need a hook",
+			svc: composeTypes.ServiceConfig{
+				StopSignal: "SIGTERM",
+			},
+		},
+		{
+			name: "invalid stop signal",
+			svc: composeTypes.ServiceConfig{
+				StopSignal: "SIGNOTREAL",
+			},
+			expErr: true,
+		},
+		{
+			name: "init with entrypoint",
+			svc: composeTypes.ServiceConfig{
+				Init:       truePtr(),
+				Entrypoint: composeTypes.ShellCommand{"/app/run"},
+			},
+			expCommand: []string{tiniPath, "--", "/app/run"},
+			expTini:    true,
+		},
+		{
+			name: "init with image entrypoint",
+			svc: composeTypes.ServiceConfig{
+				Init: truePtr(),
+			},
+			expCommand: []string{tiniPath, "--", "docker-entrypoint.sh"},
+			expArgs:    []string{"worker"},
+			expTini:    true,
+		},
+		{
+			name: "init with image entrypoint and custom command",
+			svc: composeTypes.ServiceConfig{
+				Init:    truePtr(),
+				Command: composeTypes.ShellCommand{"scheduler"},
+			},
+			expCommand: []string{tiniPath, "--", "docker-entrypoint.sh"},
+			expArgs:    []string{"scheduler"},
+			expTini:    true,
+		},
+		{
+			name: "ulimits",
+			svc: composeTypes.ServiceConfig{
+				Ulimits: map[string]*composeTypes.UlimitsConfig{
+					"nproc":  {Single: 65535},
+					"nofile": {Soft: 20000, Hard: 40000},
+				},
+			},
+			expCommand: []string{tiniPath, "-rlimit", "nofile=20000:40000", "-rlimit", "nproc=65535:65535",
+				"-exec", "--", "docker-entrypoint.sh"},
+			expArgs: []string{"worker"},
+			expTini: true,
+		},
+		{
+			name: "ulimits with init",
+			svc: composeTypes.ServiceConfig{
+				Init:       truePtr(),
+				Entrypoint: composeTypes.ShellCommand{"/app/run"},
+				Ulimits: map[string]*composeTypes.UlimitsConfig{
+					"core": {Single: -1},
+				},
+			},
+			expCommand: []string{tiniPath, "-rlimit", "core=-1:-1", "--", "/app/run"},
+			expTini:    true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var spec podSpec
+			spec.pod.Spec.Containers = []corev1.Container{{
+				Command: test.svc.Entrypoint,
+				Args:    test.svc.Command,
+			}}
+
+			err := spec.addStopBehavior(test.svc, images)
+			if test.expErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			container := spec.pod.Spec.Containers[0]
+			assert.Equal(t, test.expGracePeriod, spec.pod.Spec.TerminationGracePeriodSeconds)
+			assert.Equal(t, test.expCommand, container.Command)
+			assert.Equal(t, test.expArgs, container.Args)
+
+			if test.expPreStop == nil {
+				assert.Nil(t, container.Lifecycle)
+			} else {
+				assert.Equal(t, test.expPreStop, container.Lifecycle.PreStop.Exec.Command)
+			}
+
+			if test.expTini {
+				assert.Len(t, spec.pod.Spec.InitContainers, 1)
+				assert.Len(t, container.VolumeMounts, 1)
+			} else {
+				assert.Empty(t, spec.pod.Spec.InitContainers)
+				assert.Empty(t, container.VolumeMounts)
+			}
+		})
+	}
+}
+
+type fakeImageInspector struct {
+	config v1.Config
+	files  map[string][]byte
+}
+
+func (images fakeImageInspector) Config(image string) (v1.Config, error) {
+	return images.config, nil
+}
+
+func (images fakeImageInspector) ReadFiles(image string, paths ...string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for _, path := range paths {
+		if contents, ok := images.files[path]; ok {
+			files[path] = contents
+		}
+	}
+	return files, nil
+}
@@ -45,11 +45,6 @@ func (s *server) BlimpUpPreview(req *cluster.BlimpUpPreviewRequest, srv cluster.
 		return err
 	}
 
-	blimpCmd := []string{"blimp", "up", "-d", "--disable-status-output"}
-	for _, f := range req.GetComposeFiles() {
-		blimpCmd = append(blimpCmd, "-f", f)
-	}
-
 	env := []corev1.EnvVar{
 		{
 			Name:  "BLIMP_TOKEN",
@@ -88,7 +83,7 @@ func (s *server) BlimpUpPreview(req *cluster.BlimpUpPreviewRequest, srv cluster.
 					},
 				},
 				Env:  env,
-				Args: blimpCmd,
+				Args: previewCommand(req),
 			}},
 			RestartPolicy: corev1.RestartPolicyNever,
 		},
@@ -143,3 +138,18 @@ func (s *server) BlimpUpPreview(req *cluster.BlimpUpPreviewRequest, srv cluster.
 		}
 	}
 }
+
+// previewCommand returns the `blimp up` command that boots the preview.
+func previewCommand(req *cluster.BlimpUpPreviewRequest) []string {
+	blimpCmd := []string{"blimp", "up", "-d", "--disable-status-output"}
+	for _, f := range req.GetComposeFiles() {
+		blimpCmd = append(blimpCmd, "-f", f)
+	}
+	for _, profile := range req.GetProfiles() {
+		blimpCmd = append(blimpCmd, "--profile", profile)
+	}
+	if req.GetLocked() {
+		blimpCmd = append(blimpCmd, "--locked")
+	}
+	return blimpCmd
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/blimp/pkg/proto/cluster"
+)
+
+func TestPreviewCommand(t *testing.T) {
+	assert.Equal(t, []string{"blimp", "up", "-d", "--disable-status-output"},
+		previewCommand(&cluster.BlimpUpPreviewRequest{}))
+
+	assert.Equal(t, []string{"blimp", "up", "-d", "--disable-status-output",
+		"-f", "docker-compose.yml", "-f", "docker-compose.preview.yml",
+		"--profile", "frontend", "--profile", "debug"},
+		previewCommand(&cluster.BlimpUpPreviewRequest{
+			ComposeFiles: []string{"docker-compose.yml", "docker-compose.preview.yml"},
+			Profiles:     []string{"frontend", "debug"},
+		}))
+
+	assert.Equal(t, []string{"blimp", "up", "-d", "--disable-status-output", "--locked"},
+		previewCommand(&cluster.BlimpUpPreviewRequest{Locked: true}))
+}
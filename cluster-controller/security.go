@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kelda/blimp/pkg/errors"
+	"github.com/kelda/blimp/pkg/metadata"
+)
+
+// securityPolicy decides which of the security-sensitive Compose options
+// services are allowed to use. Sandboxes share nodes with other users, so by
+// default we only allow options that don't give containers more privileges
+// than they'd have by default.
+type securityPolicy struct {
+	allowPrivileged bool
+
+	// allowUnconfined controls whether services can disable the seccomp and
+	// AppArmor profiles via `security_opt`.
+	allowUnconfined bool
+
+	// allowedCapabilities are the capabilities that can be added via
+	// `cap_add`. Dropping capabilities is always allowed.
+	allowedCapabilities map[string]struct{}
+
+	allowedSysctls map[string]struct{}
+
+	// allowedUlimits are the ulimits that services can set. Kubernetes
+	// doesn't have a way to set ulimits, so blimp-tini sets them before
+	// starting the service. Containers can't raise their hard limits, so
+	// services that ask for more than the node's limits fail to start.
+	allowedUlimits map[string]struct{}
+}
+
+// defaultAllowedCapabilities are the capabilities granted by Docker by
+// default. Adding them is a no-op, but some Compose files do so explicitly.
+var defaultAllowedCapabilities = []string{
+	"AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL",
+	"MKNOD", "NET_BIND_SERVICE", "NET_RAW", "SETFCAP", "SETGID", "SETPCAP",
+	"SETUID", "SYS_CHROOT",
+}
+
+// defaultAllowedSysctls are the sysctls that Kubernetes considers safe since
+// they're namespaced, and can't affect other pods on the node.
+var defaultAllowedSysctls = []string{
+	"kernel.shm_rmid_forced",
+	"net.ipv4.ip_local_port_range",
+	"net.ipv4.tcp_syncookies",
+	"net.ipv4.ping_group_range",
+}
+
+var defaultAllowedUlimits = []string{"core", "nofile", "nproc"}
+
+// securityPolicyFromEnv returns the default security policy, extended by the
+// cluster operator's configuration in the environment. Note that any sysctls
+// added via ALLOWED_SYSCTLS must also be allowed by the kubelet.
+func securityPolicyFromEnv() securityPolicy {
+	splitEnv := func(key string) []string {
+		var vals []string
+		for _, val := range strings.Split(os.Getenv(key), ",") {
+			if val = strings.TrimSpace(val); val != "" {
+				vals = append(vals, val)
+			}
+		}
+		return vals
+	}
+
+	var capabilities []string
+	for _, capability := range splitEnv("ALLOWED_CAPABILITIES") {
+		capabilities = append(capabilities, normalizeCapability(capability))
+	}
+
+	return securityPolicy{
+		allowPrivileged:     os.Getenv("ALLOW_PRIVILEGED_CONTAINERS") == "true",
+		allowUnconfined:     os.Getenv("ALLOW_UNCONFINED_CONTAINERS") == "true",
+		allowedCapabilities: toSet(append(capabilities, defaultAllowedCapabilities...)),
+		allowedSysctls:      toSet(append(splitEnv("ALLOWED_SYSCTLS"), defaultAllowedSysctls...)),
+		allowedUlimits:      toSet(defaultAllowedUlimits),
+	}
+}
+
+// securitySettings are the Kubernetes equivalents of a service's security
+// options.
+type securitySettings struct {
+	container   corev1.SecurityContext
+	sysctls     []corev1.Sysctl
+	annotations map[string]string
+}
+
+// apply translates the security options for the given service, and returns
+// a friendly error listing any options that aren't allowed by the policy.
+func (policy securityPolicy) apply(svc composeTypes.ServiceConfig, containerName string) (
+	securitySettings, error) {
+
+	settings := securitySettings{annotations: map[string]string{}}
+	var refused []string
+
+	if svc.Privileged {
+		if policy.allowPrivileged {
+			privileged := true
+			settings.container.Privileged = &privileged
+		} else {
+			refused = append(refused, "privileged")
+		}
+	}
+
+	if svc.ReadOnly {
+		readOnly := true
+		settings.container.ReadOnlyRootFilesystem = &readOnly
+	}
+
+	var capabilities corev1.Capabilities
+	for _, capability := range svc.CapAdd {
+		capability = normalizeCapability(capability)
+		if _, ok := policy.allowedCapabilities[capability]; !ok {
+			refused = append(refused, fmt.Sprintf("cap_add: %s", capability))
+			continue
+		}
+		capabilities.Add = append(capabilities.Add, corev1.Capability(capability))
+	}
+	for _, capability := range svc.CapDrop {
+		capabilities.Drop = append(capabilities.Drop, corev1.Capability(normalizeCapability(capability)))
+	}
+	if len(capabilities.Add) != 0 || len(capabilities.Drop) != 0 {
+		settings.container.Capabilities = &capabilities
+	}
+
+	for name, value := range svc.Sysctls {
+		if _, ok := policy.allowedSysctls[name]; !ok {
+			refused = append(refused, fmt.Sprintf("sysctls: %s", name))
+			continue
+		}
+		settings.sysctls = append(settings.sysctls, corev1.Sysctl{Name: name, Value: value})
+	}
+	// Sort for consistency to avoid unnecessary pod restarts.
+	sort.Slice(settings.sysctls, func(i, j int) bool {
+		return settings.sysctls[i].Name < settings.sysctls[j].Name
+	})
+
+	for _, opt := range svc.SecurityOpt {
+		// Docker accepts both `key=value` and `key:value`.
+		parts := strings.SplitN(strings.Replace(opt, ":", "=", 1), "=", 2)
+		key, value := parts[0], ""
+		if len(parts) == 2 {
+			value = parts[1]
+		}
+
+		switch {
+		case key == "no-new-privileges" && (value == "" || value == "true"):
+			allowEscalation := false
+			settings.container.AllowPrivilegeEscalation = &allowEscalation
+		case key == "no-new-privileges" && value == "false":
+		case (key == "seccomp" || key == "apparmor") && value == "unconfined" && policy.allowUnconfined:
+			prefix := metadata.SeccompAnnotationPrefix
+			if key == "apparmor" {
+				prefix = metadata.AppArmorAnnotationPrefix
+			}
+			settings.annotations[prefix+containerName] = "unconfined"
+		default:
+			refused = append(refused, fmt.Sprintf("security_opt: %s", opt))
+		}
+	}
+
+	for name := range svc.Ulimits {
+		if _, ok := policy.allowedUlimits[name]; !ok {
+			refused = append(refused, fmt.Sprintf("ulimits: %s", name))
+		}
+	}
+
+	if len(refused) != 0 {
+		sort.Strings(refused)
+		return securitySettings{}, errors.NewFriendlyError(
+			"Service %s uses security options that aren't allowed by the Blimp cluster:\n"+
+				"- %s\n\nPlease remove them and try blimp up again.",
+			svc.Name, strings.Join(refused, "\n- "))
+	}
+	return settings, nil
+}
+
+// normalizeCapability converts the capability into the format expected by
+// Kubernetes. Docker allows the names to be lowercase, and prefixed by CAP_.
+func normalizeCapability(capability string) string {
+	return strings.TrimPrefix(strings.ToUpper(capability), "CAP_")
+}
+
+func toSet(strs []string) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, str := range strs {
+		set[str] = struct{}{}
+	}
+	return set
+}
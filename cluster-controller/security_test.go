@@ -0,0 +1,144 @@
+package main
+
+import (
+	"testing"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSecurityPolicy(t *testing.T) {
+	boolPtr := func(b bool) *bool {
+		return &b
+	}
+
+	defaultPolicy := securityPolicy{
+		allowedCapabilities: toSet(defaultAllowedCapabilities),
+		allowedSysctls:      toSet(defaultAllowedSysctls),
+		allowedUlimits:      toSet(defaultAllowedUlimits),
+	}
+	permissivePolicy := securityPolicy{
+		allowPrivileged:     true,
+		allowUnconfined:     true,
+		allowedCapabilities: toSet(append([]string{"SYS_ADMIN"}, defaultAllowedCapabilities...)),
+		allowedSysctls:      toSet(append([]string{"net.core.somaxconn"}, defaultAllowedSysctls...)),
+		allowedUlimits:      toSet(defaultAllowedUlimits),
+	}
+
+	tests := []struct {
+		name   string
+		policy securityPolicy
+		svc    composeTypes.ServiceConfig
+		exp    securitySettings
+		expErr string
+	}{
+		{
+			name:   "no options",
+			policy: defaultPolicy,
+			svc:    composeTypes.ServiceConfig{Name: "web"},
+			exp:    securitySettings{annotations: map[string]string{}},
+		},
+		{
+			name:   "allowed by default",
+			policy: defaultPolicy,
+			svc: composeTypes.ServiceConfig{
+				Name:        "web",
+				ReadOnly:    true,
+				CapAdd:      []string{"cap_net_bind_service"},
+				CapDrop:     []string{"ALL"},
+				Sysctls:     composeTypes.Mapping{"net.ipv4.tcp_syncookies": "1"},
+				SecurityOpt: []string{"no-new-privileges:true"},
+				Ulimits: map[string]*composeTypes.UlimitsConfig{
+					"nofile": {Soft: 20000, Hard: 40000},
+				},
+			},
+			exp: securitySettings{
+				container: corev1.SecurityContext{
+					ReadOnlyRootFilesystem:   boolPtr(true),
+					AllowPrivilegeEscalation: boolPtr(false),
+					Capabilities: &corev1.Capabilities{
+						Add:  []corev1.Capability{"NET_BIND_SERVICE"},
+						Drop: []corev1.Capability{"ALL"},
+					},
+				},
+				sysctls: []corev1.Sysctl{
+					{Name: "net.ipv4.tcp_syncookies", Value: "1"},
+				},
+				annotations: map[string]string{},
+			},
+		},
+		{
+			name:   "refused by default",
+			policy: defaultPolicy,
+			svc: composeTypes.ServiceConfig{
+				Name:        "web",
+				Privileged:  true,
+				CapAdd:      []string{"SYS_ADMIN"},
+				Sysctls:     composeTypes.Mapping{"net.core.somaxconn": "1024"},
+				SecurityOpt: []string{"seccomp=unconfined"},
+				Ulimits: map[string]*composeTypes.UlimitsConfig{
+					"memlock": {Single: -1},
+				},
+			},
+			expErr: "Service web uses security options that aren't allowed by the Blimp cluster:\n" +
+				"- cap_add: SYS_ADMIN\n" +
+				"- privileged\n" +
+				"- security_opt: seccomp=unconfined\n" +
+				"- sysctls: net.core.somaxconn\n" +
+				"- ulimits: memlock\n\n" +
+				"Please remove them and try blimp up again.",
+		},
+		{
+			name:   "permissive policy",
+			policy: permissivePolicy,
+			svc: composeTypes.ServiceConfig{
+				Name:        "web",
+				Privileged:  true,
+				CapAdd:      []string{"SYS_ADMIN"},
+				Sysctls:     composeTypes.Mapping{"net.core.somaxconn": "1024"},
+				SecurityOpt: []string{"seccomp:unconfined", "apparmor=unconfined"},
+			},
+			exp: securitySettings{
+				container: corev1.SecurityContext{
+					Privileged: boolPtr(true),
+					Capabilities: &corev1.Capabilities{
+						Add: []corev1.Capability{"SYS_ADMIN"},
+					},
+				},
+				sysctls: []corev1.Sysctl{
+					{Name: "net.core.somaxconn", Value: "1024"},
+				},
+				annotations: map[string]string{
+					"container.seccomp.security.alpha.kubernetes.io/web": "unconfined",
+					"container.apparmor.security.beta.kubernetes.io/web": "unconfined",
+				},
+			},
+		},
+		{
+			name:   "custom profiles are never allowed",
+			policy: permissivePolicy,
+			svc: composeTypes.ServiceConfig{
+				Name:        "web",
+				SecurityOpt: []string{"seccomp=/path/to/profile.json"},
+			},
+			expErr: "Service web uses security options that aren't allowed by the Blimp cluster:\n" +
+				"- security_opt: seccomp=/path/to/profile.json\n\n" +
+				"Please remove them and try blimp up again.",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			settings, err := test.policy.apply(test.svc, test.svc.Name)
+			if test.expErr != "" {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.exp, settings)
+		})
+	}
+}
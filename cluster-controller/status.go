@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -17,6 +18,8 @@ import (
 
 	"github.com/kelda/blimp/pkg/kube"
 	"github.com/kelda/blimp/pkg/errors"
+	"github.com/kelda/blimp/pkg/metadata"
+	"github.com/kelda/blimp/pkg/names"
 	"github.com/kelda/blimp/pkg/proto/cluster"
 )
 
@@ -125,6 +128,8 @@ func (sf *statusFetcher) Get(namespace string) (cluster.SandboxStatus, error) {
 	sandboxPhase := cluster.SandboxStatus_RUNNING
 
 	services := map[string]*cluster.ServiceStatus{}
+	replicas := map[string]uint32{}
+	readyReplicas := map[string]uint32{}
 	for _, pod := range pods {
 		if pod.GetName() == "reservation" {
 			// Don't include its status, and also mark the sandbox as preparing.
@@ -133,9 +138,58 @@ func (sf *statusFetcher) Get(namespace string) (cluster.SandboxStatus, error) {
 			sandboxPhase = cluster.SandboxStatus_PREPARING
 			continue
 		}
-		svcName := pod.GetLabels()["blimp.service"]
-		serviceStatus := sf.getServiceStatus(pod)
-		services[svcName] = &serviceStatus
+		// Services that share a namespace with another service run as
+		// additional containers in the other service's pod.
+		podServices := []string{pod.GetLabels()["blimp.service"]}
+		if colocated, ok := pod.GetAnnotations()[metadata.ColocatedServicesKey]; ok {
+			podServices = append(podServices, metadata.ParseColocatedServices(colocated)...)
+		}
+
+		for _, svcName := range podServices {
+			container := names.ToDNS1123(svcName)
+			replicas[svcName]++
+			if serviceIsReady(pod, container) {
+				readyReplicas[svcName]++
+			}
+
+			// The service's status is based on its first replica. Pods deployed
+			// before replicas were supported don't have the replica label.
+			if replica, ok := pod.GetLabels()["blimp.replica"]; ok && replica != "0" {
+				if _, ok := services[svcName]; !ok {
+					services[svcName] = &cluster.ServiceStatus{Phase: cluster.ServicePhase_UNKNOWN}
+				}
+				continue
+			}
+			serviceStatus := sf.getServiceStatus(pod, container)
+			serviceStatus.PodName = pod.GetName()
+			services[svcName] = &serviceStatus
+		}
+	}
+
+	// Services whose images failed to build aren't updated. They keep running
+	// the pods from their last successful build, if any, but are reported as
+	// failed so that the user sees the build error.
+	if buildErrors, ok := ns.Annotations[metadata.BuildErrorsKey]; ok {
+		parsed, err := metadata.ParseBuildErrors(buildErrors)
+		if err != nil {
+			log.WithError(err).WithField("namespace", namespace).Warn("Failed to parse build errors")
+		}
+		for svcName, msg := range parsed {
+			var podName string
+			if status, ok := services[svcName]; ok {
+				podName = status.PodName
+			}
+			services[svcName] = &cluster.ServiceStatus{
+				Phase:   cluster.ServicePhase_BUILD_FAILED,
+				Msg:     msg,
+				PodName: podName,
+			}
+		}
+	}
+
+	for svcName, status := range services {
+		status.Replicas = replicas[svcName]
+		status.ReadyReplicas = readyReplicas[svcName]
 	}
 	return cluster.SandboxStatus{
 		Phase:    sandboxPhase,
@@ -183,12 +237,31 @@ func (sf *statusFetcher) isPulling(namespace, pod, fieldPath string) bool {
 	return pullCompleted.IsZero() || pullCompleted.Before(&pullStarted)
 }
 
-func (sf *statusFetcher) getServiceStatus(pod *corev1.Pod) cluster.ServiceStatus {
+// serviceIsReady returns whether the given service's container in the pod is
+// ready. Pods that only run a single service are ready once their container
+// is ready.
+func serviceIsReady(pod *corev1.Pod, container string) bool {
+	if len(pod.Spec.Containers) <= 1 {
+		return podIsReady(pod)
+	}
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == container {
+			return cs.Ready
+		}
+	}
+	return false
+}
+
+// getServiceStatus returns the status of the service that runs in the given
+// container of the pod.
+func (sf *statusFetcher) getServiceStatus(pod *corev1.Pod, container string) cluster.ServiceStatus {
 	// Check if the pod isn't running because an init container is
-	// blocking boot.
+	// blocking boot. Pods that run multiple services have init containers
+	// for each service, which block all the services in the pod.
 	for _, c := range pod.Status.InitContainerStatuses {
 		var phase cluster.ServicePhase
-		switch c.Name {
+		switch kube.InitContainerType(c.Name) {
 		case kube.ContainerNameCopyVCP, kube.ContainerNameInitializeVolumeFromImage,
 			kube.ContainerNameWaitInitializedVolumes:
 			phase = cluster.ServicePhase_INITIALIZING_VOLUMES
@@ -212,7 +285,7 @@ func (sf *statusFetcher) getServiceStatus(pod *corev1.Pod) cluster.ServiceStatus
 
 		// Because the volume initialization container uses the user's image,
 		// we need to explicitly tell users about those errors.
-		if c.Name == kube.ContainerNameInitializeVolumeFromImage {
+		if kube.InitContainerType(c.Name) == kube.ContainerNameInitializeVolumeFromImage {
 			if isImagePullFailure(c) {
 				return cluster.ServiceStatus{
 					Phase: cluster.ServicePhase_PENDING,
@@ -221,7 +294,7 @@ func (sf *statusFetcher) getServiceStatus(pod *corev1.Pod) cluster.ServiceStatus
 			}
 
 			isPulling := sf.isPulling(pod.Namespace, pod.Name,
-				fmt.Sprintf("spec.initContainers{%s}", kube.ContainerNameInitializeVolumeFromImage))
+				fmt.Sprintf("spec.initContainers{%s}", c.Name))
 			if isPulling {
 				return cluster.ServiceStatus{
 					Phase: cluster.ServicePhase_PENDING,
@@ -240,8 +313,7 @@ func (sf *statusFetcher) getServiceStatus(pod *corev1.Pod) cluster.ServiceStatus
 	}
 
 	// Inspect the container's status to give more detailed information.
-	if len(pod.Status.ContainerStatuses) == 1 {
-		cs := pod.Status.ContainerStatuses[0]
+	if cs, ok := getContainerStatus(pod, container); ok {
 		if status, ok := checkClusterError(cs); ok {
 			return status
 		}
@@ -251,6 +323,7 @@ func (sf *statusFetcher) getServiceStatus(pod *corev1.Pod) cluster.ServiceStatus
 			if !cs.Ready {
 				return cluster.ServiceStatus{
 					Phase:      cluster.ServicePhase_UNHEALTHY,
+					Msg:        sf.getHealthCheckFailure(pod, cs),
 					HasStarted: true,
 				}
 			}
@@ -315,6 +388,69 @@ func (sf *statusFetcher) getServiceStatus(pod *corev1.Pod) cluster.ServiceStatus
 	}
 }
 
+// getHealthCheckFailure returns the output of the most recent failed
+// healthcheck for the given container, or an empty string if the healthcheck
+// hasn't failed since the container started.
+func (sf *statusFetcher) getHealthCheckFailure(pod *corev1.Pod, cs corev1.ContainerStatus) string {
+	events, err := sf.eventsLister.Events(pod.Namespace).List(labels.Everything())
+	if err != nil {
+		log.WithError(err).Warn("Failed to get events")
+		return ""
+	}
+
+	var latest *corev1.Event
+	for _, event := range events {
+		if event.InvolvedObject.Kind != "Pod" ||
+			event.InvolvedObject.Namespace != pod.Namespace ||
+			event.InvolvedObject.Name != pod.Name ||
+			event.InvolvedObject.FieldPath != fmt.Sprintf("spec.containers{%s}", cs.Name) ||
+			event.Reason != "Unhealthy" {
+			continue
+		}
+
+		// Ignore failures from previous runs of the container.
+		if cs.State.Running != nil && event.LastTimestamp.Before(&cs.State.Running.StartedAt) {
+			continue
+		}
+
+		if latest == nil || latest.LastTimestamp.Before(&event.LastTimestamp) {
+			latest = event
+		}
+	}
+
+	if latest == nil {
+		return ""
+	}
+
+	// The event message is prefixed by the type of probe, e.g.
+	// "Readiness probe failed: <output>".
+	output := latest.Message
+	if parts := strings.SplitN(output, "probe failed: ", 2); len(parts) == 2 {
+		output = parts[1]
+	}
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return "Healthcheck failed"
+	}
+	return fmt.Sprintf("Healthcheck failed: %s", output)
+}
+
+// getContainerStatus returns the status of the given container in the pod.
+// Pods deployed before services could share pods only have a single
+// container, which may have a different name.
+func getContainerStatus(pod *corev1.Pod, container string) (corev1.ContainerStatus, bool) {
+	if len(pod.Status.ContainerStatuses) == 1 && len(pod.Spec.Containers) <= 1 {
+		return pod.Status.ContainerStatuses[0], true
+	}
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == container {
+			return cs, true
+		}
+	}
+	return corev1.ContainerStatus{}, false
+}
+
 func isUnschedulable(pod *corev1.Pod) bool {
 	if pod.Status.Phase != corev1.PodPending {
 		return false
@@ -11,6 +11,8 @@ import (
 	fakeKube "k8s.io/client-go/kubernetes/fake"
 
 	"github.com/kelda/blimp/pkg/kube"
+	"github.com/kelda/blimp/pkg/metadata"
+	"github.com/kelda/blimp/pkg/names"
 	"github.com/kelda/blimp/pkg/proto/cluster"
 )
 
@@ -57,7 +59,9 @@ func TestGetStatus(t *testing.T) {
 				Phase: cluster.SandboxStatus_RUNNING,
 				Services: map[string]*cluster.ServiceStatus{
 					"web": {
-						Phase: cluster.ServicePhase_PENDING,
+						Phase:    cluster.ServicePhase_PENDING,
+						Replicas: 1,
+						PodName:  "web",
 						Msg: fmt.Sprintf(createContainerErrorTemplate,
 							"CreateContainerError", "context deadline exceeded"),
 					},
@@ -101,7 +105,9 @@ func TestGetStatus(t *testing.T) {
 				Phase: cluster.SandboxStatus_RUNNING,
 				Services: map[string]*cluster.ServiceStatus{
 					"web": {
-						Phase: cluster.ServicePhase_PENDING,
+						Phase:    cluster.ServicePhase_PENDING,
+						Replicas: 1,
+						PodName:  "web",
 						Msg: fmt.Sprintf(createContainerErrorTemplate,
 							"CreateContainerError", "context deadline exceeded"),
 					},
@@ -138,7 +144,9 @@ func TestGetStatus(t *testing.T) {
 				Phase: cluster.SandboxStatus_RUNNING,
 				Services: map[string]*cluster.ServiceStatus{
 					"web": {
-						Phase: cluster.ServicePhase_EXITED,
+						Phase:    cluster.ServicePhase_EXITED,
+						Replicas: 1,
+						PodName:  "web",
 						Msg: "The node was low on resource: memory. " +
 							"Container nuxtpublic-8c9fd51e73 was using 819944Ki, which exceeds its request of 50Mi.",
 						HasStarted: true,
@@ -146,6 +154,284 @@ func TestGetStatus(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "Replicas",
+			namespace: "namespace",
+			mockObjects: []runtime.Object{
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "namespace",
+					},
+				},
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "namespace",
+						Name:      "web",
+						Labels: map[string]string{
+							"blimp.customerPod": "true",
+							"blimp.service":     "web",
+							"blimp.replica":     "0",
+						},
+					},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodRunning,
+						Conditions: []corev1.PodCondition{
+							{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+						},
+					},
+				},
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "namespace",
+						Name:      "web-1",
+						Labels: map[string]string{
+							"blimp.customerPod": "true",
+							"blimp.service":     "web",
+							"blimp.replica":     "1",
+						},
+					},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodPending,
+					},
+				},
+			},
+			exp: cluster.SandboxStatus{
+				Phase: cluster.SandboxStatus_RUNNING,
+				Services: map[string]*cluster.ServiceStatus{
+					"web": {
+						Phase:         cluster.ServicePhase_RUNNING,
+						HasStarted:    true,
+						Replicas:      2,
+						PodName:       "web",
+						ReadyReplicas: 1,
+					},
+				},
+			},
+		},
+		{
+			name:      "Unhealthy",
+			namespace: "namespace",
+			mockObjects: []runtime.Object{
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "namespace",
+					},
+				},
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "namespace",
+						Name:      "web",
+						Labels: map[string]string{
+							"blimp.customerPod": "true",
+							"blimp.service":     "web",
+						},
+					},
+					Status: corev1.PodStatus{
+						ContainerStatuses: []corev1.ContainerStatus{
+							{
+								Name: "web",
+								State: corev1.ContainerState{
+									Running: &corev1.ContainerStateRunning{
+										StartedAt: metav1.Unix(100, 0),
+									},
+								},
+							},
+						},
+					},
+				},
+				// A failure from before the container restarted.
+				&corev1.Event{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "namespace",
+						Name:      "web.old",
+					},
+					InvolvedObject: corev1.ObjectReference{
+						Kind:      "Pod",
+						Namespace: "namespace",
+						Name:      "web",
+						FieldPath: "spec.containers{web}",
+					},
+					Reason:        "Unhealthy",
+					Message:       "Liveness probe failed: old failure",
+					LastTimestamp: metav1.Unix(50, 0),
+				},
+				&corev1.Event{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "namespace",
+						Name:      "web.new",
+					},
+					InvolvedObject: corev1.ObjectReference{
+						Kind:      "Pod",
+						Namespace: "namespace",
+						Name:      "web",
+						FieldPath: "spec.containers{web}",
+					},
+					Reason:        "Unhealthy",
+					Message:       "Readiness probe failed: curl: (7) Failed to connect to localhost port 80\n",
+					LastTimestamp: metav1.Unix(150, 0),
+				},
+			},
+			exp: cluster.SandboxStatus{
+				Phase: cluster.SandboxStatus_RUNNING,
+				Services: map[string]*cluster.ServiceStatus{
+					"web": {
+						Phase:      cluster.ServicePhase_UNHEALTHY,
+						Msg:        "Healthcheck failed: curl: (7) Failed to connect to localhost port 80",
+						HasStarted: true,
+						Replicas:   1,
+						PodName:    "web",
+					},
+				},
+			},
+		},
+		{
+			name:      "Colocated services",
+			namespace: "namespace",
+			mockObjects: []runtime.Object{
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "namespace",
+					},
+				},
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "namespace",
+						Name:      "vpn",
+						Labels: map[string]string{
+							"blimp.customerPod": "true",
+							"blimp.service":     "vpn",
+							"blimp.replica":     "0",
+						},
+						Annotations: map[string]string{
+							metadata.ColocatedServicesKey: "app",
+						},
+					},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{Name: names.ToDNS1123("vpn")},
+							{Name: names.ToDNS1123("app")},
+						},
+					},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodRunning,
+						ContainerStatuses: []corev1.ContainerStatus{
+							{
+								Name:  names.ToDNS1123("vpn"),
+								Ready: true,
+								State: corev1.ContainerState{
+									Running: &corev1.ContainerStateRunning{},
+								},
+							},
+							{
+								Name: names.ToDNS1123("app"),
+								State: corev1.ContainerState{
+									Terminated: &corev1.ContainerStateTerminated{
+										Message: "crashed",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			exp: cluster.SandboxStatus{
+				Phase: cluster.SandboxStatus_RUNNING,
+				Services: map[string]*cluster.ServiceStatus{
+					"vpn": {
+						Phase:         cluster.ServicePhase_RUNNING,
+						HasStarted:    true,
+						Replicas:      1,
+						ReadyReplicas: 1,
+						PodName:       "vpn",
+					},
+					"app": {
+						Phase:      cluster.ServicePhase_EXITED,
+						Msg:        "crashed",
+						HasStarted: true,
+						Replicas:   1,
+						PodName:    "vpn",
+					},
+				},
+			},
+		},
+		{
+			name:      "Build failed",
+			namespace: "namespace",
+			mockObjects: []runtime.Object{
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "namespace",
+						Annotations: map[string]string{
+							metadata.BuildErrorsKey: metadata.BuildErrors(map[string]string{
+								"web": "See the build log at ~/.blimp/build-logs/web.log",
+							}),
+						},
+					},
+				},
+			},
+			exp: cluster.SandboxStatus{
+				Phase: cluster.SandboxStatus_RUNNING,
+				Services: map[string]*cluster.ServiceStatus{
+					"web": {
+						Phase: cluster.ServicePhase_BUILD_FAILED,
+						Msg:   "See the build log at ~/.blimp/build-logs/web.log",
+					},
+				},
+			},
+		},
+		{
+			name:      "Build failed with previous pod",
+			namespace: "namespace",
+			mockObjects: []runtime.Object{
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "namespace",
+						Annotations: map[string]string{
+							metadata.BuildErrorsKey: metadata.BuildErrors(map[string]string{
+								"web": "See the build log at ~/.blimp/build-logs/web.log",
+							}),
+						},
+					},
+				},
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "namespace",
+						Name:      "web",
+						Labels: map[string]string{
+							"blimp.customerPod": "true",
+							"blimp.service":     "web",
+							"blimp.replica":     "0",
+						},
+					},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: names.ToDNS1123("web")}},
+					},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodRunning,
+						ContainerStatuses: []corev1.ContainerStatus{
+							{
+								Name:  names.ToDNS1123("web"),
+								Ready: true,
+								State: corev1.ContainerState{
+									Running: &corev1.ContainerStateRunning{},
+								},
+							},
+						},
+					},
+				},
+			},
+			exp: cluster.SandboxStatus{
+				Phase: cluster.SandboxStatus_RUNNING,
+				Services: map[string]*cluster.ServiceStatus{
+					"web": {
+						Phase:    cluster.ServicePhase_BUILD_FAILED,
+						Msg:      "See the build log at ~/.blimp/build-logs/web.log",
+						Replicas: 1,
+						PodName:  "web",
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kelda/blimp/pkg/errors"
+)
+
+const (
+	passwdPath = "/etc/passwd"
+	groupPath  = "/etc/group"
+)
+
+// addUser configures the user and group that the service's container runs as
+// according to its `user` field. Kubernetes only accepts numeric IDs, so
+// names are resolved using the image's /etc/passwd and /etc/group, like
+// Docker does.
+func (p *podSpec) addUser(svc composeTypes.ServiceConfig, images imageInspector) error {
+	if svc.User == "" {
+		return nil
+	}
+
+	parts := strings.Split(svc.User, ":")
+	if len(parts) > 2 {
+		return errors.NewFriendlyError("Invalid user field (%s) for service %s.\n"+
+			"Expected at most two values.", svc.User, svc.Name)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return errors.NewFriendlyError("Invalid user field (%s) for service %s.\n"+
+				"The user and group can't be empty.", svc.User, svc.Name)
+		}
+	}
+
+	// Only pull the files from the image if we need them.
+	var files map[string][]byte
+	readFile := func(path string) ([]byte, error) {
+		if files == nil {
+			var err error
+			files, err = images.ReadFiles(p.image, passwdPath, groupPath)
+			if err != nil {
+				return nil, errors.WithContext(fmt.Sprintf("read users for %s", svc.Name), err)
+			}
+		}
+		return files[path], nil
+	}
+
+	var uid int64
+	var gid *int64
+	if id, err := strconv.ParseInt(parts[0], 10, 64); err == nil {
+		uid = id
+	} else {
+		passwd, err := readFile(passwdPath)
+		if err != nil {
+			return err
+		}
+
+		entry, ok := lookupEntry(passwd, parts[0])
+		if !ok || len(entry) < 3 {
+			return errors.NewFriendlyError("Invalid user field (%s) for service %s.\n"+
+				"User %s doesn't exist in the image's %s.",
+				svc.User, svc.Name, parts[0], passwdPath)
+		}
+
+		uid, err = strconv.ParseInt(entry[1], 10, 64)
+		if err != nil {
+			return errors.WithContext(fmt.Sprintf("parse uid for user %s", parts[0]), err)
+		}
+
+		// Docker runs named users with their primary group by default.
+		primaryGID, err := strconv.ParseInt(entry[2], 10, 64)
+		if err != nil {
+			return errors.WithContext(fmt.Sprintf("parse gid for user %s", parts[0]), err)
+		}
+		gid = &primaryGID
+	}
+
+	if len(parts) == 2 {
+		if id, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+			gid = &id
+		} else {
+			group, err := readFile(groupPath)
+			if err != nil {
+				return err
+			}
+
+			entry, ok := lookupEntry(group, parts[1])
+			if !ok || len(entry) < 2 {
+				return errors.NewFriendlyError("Invalid user field (%s) for service %s.\n"+
+					"Group %s doesn't exist in the image's %s.",
+					svc.User, svc.Name, parts[1], groupPath)
+			}
+
+			id, err := strconv.ParseInt(entry[1], 10, 64)
+			if err != nil {
+				return errors.WithContext(fmt.Sprintf("parse gid for group %s", parts[1]), err)
+			}
+			gid = &id
+		}
+	}
+
+	container := &p.pod.Spec.Containers[0]
+	if container.SecurityContext == nil {
+		container.SecurityContext = &corev1.SecurityContext{}
+	}
+	container.SecurityContext.RunAsUser = &uid
+	container.SecurityContext.RunAsGroup = gid
+	return nil
+}
+
+// lookupEntry returns the fields of the entry with the given name in a file
+// in the /etc/passwd or /etc/group format. The password field is removed, so
+// the ID is always at index 1.
+func lookupEntry(file []byte, name string) ([]string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(file))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if fields[0] != name {
+			continue
+		}
+
+		// Drop the password field.
+		if len(fields) < 2 {
+			return nil, false
+		}
+		return append([]string{fields[0]}, fields[2:]...), true
+	}
+	return nil, false
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestAddUser(t *testing.T) {
+	int64Ptr := func(i int64) *int64 {
+		return &i
+	}
+
+	images := fakeImageInspector{
+		files: map[string][]byte{
+			passwdPath: []byte("root:x:0:0:root:/root:/bin/bash\n" +
+				"# Comment\n" +
+				"postgres:x:999:998:PostgreSQL administrator:/var/lib/postgresql:/bin/bash\n"),
+			groupPath: []byte("root:x:0:\n" +
+				"postgres:x:998:\n" +
+				"node:x:1000:postgres\n"),
+		},
+	}
+
+	tests := []struct {
+		name   string
+		user   string
+		exp    *corev1.SecurityContext
+		expErr string
+	}{
+		{
+			name: "no user",
+		},
+		{
+			name: "numeric user",
+			user: "1000",
+			exp:  &corev1.SecurityContext{RunAsUser: int64Ptr(1000)},
+		},
+		{
+			name: "numeric user and group",
+			user: "1000:1001",
+			exp:  &corev1.SecurityContext{RunAsUser: int64Ptr(1000), RunAsGroup: int64Ptr(1001)},
+		},
+		{
+			name: "named user uses primary group",
+			user: "postgres",
+			exp:  &corev1.SecurityContext{RunAsUser: int64Ptr(999), RunAsGroup: int64Ptr(998)},
+		},
+		{
+			name: "named user and group",
+			user: "postgres:node",
+			exp:  &corev1.SecurityContext{RunAsUser: int64Ptr(999), RunAsGroup: int64Ptr(1000)},
+		},
+		{
+			name: "numeric user and named group",
+			user: "1234:postgres",
+			exp:  &corev1.SecurityContext{RunAsUser: int64Ptr(1234), RunAsGroup: int64Ptr(998)},
+		},
+		{
+			name: "unknown user",
+			user: "mysql",
+			expErr: "Invalid user field (mysql) for service web.\n" +
+				"User mysql doesn't exist in the image's /etc/passwd.",
+		},
+		{
+			name: "unknown group",
+			user: "postgres:mysql",
+			expErr: "Invalid user field (postgres:mysql) for service web.\n" +
+				"Group mysql doesn't exist in the image's /etc/group.",
+		},
+		{
+			name: "too many values",
+			user: "1:2:3",
+			expErr: "Invalid user field (1:2:3) for service web.\n" +
+				"Expected at most two values.",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			spec := podSpec{
+				image: "image",
+				pod: corev1.Pod{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web"}},
+					},
+				},
+			}
+
+			err := spec.addUser(composeTypes.ServiceConfig{Name: "web", User: test.user}, images)
+			if test.expErr != "" {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.exp, spec.pod.Spec.Containers[0].SecurityContext)
+		})
+	}
+}
@@ -55,5 +55,13 @@ PersistentVolume according to the Delete reclaim policy.
 
 Note that this controller _is not_ a Blimp component. It's just part of the
 abstraction Kubernetes provides for PersistentVolumes.
+
+BUILD CACHE
+
+The build cache for the namespace's buildkitd pod is stored in a separate
+PersistentVolume that's managed in the same way. buildkitd runs on a dedicated
+builder node, so it can't share the namespace's ReadWriteOnce volume with the
+rest of the sandbox. The build cache volume is labeled with a different key so
+that the two volumes are never confused.
 */
 package volume
@@ -12,6 +12,10 @@ const (
 	// PersistentVolumeClaimName is the name used for the PVC backing all Blimp
 	// volumes in a namespace.
 	PersistentVolumeClaimName = "blimp-volume"
+
+	// BuildCacheClaimName is the name used for the PVC backing buildkitd's
+	// build cache in a namespace.
+	BuildCacheClaimName = "blimp-build-cache"
 )
 
 var (
@@ -25,6 +29,17 @@ var (
 			},
 		},
 	}
+
+	// BuildCacheVolume is the volume definition that buildkitd uses to mount
+	// the PV backing its build cache.
+	BuildCacheVolume = corev1.Volume{
+		Name: "build-cache",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: BuildCacheClaimName,
+			},
+		},
+	}
 )
 
 // NamedVolumeDir returns the path within the PV that's used to back the given
@@ -27,17 +27,70 @@ const (
 	// user will experience out of disk errors if the combined size of all bind
 	// and named volumes exceeds this amount.
 	pvSize = "25Gi"
+
+	// buildCacheNamespaceLabel is the label used to associate a build cache
+	// PersistentVolume with a user namespace.
+	buildCacheNamespaceLabel = "blimp.kelda.io/build-cache-namespace"
+
+	// buildCacheSize is the size of the PersistentVolume that backs each
+	// user's build cache.
+	buildCacheSize = "10Gi"
+)
+
+// claim describes a PersistentVolumeClaim that's bound to a PersistentVolume
+// that's retained across `blimp down`s.
+type claim struct {
+	// name is the name of the PVC in the user's namespace.
+	name string
+
+	// label is the label used to associate the PersistentVolume with the
+	// user's namespace.
+	label string
+
+	// size is the size of the PersistentVolume.
+	size string
+
+	// seedSuffix is appended to the namespace to get the name of the PVC
+	// that's used to create the PersistentVolume.
+	seedSuffix string
+}
+
+var (
+	sandboxClaim = claim{
+		name:  PersistentVolumeClaimName,
+		label: pvNamespaceLabel,
+		size:  pvSize,
+	}
+
+	buildCacheClaim = claim{
+		name:       BuildCacheClaimName,
+		label:      buildCacheNamespaceLabel,
+		size:       buildCacheSize,
+		seedSuffix: "-build-cache",
+	}
 )
 
 // CreatePVC ensures that the namespace's PersistentVolumeClaim exists, and is
 // bound to user's PersistentVolume. This PVC can then be referenced by other
 // pods in the namespace to mount specific volumes.
 func CreatePVC(ctx context.Context, kubeClient kubernetes.Interface, namespace string) error {
+	return createClaim(ctx, kubeClient, namespace, sandboxClaim)
+}
+
+// CreateBuildCachePVC ensures that the PersistentVolumeClaim for the
+// namespace's build cache exists. The build cache has its own
+// PersistentVolume since buildkitd runs on a different node than the rest of
+// the sandbox.
+func CreateBuildCachePVC(ctx context.Context, kubeClient kubernetes.Interface, namespace string) error {
+	return createClaim(ctx, kubeClient, namespace, buildCacheClaim)
+}
+
+func createClaim(ctx context.Context, kubeClient kubernetes.Interface, namespace string, c claim) error {
 	persistentFs := corev1.PersistentVolumeFilesystem
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: namespace,
-			Name:      PersistentVolumeClaimName,
+			Name:      c.name,
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
 			AccessModes: []corev1.PersistentVolumeAccessMode{
@@ -45,7 +98,7 @@ func CreatePVC(ctx context.Context, kubeClient kubernetes.Interface, namespace s
 			},
 			Resources: corev1.ResourceRequirements{
 				Requests: corev1.ResourceList{
-					corev1.ResourceStorage: resource.MustParse(pvSize),
+					corev1.ResourceStorage: resource.MustParse(c.size),
 				},
 			},
 			VolumeMode: &persistentFs,
@@ -62,7 +115,7 @@ func CreatePVC(ctx context.Context, kubeClient kubernetes.Interface, namespace s
 
 	// Get the PV for the namespace. Create it if it doesn't already exist.
 	var pvName string
-	switch pv, err := getPersistentVolume(kubeClient, namespace); err {
+	switch pv, err := getPersistentVolume(kubeClient, namespace, c.label); err {
 	case nil:
 		// PersistentVolumes enter the Released phase when their associated
 		// PersistentVolumeClaim is deleted (i.e. when `blimp down` is run).
@@ -75,7 +128,7 @@ func CreatePVC(ctx context.Context, kubeClient kubernetes.Interface, namespace s
 		}
 		pvName = pv.Name
 	case errNoPersistentVolume:
-		pvName, err = createPersistentVolume(ctx, kubeClient, namespace, pvc.Spec)
+		pvName, err = createPersistentVolume(ctx, kubeClient, namespace, c, pvc.Spec)
 		if err != nil {
 			return errors.WithContext("create persistent volume", err)
 		}
@@ -111,8 +164,19 @@ func CreatePVC(ctx context.Context, kubeClient kubernetes.Interface, namespace s
 // If any pods reference the PVC, Kubernetes will block the PVC and
 // PV deletion until the pods have been deleted.
 func PermanentlyDeletePVC(kubeClient kubernetes.Interface, namespace string) error {
+	return permanentlyDeleteClaim(kubeClient, namespace, sandboxClaim)
+}
+
+// PermanentlyDeleteBuildCachePVC deletes the namespace's build cache, and its
+// underlying storage. Like PermanentlyDeletePVC, it doesn't block on the
+// deletion completing.
+func PermanentlyDeleteBuildCachePVC(kubeClient kubernetes.Interface, namespace string) error {
+	return permanentlyDeleteClaim(kubeClient, namespace, buildCacheClaim)
+}
+
+func permanentlyDeleteClaim(kubeClient kubernetes.Interface, namespace string, c claim) error {
 	pvcClient := kubeClient.CoreV1().PersistentVolumeClaims(namespace)
-	pvc, err := pvcClient.Get(PersistentVolumeClaimName, metav1.GetOptions{})
+	pvc, err := pvcClient.Get(c.name, metav1.GetOptions{})
 	if err != nil {
 		// There's no PVC, so there's nothing more to do.
 		if kerrors.IsNotFound(err) {
@@ -125,7 +189,7 @@ func PermanentlyDeletePVC(kubeClient kubernetes.Interface, namespace string) err
 	err = updatePersistentVolume(kubeClient, pvc.Spec.VolumeName,
 		func(pv corev1.PersistentVolume) (corev1.PersistentVolume, bool) {
 			// Don't allow this PV to be reused.
-			delete(pv.Labels, pvNamespaceLabel)
+			delete(pv.Labels, c.label)
 
 			// Signal to the PersistentVolume controller that the
 			// PV, and its underlying storage, should be deleted
@@ -140,7 +204,7 @@ func PermanentlyDeletePVC(kubeClient kubernetes.Interface, namespace string) err
 	// Signal to Kubernetes that we want to delete the PVC. Note that deletion
 	// won't happen immediately because of the Kubernetes finalizer which
 	// blocks PVC deletion until pods that reference the PVC have been deleted.
-	if err := pvcClient.Delete(PersistentVolumeClaimName, &metav1.DeleteOptions{}); err != nil {
+	if err := pvcClient.Delete(c.name, &metav1.DeleteOptions{}); err != nil {
 		return errors.WithContext("delete pvc", err)
 	}
 	return nil
@@ -148,7 +212,7 @@ func PermanentlyDeletePVC(kubeClient kubernetes.Interface, namespace string) err
 
 // createPersistentVolume creates a new PersistentVolume for the given namespace.
 func createPersistentVolume(ctx context.Context, kubeClient kubernetes.Interface,
-	namespace string, spec corev1.PersistentVolumeClaimSpec) (string, error) {
+	namespace string, c claim, spec corev1.PersistentVolumeClaimSpec) (string, error) {
 
 	// Retry creating the PersistentVolume up to 8 times.
 	for i := 0; i < 8; i++ {
@@ -162,7 +226,7 @@ func createPersistentVolume(ctx context.Context, kubeClient kubernetes.Interface
 		pvc := &corev1.PersistentVolumeClaim{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: kube.BlimpNamespace,
-				Name:      namespace,
+				Name:      namespace + c.seedSuffix,
 			},
 			Spec: spec,
 		}
@@ -218,13 +282,13 @@ func createPersistentVolume(ctx context.Context, kubeClient kubernetes.Interface
 				// than create a new one if booting another namespace causes the
 				// volume to be Available, so we only claimed the volume if it
 				// doesn't have an owner already.
-				if _, ok := pv.Labels[pvNamespaceLabel]; !ok {
+				if _, ok := pv.Labels[c.label]; !ok {
 					// Label the PV so that getPersistentVolume will return it in the
 					// future.
 					if pv.Labels == nil {
 						pv.Labels = map[string]string{}
 					}
-					pv.Labels[pvNamespaceLabel] = namespace
+					pv.Labels[c.label] = namespace
 					claimedVolume = true
 				}
 
@@ -265,13 +329,13 @@ func makeAvailable(pv corev1.PersistentVolume) (corev1.PersistentVolume, bool) {
 var errNoPersistentVolume = errors.New("no persistent volume")
 
 // getPersistentVolume returns the PersistentVolume associated with the given
-// namespace.
-func getPersistentVolume(kubeClient kubernetes.Interface, namespace string) (
+// namespace via the given label.
+func getPersistentVolume(kubeClient kubernetes.Interface, namespace, label string) (
 	corev1.PersistentVolume, error) {
 
 	pvClient := kubeClient.CoreV1().PersistentVolumes()
 	currPv, err := pvClient.List(metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("%s=%s", pvNamespaceLabel, namespace),
+		LabelSelector: fmt.Sprintf("%s=%s", label, namespace),
 	})
 	if err != nil {
 		return corev1.PersistentVolume{}, errors.WithContext("get", err)
@@ -26,6 +26,7 @@ require (
 	github.com/daaku/go.zipexe v1.0.1 // indirect
 	github.com/docker/cli v0.0.0-20200320120634-22acbbcc4b3f
 	github.com/docker/docker v1.14.0-0.20190319215453-e7b5f7dbe98c
+	github.com/docker/go-units v0.4.0
 	github.com/ghodss/yaml v1.0.1-0.20190212211648-25d852aebe32
 	github.com/golang/protobuf v1.4.2
 	github.com/google/go-containerregistry v0.1.0
@@ -37,6 +38,7 @@ require (
 	github.com/miekg/dns v1.1.28
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/moby/buildkit v0.6.4
+	github.com/opencontainers/go-digest v1.0.0-rc1
 	github.com/pquerna/cachecontrol v0.0.0-20180517163645-1555304b9b35 // indirect
 	github.com/sirupsen/logrus v1.6.0
 	github.com/spf13/afero v1.2.2
@@ -46,6 +48,7 @@ require (
 	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
 	golang.org/x/sync v0.0.0-20200625203802-6e8e738ad208
+	golang.org/x/sys v0.0.0-20200523222454-059865788121
 	google.golang.org/grpc v1.29.1
 	gopkg.in/square/go-jose.v2 v2.4.1
 	k8s.io/api v0.17.4
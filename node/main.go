@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strconv"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -11,6 +12,9 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	listers "k8s.io/client-go/listers/core/v1"
@@ -22,6 +26,7 @@ import (
 	"github.com/kelda/blimp/pkg/errors"
 	"github.com/kelda/blimp/pkg/expose"
 	"github.com/kelda/blimp/pkg/kube"
+	"github.com/kelda/blimp/pkg/metadata"
 	"github.com/kelda/blimp/pkg/names"
 	"github.com/kelda/blimp/pkg/ports"
 	"github.com/kelda/blimp/pkg/proto/node"
@@ -126,18 +131,17 @@ func (s *server) Tunnel(nsrv node.Controller_TunnelServer) error {
 	// Node Controller is poorly designed. We should revisit this when we
 	// redesign the other APIs that refer to service names, such as logs and
 	// SSH.
-	podName := header.Name
-	if header.Name != kube.PodNameSyncthing && header.Name != kube.PodNameBuildkitd {
-		podName = names.ToDNS1123(header.Name)
+	var dstPod *corev1.Pod
+	if header.Name == kube.PodNameSyncthing || header.Name == kube.PodNameBuildkitd {
+		dstPod, err = s.podLister.Pods(user.Namespace).Get(header.Name)
+	} else {
+		dstPod, err = s.getServicePod(user.Namespace, header.Name)
 	}
-
-	dstPod, err := s.podLister.Pods(user.Namespace).Get(podName)
 	if err != nil {
 		return status.New(codes.OutOfRange, "unknown destination").Err()
 	}
 
-	dialAddr := fmt.Sprintf("%s:%d", dstPod.Status.PodIP, header.Port)
-	stream, err := net.Dial("tcp", dialAddr)
+	stream, err := dialTunnelDestination(dstPod.Status.PodIP, header)
 	if err != nil {
 		return status.New(codes.Internal, err.Error()).Err()
 	}
@@ -146,6 +150,16 @@ func (s *server) Tunnel(nsrv node.Controller_TunnelServer) error {
 	return nil
 }
 
+// dialTunnelDestination connects to the port in the tunnel header, using the
+// header's protocol.
+func dialTunnelDestination(podIP string, header *node.TunnelHeader) (net.Conn, error) {
+	network := "tcp"
+	if header.GetProtocol() == node.TunnelProtocol_UDP {
+		network = "udp"
+	}
+	return net.Dial(network, net.JoinHostPort(podIP, strconv.Itoa(int(header.GetPort()))))
+}
+
 func (s *server) ExposedTunnel(nsrv node.Controller_ExposedTunnelServer) error {
 	msg, err := nsrv.Recv()
 	if err != nil {
@@ -179,9 +193,7 @@ func (s *server) ExposedTunnel(nsrv node.Controller_ExposedTunnelServer) error {
 		return status.New(codes.OutOfRange, "unknown destination").Err()
 	}
 
-	podName := names.ToDNS1123(info.Service)
-
-	dstPod, err := s.podLister.Pods(header.Namespace).Get(podName)
+	dstPod, err := s.getServicePod(header.Namespace, info.Service)
 	if err != nil {
 		return status.New(codes.OutOfRange, "unknown destination").Err()
 	}
@@ -196,6 +208,26 @@ func (s *server) ExposedTunnel(nsrv node.Controller_ExposedTunnelServer) error {
 	return nil
 }
 
+// getServicePod returns the pod that runs the first replica of the given
+// service. Services that share a namespace with another service run in the
+// other service's pod.
+func (s *server) getServicePod(namespace, service string) (*corev1.Pod, error) {
+	pod, err := s.podLister.Pods(namespace).Get(names.ToDNS1123(service))
+	if !kerrors.IsNotFound(err) {
+		return pod, err
+	}
+
+	pods, listErr := s.podLister.Pods(namespace).List(
+		labels.Set{metadata.ServiceLabel(service): "0"}.AsSelector())
+	if listErr != nil {
+		return nil, listErr
+	}
+	if len(pods) == 0 {
+		return nil, err
+	}
+	return pods[0], nil
+}
+
 func (s *server) SyncNotifications(srv node.Controller_SyncNotificationsServer) error {
 	handshake, err := srv.Recv()
 	if err != nil {
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kelda/blimp/pkg/proto/node"
+)
+
+func TestDialTunnelDestination(t *testing.T) {
+	udpListener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer udpListener.Close()
+
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer tcpListener.Close()
+
+	// UDP tunnels should send each write as a datagram to the pod.
+	conn, err := dialTunnelDestination("127.0.0.1", &node.TunnelHeader{
+		Port:     uint32(udpListener.LocalAddr().(*net.UDPAddr).Port),
+		Protocol: node.TunnelProtocol_UDP,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "udp", conn.RemoteAddr().Network())
+
+	_, err = conn.Write([]byte("datagram"))
+	require.NoError(t, err)
+	require.NoError(t, udpListener.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 64)
+	n, _, err := udpListener.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "datagram", string(buf[:n]))
+
+	// Tunnels default to TCP.
+	conn, err = dialTunnelDestination("127.0.0.1", &node.TunnelHeader{
+		Port: uint32(tcpListener.Addr().(*net.TCPAddr).Port),
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "tcp", conn.RemoteAddr().Network())
+}
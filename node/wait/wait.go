@@ -43,13 +43,21 @@ type podCondition func(corev1.Pod) (status string, satisfied bool)
 // podWaiter orchestrates waiting for a pod to satisfy a condition.
 type podWaiter struct {
 	namespace, name string
-	condition       podCondition
-	watcher         *kube.Watcher
-	lister          listers.PodLister
+	// container restricts the condition to a single container in the pod,
+	// if set.
+	container string
+	condition podCondition
+	watcher   *kube.Watcher
+	lister    listers.PodLister
 }
 
 const Port = 9002
 
+// ServiceConditionCompletedSuccessfully is the `depends_on` condition for
+// waiting until a service's container exits successfully. It's not defined
+// by compose-go.
+const ServiceConditionCompletedSuccessfully = "service_completed_successfully"
+
 func Run(kubeClient kubernetes.Interface, syncTracker *SyncTracker) {
 	podInformer := informers.NewSharedInformerFactory(kubeClient, 30*time.Second).
 		Core().V1().Pods()
@@ -96,14 +104,26 @@ func (s *server) CheckReady(req *wait.CheckReadyRequest, srv wait.BootWaiter_Che
 			pc = conditionPodHealthy
 		case composeTypes.ServiceConditionStarted:
 			pc = conditionPodStarted
+		case ServiceConditionCompletedSuccessfully:
+			pc = conditionPodCompletedSuccessfully
 		default:
 			// If the service condition is unknown, just ignore it.
 			continue
 		}
 
+		// Services that share a namespace with another service run as a
+		// container in the other service's pod.
+		name := names.ToDNS1123(condition.Service)
+		var container string
+		if condition.GetPod() != "" {
+			name = condition.GetPod()
+			container = names.ToDNS1123(condition.Service)
+		}
+
 		waiters = append(waiters, podWaiter{
 			namespace: req.GetNamespace(),
-			name:      names.ToDNS1123(condition.Service),
+			name:      name,
+			container: container,
 			condition: pc,
 			watcher:   s.podWatcher,
 			lister:    s.podLister,
@@ -179,6 +199,17 @@ func (w podWaiter) wait(ctx context.Context, updates chan<- string) error {
 			return fmt.Sprintf("failed to get pod %s: %s", w.name, err), false
 		}
 
+		if w.container != "" {
+			pod = pod.DeepCopy()
+			var containers []corev1.ContainerStatus
+			for _, c := range pod.Status.ContainerStatuses {
+				if c.Name == w.container {
+					containers = append(containers, c)
+				}
+			}
+			pod.Status.ContainerStatuses = containers
+		}
+
 		status, ready := w.condition(*pod)
 		return fmt.Sprintf("pod %s is %s", w.name, status), ready
 	}
@@ -224,20 +255,69 @@ func conditionPodStarted(pod corev1.Pod) (string, bool) {
 	return string(pod.Status.Phase), pod.Status.Phase == corev1.PodRunning
 }
 
+func conditionPodCompletedSuccessfully(pod corev1.Pod) (string, bool) {
+	if len(pod.Status.ContainerStatuses) == 0 {
+		return string(pod.Status.Phase), false
+	}
+
+	for _, container := range pod.Status.ContainerStatuses {
+		// A container that was restarted after exiting is running again, so
+		// its previous exit doesn't count as completing.
+		if container.State.Running != nil {
+			return "still running", false
+		}
+
+		// If the container is waiting to be restarted after exiting, its exit
+		// code is in the last termination state.
+		terminated := container.State.Terminated
+		restarting := false
+		if terminated == nil {
+			terminated = container.LastTerminationState.Terminated
+			restarting = container.State.Waiting != nil &&
+				pod.Spec.RestartPolicy != corev1.RestartPolicyNever
+		}
+
+		if terminated == nil {
+			return "still running", false
+		}
+
+		if terminated.ExitCode != 0 {
+			// Keep waiting in case the dependency gets fixed and redeployed,
+			// but make the failure clear to the user.
+			status := fmt.Sprintf("failed: exited with code %d", terminated.ExitCode)
+			if terminated.Message != "" {
+				status += fmt.Sprintf(" (%s)", terminated.Message)
+			}
+			return status, false
+		}
+
+		if restarting {
+			return "restarting", false
+		}
+	}
+	return "completed successfully", true
+}
+
 func conditionFinishedVolumeInit(pod corev1.Pod) (string, bool) {
+	// Pods that run multiple services may initialize volumes for each of
+	// them.
+	var initializesVolumes bool
 	for _, c := range pod.Status.InitContainerStatuses {
-		if c.Name != kube.ContainerNameInitializeVolumeFromImage {
+		if kube.InitContainerType(c.Name) != kube.ContainerNameInitializeVolumeFromImage {
 			continue
 		}
 
+		initializesVolumes = true
 		completed := c.State.Terminated != nil && c.State.Terminated.Reason == "Completed"
-		if completed {
-			return "completed volume initialization", true
+		if !completed {
+			return "waiting for volume initialization", false
 		}
-		return "waiting for volume initialization", false
 	}
 
-	// The pod doesn't have an init container for initializing volumes, so
-	// ignore it.
-	return "skipped. doesn't initialize volumes", true
+	if !initializesVolumes {
+		// The pod doesn't have an init container for initializing volumes, so
+		// ignore it.
+		return "skipped. doesn't initialize volumes", true
+	}
+	return "completed volume initialization", true
 }
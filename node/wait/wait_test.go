@@ -0,0 +1,127 @@
+package wait
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestConditionPodCompletedSuccessfully(t *testing.T) {
+	tests := []struct {
+		name          string
+		restartPolicy corev1.RestartPolicy
+		status        corev1.PodStatus
+		expStatus     string
+		expSatisfied  bool
+	}{
+		{
+			name:      "not scheduled",
+			status:    corev1.PodStatus{Phase: corev1.PodPending},
+			expStatus: "Pending",
+		},
+		{
+			name: "running",
+			status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}},
+			},
+			expStatus: "still running",
+		},
+		{
+			name: "completed",
+			status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{ExitCode: 0},
+					},
+				}},
+			},
+			expStatus:    "completed successfully",
+			expSatisfied: true,
+		},
+		{
+			name: "failed",
+			status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{ExitCode: 1},
+					},
+				}},
+			},
+			expStatus: "failed: exited with code 1",
+		},
+		{
+			name: "failed and restarting",
+			status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+					},
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{
+							ExitCode: 2,
+							Message:  "migration failed",
+						},
+					},
+				}},
+			},
+			expStatus: "failed: exited with code 2 (migration failed)",
+		},
+		{
+			name: "running after restart",
+			status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{ExitCode: 0},
+					},
+				}},
+			},
+			expStatus: "still running",
+		},
+		{
+			name: "completed and restarting",
+			status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+					},
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{ExitCode: 0},
+					},
+				}},
+			},
+			expStatus: "restarting",
+		},
+		{
+			name:          "completed and waiting without restarts",
+			restartPolicy: corev1.RestartPolicyNever,
+			status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{},
+					},
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{ExitCode: 0},
+					},
+				}},
+			},
+			expStatus:    "completed successfully",
+			expSatisfied: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			status, satisfied := conditionPodCompletedSuccessfully(corev1.Pod{
+				Spec:   corev1.PodSpec{RestartPolicy: test.restartPolicy},
+				Status: test.status,
+			})
+			assert.Equal(t, test.expStatus, status)
+			assert.Equal(t, test.expSatisfied, satisfied)
+		})
+	}
+}
@@ -4,14 +4,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/containerd/console"
 	"github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/secrets/secretsprovider"
+	"github.com/moby/buildkit/session/sshforward/sshprovider"
+	"github.com/moby/buildkit/util/entitlements"
 	"github.com/moby/buildkit/util/progress/progressui"
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/kelda/blimp/pkg/auth"
 	"github.com/kelda/blimp/pkg/build"
@@ -22,9 +26,10 @@ import (
 type Client struct {
 	client       *client.Client
 	authProvider *authProvider
+	progress     *build.Progress
 }
 
-func New(tunnelManager tunnel.Manager, regCreds auth.RegistryCredentials) (build.Interface, error) {
+func New(tunnelManager tunnel.Manager, regCreds auth.RegistryCredentials, progress *build.Progress) (Client, error) {
 	tunnelErr := make(chan error)
 	tunnelReady := make(chan struct{})
 	go func() {
@@ -32,24 +37,89 @@ func New(tunnelManager tunnel.Manager, regCreds auth.RegistryCredentials) (build
 	}()
 	select {
 	case err := <-tunnelErr:
-		return nil, errors.WithContext("connect to buildkitd", err)
+		return Client{}, errors.WithContext("connect to buildkitd", err)
 	case <-tunnelReady:
 	}
 
 	c, err := client.New(context.Background(), "tcp://127.0.0.1:1234")
 	if err != nil {
-		return nil, errors.WithContext("connect to buildkit", err)
+		return Client{}, errors.WithContext("connect to buildkit", err)
 	}
 
 	return Client{
 		client:       c,
 		authProvider: &authProvider{regCreds: regCreds},
+		progress:     progress,
 	}, nil
 }
 
-func (c Client) BuildAndPush(images map[string]build.BuildPushConfig) (map[string]string, error) {
+// NewLocal returns a builder that builds with a buildkitd running on the
+// user's machine, such as the one that nerdctl uses to build images for
+// containerd.
+func NewLocal(regCreds auth.RegistryCredentials, progress *build.Progress) (Client, error) {
+	var lastErr error
+	for _, addr := range localBuildkitdAddresses() {
+		c, err := client.New(context.Background(), addr)
+		if err != nil {
+			lastErr = errors.WithContext("create buildkit client", err)
+			continue
+		}
+
+		// The connection is established lazily, so make a request to check
+		// that buildkitd is actually running.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err = c.ListWorkers(ctx)
+		cancel()
+		if err != nil {
+			c.Close()
+			lastErr = errors.WithContext(fmt.Sprintf("connect to buildkitd at %s", addr), err)
+			continue
+		}
+
+		return Client{
+			client:       c,
+			authProvider: &authProvider{regCreds: regCreds},
+			progress:     progress,
+		}, nil
+	}
+	return Client{}, lastErr
+}
+
+// localBuildkitdAddresses returns the addresses that a local buildkitd might
+// be listening on. BUILDKIT_HOST takes precedence, followed by the rootless
+// sockets, and then the system-wide sockets. Like nerdctl, the sockets for
+// the current containerd namespace are tried before the default sockets.
+func localBuildkitdAddresses() []string {
+	if host := os.Getenv("BUILDKIT_HOST"); host != "" {
+		return []string{host}
+	}
+
+	namespace := os.Getenv("CONTAINERD_NAMESPACE")
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	var dirs []string
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		dirs = append(dirs, runtimeDir)
+	}
+	dirs = append(dirs, "/run")
+
+	var addrs []string
+	for _, dir := range dirs {
+		addrs = append(addrs,
+			"unix://"+filepath.Join(dir, "buildkit-"+namespace, "buildkitd.sock"),
+			"unix://"+filepath.Join(dir, "buildkit", "buildkitd.sock"))
+	}
+	return addrs
+}
+
+// BuildAndPush builds and pushes the services with buildkitd. When services
+// are built in parallel, buildkitd shares the work that's common between
+// the builds, such as pulling base images.
+func (c Client) BuildAndPush(images map[string]build.BuildPushConfig, opts build.ScheduleOptions) (build.Results, error) {
 	var cons console.Console
-	if terminal.IsTerminal(int(os.Stdout.Fd())) {
+	if c.progress.Interactive() {
 		var err error
 		cons, err = console.ConsoleFromFile(os.Stdout)
 		if err != nil {
@@ -57,27 +127,80 @@ func (c Client) BuildAndPush(images map[string]build.BuildPushConfig) (map[strin
 		}
 	}
 
-	pushedImages := map[string]string{}
-	for name, opts := range images {
+	return build.Schedule(images, opts, func(name string, opts build.BuildPushConfig) (string, error) {
 		digest, err := c.buildOne(name, opts, cons)
 		if err != nil {
-			return nil, errors.WithContext(fmt.Sprintf("buildkit build %s", name), err)
+			return "", errors.NewFriendlyError(
+				"Image build for %q failed. The build log is saved at %s.\n\n"+
+					"The full error was:\n%s", name, c.progress.LogPath(name), err)
+		}
+		return build.ReplaceTagWithDigest(opts.ImageName, digest), nil
+	}), nil
+}
+
+// Prune deletes everything in the build cache, and returns the number of
+// bytes that were freed.
+func (c Client) Prune() (int64, error) {
+	var reclaimed int64
+	usage := make(chan client.UsageInfo)
+	done := make(chan struct{})
+	go func() {
+		for info := range usage {
+			reclaimed += info.Size
 		}
+		close(done)
+	}()
 
-		pushedImages[name] = build.ReplaceTagWithDigest(opts.ImageName, digest)
+	err := c.client.Prune(context.Background(), usage, client.PruneAll)
+	close(usage)
+	<-done
+	if err != nil {
+		return 0, errors.WithContext("buildkit prune", err)
 	}
-
-	return pushedImages, nil
+	return reclaimed, nil
 }
 
 func (c Client) buildOne(name string, opts build.BuildPushConfig, cons console.Console) (digest string, err error) {
-	var ch chan *client.SolveStatus
+	frontendAttrs, allowedEntitlements, err := frontendOptions(name, opts)
+	if err != nil {
+		return "", err
+	}
+
+	attachables, err := c.getAttachables(opts)
+	if err != nil {
+		return "", err
+	}
+
+	solveOpt := client.SolveOpt{
+		Frontend:      "dockerfile.v0",
+		FrontendAttrs: frontendAttrs,
+		LocalDirs: map[string]string{
+			"context":    opts.Context,
+			"dockerfile": opts.Context,
+		},
+		Exports: []client.ExportEntry{
+			{
+				Type: client.ExporterImage,
+				Attrs: map[string]string{
+					"name":           opts.ImageName,
+					"push":           "true",
+					"name-canonical": "true",
+				},
+			},
+		},
+		CacheImports:        cacheImports(opts),
+		CacheExports:        cacheExports(opts),
+		Session:             attachables,
+		AllowedEntitlements: allowedEntitlements,
+	}
+
+	var display chan *client.SolveStatus
 	if cons != nil {
-		ch = make(chan *client.SolveStatus)
+		display = make(chan *client.SolveStatus)
 		statusErr := make(chan error)
 		go func() {
 			statusErr <- progressui.DisplaySolveStatus(context.Background(),
-				fmt.Sprintf("Building %s", name), cons, os.Stdout, ch)
+				fmt.Sprintf("Building %s", name), cons, os.Stdout, display)
 		}()
 
 		defer func() {
@@ -89,12 +212,37 @@ func (c Client) buildOne(name string, opts build.BuildPushConfig, cons console.C
 		}()
 	}
 
+	// Solve closes the status channel when it returns, which causes
+	// emitEvents to exit.
+	ch := make(chan *client.SolveStatus)
+	eventsDone := make(chan struct{})
+	go func() {
+		emitEvents(c.progress, name, ch, display)
+		close(eventsDone)
+	}()
+	defer func() { <-eventsDone }()
+
+	resp, err := c.client.Solve(context.Background(), nil, solveOpt, ch)
+	if err != nil {
+		return "", errors.WithContext("buildkit solve", err)
+	}
+
+	digest, ok := resp.ExporterResponse["containerimage.digest"]
+	if !ok {
+		return "", errors.New("didn't receive digest from buildkit")
+	}
+
+	return digest, nil
+}
+
+// frontendOptions returns the Dockerfile frontend attributes for the build,
+// and the entitlements that the build requires.
+func frontendOptions(name string, opts build.BuildPushConfig) (map[string]string, []entitlements.Entitlement, error) {
 	// The buildkit documentation on build options is non-existent.
 	// These keys are copied from the Docker source:
 	// https://github.com/moby/moby/blob/7ae5222c72cc2aac42225df8f62c2f71a1813ab4/builder/builder-next/builder.go#L253
 	frontendAttrs := map[string]string{
-		"filename":   opts.Dockerfile,
-		"cache-from": strings.Join(opts.CacheFrom, ","),
+		"filename": opts.Dockerfile,
 	}
 
 	if opts.Target != "" {
@@ -118,37 +266,96 @@ func (c Client) buildOne(name string, opts build.BuildPushConfig, cons console.C
 		frontendAttrs["image-resolve-mode"] = "default"
 	}
 
-	solveOpt := client.SolveOpt{
-		Frontend:      "dockerfile.v0",
-		FrontendAttrs: frontendAttrs,
-		LocalDirs: map[string]string{
-			"context":    opts.Context,
-			"dockerfile": opts.Context,
-		},
-		Exports: []client.ExportEntry{
-			{
-				Type: client.ExporterImage,
-				Attrs: map[string]string{
-					"name":           opts.ImageName,
-					"push":           "true",
-					"name-canonical": "true",
-				},
+	if len(opts.ExtraHosts) != 0 {
+		// The Compose file uses `host:ip`, but BuildKit expects `host=ip`.
+		var hosts []string
+		for _, host := range opts.ExtraHosts {
+			hosts = append(hosts, strings.Replace(host, ":", "=", 1))
+		}
+		frontendAttrs["add-hosts"] = strings.Join(hosts, ",")
+	}
+
+	var allowedEntitlements []entitlements.Entitlement
+	switch opts.Network {
+	case "", "default":
+	case "none":
+		frontendAttrs["force-network-mode"] = "none"
+	case "host":
+		frontendAttrs["force-network-mode"] = "host"
+		allowedEntitlements = append(allowedEntitlements, entitlements.EntitlementNetworkHost)
+	default:
+		return nil, nil, errors.NewFriendlyError(
+			"Service %s builds with `network: %s`, but only the `default`, `none`, and `host` "+
+				"build networks are supported.", name, opts.Network)
+	}
+	return frontendAttrs, allowedEntitlements, nil
+}
+
+// cacheImports returns the registry caches that the build imports. BuildKit
+// converts them into the `cache-from` frontend attribute.
+func cacheImports(opts build.BuildPushConfig) []client.CacheOptionsEntry {
+	refs := append([]string{}, opts.CacheFrom...)
+	for _, repo := range opts.CacheImports {
+		refs = append(refs, build.CacheImage(repo, build.CacheTagBuildkit))
+	}
+
+	var imports []client.CacheOptionsEntry
+	for _, ref := range refs {
+		imports = append(imports, client.CacheOptionsEntry{
+			Type:  "registry",
+			Attrs: map[string]string{"ref": ref},
+		})
+	}
+	return imports
+}
+
+// cacheExports returns the registry cache that the build exports. The cache
+// is exported in `max` mode so that the layers of intermediate stages are
+// cached as well.
+func cacheExports(opts build.BuildPushConfig) []client.CacheOptionsEntry {
+	if opts.CacheExport == "" {
+		return nil
+	}
+
+	return []client.CacheOptionsEntry{
+		{
+			Type: "registry",
+			Attrs: map[string]string{
+				"ref":  build.CacheImage(opts.CacheExport, build.CacheTagBuildkit),
+				"mode": "max",
 			},
 		},
-		Session: []session.Attachable{
-			c.authProvider,
-		},
 	}
+}
 
-	resp, err := c.client.Solve(context.Background(), nil, solveOpt, ch)
-	if err != nil {
-		return "", errors.WithContext("buildkit solve", err)
-	}
+// getAttachables returns the session attachables that give the builder
+// access to the client's credentials, SSH agents, and secrets. They're
+// served over the build session, so they never get baked into the image.
+func (c Client) getAttachables(opts build.BuildPushConfig) ([]session.Attachable, error) {
+	attachables := []session.Attachable{c.authProvider}
 
-	digest, ok := resp.ExporterResponse["containerimage.digest"]
-	if !ok {
-		return "", errors.New("didn't receive digest from buildkit")
+	if len(opts.SSH) != 0 {
+		var agents []sshprovider.AgentConfig
+		for id, path := range opts.SSH {
+			agent := sshprovider.AgentConfig{ID: id}
+			if path != "" {
+				agent.Paths = []string{path}
+			}
+			agents = append(agents, agent)
+		}
+
+		sshProvider, err := sshprovider.NewSSHAgentProvider(agents)
+		if err != nil {
+			return nil, errors.NewFriendlyError(
+				"Failed to forward SSH agents to the build. Make sure that SSH_AUTH_SOCK "+
+					"is set, or that the paths in the `ssh` build option exist.\n\n"+
+					"The full error was:\n%s", err)
+		}
+		attachables = append(attachables, sshProvider)
 	}
 
-	return digest, nil
+	if len(opts.Secrets) != 0 {
+		attachables = append(attachables, secretsprovider.FromMap(opts.Secrets))
+	}
+	return attachables, nil
 }
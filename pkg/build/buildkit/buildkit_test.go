@@ -0,0 +1,243 @@
+package buildkit
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/session/secrets"
+	"github.com/moby/buildkit/session/sshforward"
+	"github.com/moby/buildkit/util/entitlements"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kelda/blimp/pkg/build"
+	"github.com/kelda/blimp/pkg/errors"
+)
+
+func TestFrontendOptions(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            composeTypes.BuildConfig
+		expAttrs        map[string]string
+		expEntitlements []entitlements.Entitlement
+		expErr          error
+	}{
+		{
+			name: "default",
+			opts: composeTypes.BuildConfig{Dockerfile: "Dockerfile"},
+			expAttrs: map[string]string{
+				"filename":           "Dockerfile",
+				"image-resolve-mode": "default",
+			},
+		},
+		{
+			name: "extra hosts and no network",
+			opts: composeTypes.BuildConfig{
+				Dockerfile: "Dockerfile",
+				ExtraHosts: []string{"db:10.0.0.1", "ipv6:::1"},
+				Network:    "none",
+			},
+			expAttrs: map[string]string{
+				"filename":           "Dockerfile",
+				"image-resolve-mode": "default",
+				"add-hosts":          "db=10.0.0.1,ipv6=::1",
+				"force-network-mode": "none",
+			},
+		},
+		{
+			name: "host network",
+			opts: composeTypes.BuildConfig{Dockerfile: "Dockerfile", Network: "host"},
+			expAttrs: map[string]string{
+				"filename":           "Dockerfile",
+				"image-resolve-mode": "default",
+				"force-network-mode": "host",
+			},
+			expEntitlements: []entitlements.Entitlement{entitlements.EntitlementNetworkHost},
+		},
+		{
+			name: "unsupported network",
+			opts: composeTypes.BuildConfig{Dockerfile: "Dockerfile", Network: "my-network"},
+			expErr: errors.NewFriendlyError(
+				"Service web builds with `network: my-network`, but only the `default`, `none`, and `host` " +
+					"build networks are supported."),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			attrs, ents, err := frontendOptions("web", build.BuildPushConfig{BuildConfig: test.opts})
+			assert.Equal(t, test.expErr, err)
+			assert.Equal(t, test.expAttrs, attrs)
+			assert.Equal(t, test.expEntitlements, ents)
+		})
+	}
+}
+
+func TestLocalBuildkitdAddresses(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expAddrs []string
+	}{
+		{
+			name: "BUILDKIT_HOST",
+			env: map[string]string{
+				"BUILDKIT_HOST":   "tcp://127.0.0.1:1234",
+				"XDG_RUNTIME_DIR": "/run/user/1000",
+			},
+			expAddrs: []string{"tcp://127.0.0.1:1234"},
+		},
+		{
+			name: "rootless",
+			env:  map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000"},
+			expAddrs: []string{
+				"unix:///run/user/1000/buildkit-default/buildkitd.sock",
+				"unix:///run/user/1000/buildkit/buildkitd.sock",
+				"unix:///run/buildkit-default/buildkitd.sock",
+				"unix:///run/buildkit/buildkitd.sock",
+			},
+		},
+		{
+			name: "containerd namespace",
+			env:  map[string]string{"CONTAINERD_NAMESPACE": "k8s.io"},
+			expAddrs: []string{
+				"unix:///run/buildkit-k8s.io/buildkitd.sock",
+				"unix:///run/buildkit/buildkitd.sock",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			for _, key := range []string{"BUILDKIT_HOST", "XDG_RUNTIME_DIR", "CONTAINERD_NAMESPACE"} {
+				defer setenv(key, test.env[key])()
+			}
+			assert.Equal(t, test.expAddrs, localBuildkitdAddresses())
+		})
+	}
+}
+
+func TestGetAttachables(t *testing.T) {
+	c := Client{authProvider: &authProvider{}}
+
+	attachables, err := c.getAttachables(build.BuildPushConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(attachables), "only the registry credentials should be attached")
+
+	attachables, err = c.getAttachables(build.BuildPushConfig{
+		Secrets: map[string][]byte{"token": []byte("secret")},
+	})
+	require.NoError(t, err)
+	require.Len(t, attachables, 2)
+	secretsServer, ok := attachables[1].(secrets.SecretsServer)
+	require.True(t, ok)
+	resp, err := secretsServer.GetSecret(context.Background(), &secrets.GetSecretRequest{ID: "token"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), resp.Data)
+
+	// Forward an SSH agent socket.
+	dir, err := ioutil.TempDir("", "blimp-ssh")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sock := filepath.Join(dir, "agent.sock")
+	listener, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	attachables, err = c.getAttachables(build.BuildPushConfig{
+		SSH: map[string]string{"github": sock},
+	})
+	require.NoError(t, err)
+	require.Len(t, attachables, 2)
+	sshServer, ok := attachables[1].(sshforward.SSHServer)
+	require.True(t, ok)
+	_, err = sshServer.CheckAgent(context.Background(), &sshforward.CheckAgentRequest{ID: "github"})
+	assert.NoError(t, err)
+	_, err = sshServer.CheckAgent(context.Background(), &sshforward.CheckAgentRequest{ID: "gitlab"})
+	assert.Error(t, err)
+
+	// Paths that don't exist should fail with a friendly error.
+	_, err = c.getAttachables(build.BuildPushConfig{
+		SSH: map[string]string{"github": filepath.Join(dir, "missing.sock")},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Failed to forward SSH agents to the build.")
+}
+
+func TestCacheImports(t *testing.T) {
+	tests := []struct {
+		name string
+		opts build.BuildPushConfig
+		exp  []client.CacheOptionsEntry
+	}{
+		{
+			name: "none",
+			opts: build.BuildPushConfig{},
+			exp:  nil,
+		},
+		{
+			name: "cache_from and cache repositories",
+			opts: build.BuildPushConfig{
+				BuildConfig: composeTypes.BuildConfig{CacheFrom: []string{"web:latest"}},
+				CacheImports: []string{
+					"blimp-registry.io/ns/web",
+					"registry.example.com/team/web",
+				},
+			},
+			exp: []client.CacheOptionsEntry{
+				{Type: "registry", Attrs: map[string]string{"ref": "web:latest"}},
+				{Type: "registry", Attrs: map[string]string{"ref": "blimp-registry.io/ns/web:cache"}},
+				{Type: "registry", Attrs: map[string]string{"ref": "registry.example.com/team/web:cache"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.exp, cacheImports(test.opts))
+		})
+	}
+}
+
+func TestCacheExports(t *testing.T) {
+	assert.Nil(t, cacheExports(build.BuildPushConfig{}))
+	assert.Equal(t, []client.CacheOptionsEntry{
+		{
+			Type: "registry",
+			Attrs: map[string]string{
+				"ref":  "blimp-registry.io/ns/web:cache",
+				"mode": "max",
+			},
+		},
+	}, cacheExports(build.BuildPushConfig{CacheExport: "blimp-registry.io/ns/web"}))
+}
+
+// setenv sets the environment variable, and returns a function that restores
+// its original value. Empty values unset the variable.
+func setenv(key, value string) (restore func()) {
+	orig, ok := os.LookupEnv(key)
+	restore = func() {
+		if ok {
+			os.Setenv(key, orig)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+
+	if value == "" {
+		os.Unsetenv(key)
+	} else {
+		os.Setenv(key, value)
+	}
+	return restore
+}
@@ -0,0 +1,88 @@
+package buildkit
+
+import (
+	"strings"
+
+	"github.com/moby/buildkit/client"
+	digest "github.com/opencontainers/go-digest"
+
+	"github.com/kelda/blimp/pkg/build"
+)
+
+// emitEvents translates the status updates from a solve into build events.
+// If `display` is non-nil, the updates are also forwarded to it, and it's
+// closed once `statuses` is closed.
+func emitEvents(progress *build.Progress, service string,
+	statuses <-chan *client.SolveStatus, display chan<- *client.SolveStatus) {
+	if display != nil {
+		defer close(display)
+	}
+
+	// BuildKit resends vertices whenever they change, so keep track of which
+	// events have already been emitted.
+	started := map[digest.Digest]bool{}
+	completed := map[digest.Digest]bool{}
+	pushed := map[string]bool{}
+	for status := range statuses {
+		if display != nil {
+			display <- status
+		}
+
+		for _, v := range status.Vertexes {
+			vertex := v.Digest.String()
+			if v.Started != nil && !v.Cached && !started[v.Digest] {
+				started[v.Digest] = true
+				progress.Emit(build.Event{
+					Service: service,
+					Type:    build.EventVertexStarted,
+					Vertex:  vertex,
+					Name:    v.Name,
+				})
+			}
+
+			if v.Completed != nil && !completed[v.Digest] {
+				completed[v.Digest] = true
+				eventType := build.EventVertexCompleted
+				if v.Cached {
+					eventType = build.EventCacheHit
+				}
+				progress.Emit(build.Event{
+					Service: service,
+					Type:    eventType,
+					Vertex:  vertex,
+					Name:    v.Name,
+					Error:   v.Error,
+				})
+			}
+		}
+
+		for _, s := range status.Statuses {
+			if !strings.HasPrefix(s.ID, "pushing") || pushed[s.ID] {
+				continue
+			}
+
+			done := s.Completed != nil
+			pushed[s.ID] = done
+			progress.Emit(build.Event{
+				Service: service,
+				Type:    build.EventPushProgress,
+				Vertex:  s.Vertex.String(),
+				Name:    s.ID,
+				Current: s.Current,
+				Total:   s.Total,
+				Done:    done,
+			})
+		}
+
+		for _, l := range status.Logs {
+			for _, line := range strings.Split(strings.TrimRight(string(l.Data), "\n"), "\n") {
+				progress.Emit(build.Event{
+					Service: service,
+					Type:    build.EventLog,
+					Vertex:  l.Vertex.String(),
+					Message: line,
+				})
+			}
+		}
+	}
+}
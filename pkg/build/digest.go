@@ -0,0 +1,162 @@
+package build
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/docker/docker/builder/dockerignore"
+	"github.com/docker/docker/pkg/fileutils"
+	composeTypes "github.com/kelda/compose-go/types"
+
+	"github.com/kelda/blimp/pkg/errors"
+)
+
+// Digest returns a digest of the inputs to the given build: the files in the
+// build context that aren't excluded by the .dockerignore, the Dockerfile,
+// and the build options that affect the image, such as the args, target,
+// and labels. Builds with the same digest produce equivalent images, so
+// images are tagged with their digest to decide whether they need to be
+// rebuilt.
+func Digest(cfg composeTypes.BuildConfig) (string, error) {
+	h := sha256.New()
+	if err := hashContext(h, cfg.Context); err != nil {
+		return "", errors.WithContext("hash build context", err)
+	}
+
+	// The Dockerfile is always sent to the builder, even if it's outside the
+	// build context or excluded by the .dockerignore.
+	dockerfilePath := cfg.Dockerfile
+	if !filepath.IsAbs(dockerfilePath) {
+		dockerfilePath = filepath.Join(cfg.Context, dockerfilePath)
+	}
+	dockerfile, err := ioutil.ReadFile(dockerfilePath)
+	if err != nil {
+		return "", errors.WithContext("read Dockerfile", err)
+	}
+	writeFields(h, "dockerfile", string(dockerfile))
+
+	var args []string
+	for key := range cfg.Args {
+		args = append(args, key)
+	}
+	sort.Strings(args)
+	for _, key := range args {
+		// Distinguish args without values (which are read from the
+		// environment when the Compose file is loaded) from empty args.
+		if val := cfg.Args[key]; val != nil {
+			writeFields(h, "arg", key, *val)
+		} else {
+			writeFields(h, "arg", key)
+		}
+	}
+
+	writeFields(h, "target", cfg.Target)
+
+	var labels []string
+	for key := range cfg.Labels {
+		labels = append(labels, key)
+	}
+	sort.Strings(labels)
+	for _, key := range labels {
+		writeFields(h, "label", key, cfg.Labels[key])
+	}
+
+	// The network and extra hosts can change the results of RUN
+	// instructions. cache_from isn't included since it only affects which
+	// layers are reused.
+	writeFields(h, "network", cfg.Network)
+	for _, host := range cfg.ExtraHosts {
+		writeFields(h, "extra_host", host)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// hashContext writes the paths, modes, and contents of the files in the build
+// context to the hash. Files that are excluded by the .dockerignore are
+// skipped, since they're not sent to the builder.
+func hashContext(h hash.Hash, dir string) error {
+	var excludes []string
+	f, err := os.Open(filepath.Join(dir, ".dockerignore"))
+	switch {
+	case err == nil:
+		excludes, err = dockerignore.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return errors.WithContext("parse .dockerignore", err)
+		}
+	case !os.IsNotExist(err):
+		return errors.WithContext("open .dockerignore", err)
+	}
+
+	pm, err := fileutils.NewPatternMatcher(excludes)
+	if err != nil {
+		return errors.WithContext("parse .dockerignore", err)
+	}
+
+	return filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+
+		// This mirrors how the Docker CLI applies the .dockerignore. Excluded
+		// directories are only walked if a later pattern may re-include
+		// files within them.
+		excluded, err := pm.Matches(relPath)
+		if err != nil {
+			return err
+		}
+		if excluded {
+			if fi.IsDir() && !pm.Exclusions() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath = filepath.ToSlash(relPath)
+		switch mode := fi.Mode(); {
+		case mode&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			writeFields(h, "symlink", relPath, link)
+		case mode.IsRegular():
+			writeFields(h, "file", relPath, mode.String(), fmt.Sprintf("%d", fi.Size()))
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			if _, err := io.Copy(h, f); err != nil {
+				return err
+			}
+		default:
+			writeFields(h, "other", relPath, mode.String())
+		}
+		return nil
+	})
+}
+
+// writeFields writes the given fields to the hash, separated by null bytes
+// so that different fields can't produce the same input.
+func writeFields(h hash.Hash, fields ...string) {
+	for _, field := range fields {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+}
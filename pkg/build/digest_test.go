@@ -0,0 +1,86 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	composeTypes "github.com/kelda/compose-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blimp-build-digest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFile := func(path, contents string) {
+		path = filepath.Join(dir, path)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+	writeFile("Dockerfile", "FROM alpine")
+	writeFile(".dockerignore", "node_modules\n*.log\n")
+	writeFile("src/main.go", "package main")
+	writeFile("node_modules/dep/index.js", "dep")
+
+	env := "production"
+	cfg := composeTypes.BuildConfig{
+		Context:    dir,
+		Dockerfile: "Dockerfile",
+		Args:       composeTypes.MappingWithEquals{"ENV": &env},
+	}
+	digest := func(cfg composeTypes.BuildConfig) string {
+		d, err := Digest(cfg)
+		assert.NoError(t, err)
+		return d
+	}
+
+	initial := digest(cfg)
+	assert.Equal(t, initial, digest(cfg))
+
+	// Files that are excluded by the .dockerignore don't affect the digest.
+	writeFile("node_modules/dep/index.js", "changed")
+	writeFile("debug.log", "log")
+	assert.Equal(t, initial, digest(cfg))
+
+	// Changing the args or target changes the digest.
+	otherEnv := "development"
+	withArgs := cfg
+	withArgs.Args = composeTypes.MappingWithEquals{"ENV": &otherEnv}
+	assert.NotEqual(t, initial, digest(withArgs))
+
+	withTarget := cfg
+	withTarget.Target = "dev"
+	assert.NotEqual(t, initial, digest(withTarget))
+
+	// So do the labels, network, and extra hosts, but not cache_from.
+	withLabels := cfg
+	withLabels.Labels = composeTypes.Labels{"version": "1"}
+	assert.NotEqual(t, initial, digest(withLabels))
+
+	withChangedLabels := cfg
+	withChangedLabels.Labels = composeTypes.Labels{"version": "2"}
+	assert.NotEqual(t, digest(withLabels), digest(withChangedLabels))
+
+	withNetwork := cfg
+	withNetwork.Network = "host"
+	assert.NotEqual(t, initial, digest(withNetwork))
+
+	withExtraHosts := cfg
+	withExtraHosts.ExtraHosts = composeTypes.HostsList{"db:10.0.0.1"}
+	assert.NotEqual(t, initial, digest(withExtraHosts))
+
+	withCacheFrom := cfg
+	withCacheFrom.CacheFrom = composeTypes.StringList{"web:latest"}
+	assert.Equal(t, initial, digest(withCacheFrom))
+
+	// Changing the Dockerfile or the files in the context changes the digest.
+	writeFile("Dockerfile", "FROM ubuntu")
+	changedDockerfile := digest(cfg)
+	assert.NotEqual(t, initial, changedDockerfile)
+
+	writeFile("src/main.go", "package main\n")
+	assert.NotEqual(t, changedDockerfile, digest(cfg))
+}
@@ -10,7 +10,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/cli/cli/config/configfile"
@@ -19,7 +21,6 @@ import (
 	docker "github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/jsonmessage"
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/kelda/blimp/cli/util"
 	"github.com/kelda/blimp/pkg/auth"
@@ -29,15 +30,21 @@ import (
 )
 
 type client struct {
-	client       *docker.Client
+	client *docker.Client
+
+	// command is the CLI that corresponds to the daemon, and is used to
+	// suggest how to debug failed builds.
+	command string
+
 	regCreds     auth.RegistryCredentials
 	dockerConfig *configfile.ConfigFile
 	blimpAuth    *protoAuth.BlimpAuth
+	progress     *build.Progress
 
 	// Cache state
 	composePath        string
-	oldBlimpImageCache map[string]types.ImageSummary
-	composeImageCache  map[string]types.ImageSummary
+	oldBlimpImageCache map[string]string
+	composeImageCache  map[string]string
 }
 
 type CacheOptions struct {
@@ -46,7 +53,7 @@ type CacheOptions struct {
 }
 
 func New(regCreds auth.RegistryCredentials, dockerConfig *configfile.ConfigFile,
-	blimpAuth *protoAuth.BlimpAuth, cacheOpts CacheOptions) (build.Interface, error) {
+	blimpAuth *protoAuth.BlimpAuth, progress *build.Progress, cacheOpts CacheOptions) (build.Interface, error) {
 	dockerClient, err := getDockerClient()
 	if err != nil {
 		return nil, err
@@ -54,120 +61,239 @@ func New(regCreds auth.RegistryCredentials, dockerConfig *configfile.ConfigFile,
 
 	c := client{
 		client:       dockerClient,
+		command:      "docker",
 		regCreds:     regCreds,
 		dockerConfig: dockerConfig,
 		blimpAuth:    blimpAuth,
+		progress:     progress,
+	}
+	c.loadImageCaches(cacheOpts)
+	return c, nil
+}
+
+// loadImageCaches finds the images built by Docker Compose and previous
+// versions of Blimp, so that they can be used as caches when building the
+// services.
+func (c *client) loadImageCaches(cacheOpts CacheOptions) {
+	if cacheOpts.ProjectName == "" || cacheOpts.ComposePath == "" {
+		return
 	}
 
-	if cacheOpts.ProjectName != "" && cacheOpts.ComposePath != "" {
-		c.composePath = cacheOpts.ComposePath
-		oldBlimpImageCache, composeImageCache, err := getImageCaches(dockerClient, cacheOpts.ProjectName)
-		if err == nil {
-			c.oldBlimpImageCache = oldBlimpImageCache
-			c.composeImageCache = composeImageCache
+	c.composePath = cacheOpts.ComposePath
+	oldBlimpImageCache, composeImageCache, err := getImageCaches(c.client, cacheOpts.ProjectName)
+	if err != nil {
+		log.WithError(err).Debug("Failed to get compose image cache")
+		return
+	}
+	c.oldBlimpImageCache = oldBlimpImageCache
+	c.composeImageCache = composeImageCache
+}
+
+func (c client) BuildAndPush(images map[string]build.BuildPushConfig, opts build.ScheduleOptions) (build.Results, error) {
+	prePushed, prePushErr := c.startPrePush(images)
+	pulledBaseImage := c.pullSharedBaseImages(images, opts.Parallelism)
+
+	results := build.Schedule(images, opts, func(service string, buildOpts build.BuildPushConfig) (string, error) {
+		// Images are tagged with the digest of their build inputs, so if the
+		// image already exists locally, it's up to date and only needs to be
+		// pushed.
+		built := false
+		if buildOpts.ForceBuild || !c.hasImage(buildOpts.ImageName) {
+			// The base image was just pulled, so there's no need for the
+			// build to pull it again.
+			if pulledBaseImage[service] {
+				buildOpts.PullParent = false
+			}
+
+			if err := c.build(service, buildOpts.ImageName, buildOpts); err != nil {
+				return "", err
+			}
+			built = true
 		} else {
-			log.WithError(err).Debug("Failed to get compose image cache")
+			log.WithField("service", service).Info("Using cached image")
 		}
-	}
 
-	return c, nil
+		// Push the rest of the layers once the base image has been pushed.
+		c.waitForPrePush(service, prePushed[service])
+		digest, err := c.push(service, buildOpts.ImageName)
+		if err != nil {
+			return "", errors.WithContext("push image", err)
+		}
+
+		// The Docker builder can't export BuildKit caches, so we push the
+		// built images themselves, which can be used as caches by later
+		// builds.
+		if built && buildOpts.CacheExport != "" {
+			cacheImage := build.CacheImage(buildOpts.CacheExport, build.CacheTagDocker)
+			if err := c.exportCache(service, buildOpts.ImageName, cacheImage); err != nil {
+				log.WithError(err).WithField("service", service).Warn("Failed to export build cache")
+			}
+		}
+		return pushedImageName(buildOpts.ImageName, digest), nil
+	})
+
+	if err := <-prePushErr; err != nil {
+		// This should probably not be fatal, but we wouldn't expect it to happen.
+		log.WithError(err).Warn("Pre-push server call failed unexpectedly. Continuing anyways")
+	}
+	return results, nil
 }
 
-func (c client) BuildAndPush(images map[string]build.BuildPushConfig) (pushedImages map[string]string, err error) {
-	pushedImages = map[string]string{}
+// startPrePush starts pushing the services' base images to the registry in
+// the background. It returns a channel for each service that receives the
+// result of pushing the service's base image, and is closed once all the
+// base images have been pushed. The returned error channel receives the
+// error from the pre-push as a whole.
+func (c client) startPrePush(images map[string]build.BuildPushConfig) (map[string]chan error, <-chan error) {
+	prePushed := map[string]chan error{}
+	for service := range images {
+		// The channels are buffered so that the pre-push isn't blocked on
+		// services that fail to build, and so never wait for their result.
+		prePushed[service] = make(chan error, 1)
+	}
 
-	prePushChan := make(chan prePushResult)
-	prePushErr := make(chan error)
+	results := make(chan prePushResult)
+	prePushErr := make(chan error, 1)
 	go func() {
-		prePushErr <- pushBaseImages(c.client, c.blimpAuth, c.regCreds, images, prePushChan)
+		prePushErr <- pushBaseImages(c.client, c.blimpAuth, c.regCreds, images, results)
 	}()
-
-	// needToPush keeps track of which images still need to be pushed to the
-	// registry. We will delete service names from this map as they are pushed.
-	needToPush := map[string]struct{}{}
-	for service := range images {
-		needToPush[service] = struct{}{}
-	}
-
-	// Build all the services.
-	for serviceName, opts := range images {
-		// If the image is in the docker cache, then just tag it to be imageName
-		// rather than doing a full build.
-		if !opts.ForceBuild {
-			cached, ok := c.getCachedImage(serviceName)
-			if ok {
-				log.WithField("service", serviceName).Info("Using cached image")
-				if err := c.client.ImageTag(context.Background(), cached.ID, opts.ImageName); err != nil {
-					return nil, errors.WithContext("tag", err)
+	go func() {
+		for result := range results {
+			if ch, ok := prePushed[result.service]; ok {
+				select {
+				case ch <- result.err:
+				default:
 				}
-				continue
 			}
 		}
 
-		if err := c.build(serviceName, opts.ImageName, opts); err != nil {
-			return nil, errors.WithContext("build", err)
+		// Services whose base images weren't pre-pushed are pushed in full.
+		for _, ch := range prePushed {
+			close(ch)
 		}
-	}
+	}()
+	return prePushed, prePushErr
+}
 
-	// Wait for prepushes to complete. If prepush isn't complete yet, indiciate
-	// that we're waiting for it to finish.
-	for {
-		var result prePushResult
-		var ok bool
-		select {
-		case result, ok = <-prePushChan:
-		default:
+// waitForPrePush waits for the service's base image to be pushed. If the
+// pre-push isn't complete yet, it indicates that we're waiting for it to
+// finish.
+func (c client) waitForPrePush(service string, prePushed <-chan error) {
+	var err error
+	select {
+	case err = <-prePushed:
+	default:
+		if c.progress.Interactive() {
 			pp := util.NewProgressPrinter(os.Stdout, "Waiting for base image to be uploaded")
 			go pp.Run()
-			result, ok = <-prePushChan
+			err = <-prePushed
 			pp.Stop()
+		} else {
+			err = <-prePushed
 		}
+	}
 
-		if !ok {
-			break
-		}
+	if err != nil {
+		log.WithField("service", service).WithError(err).Debug("Prepush failed. Proceeding with a full image push")
+	}
+}
 
-		if result.err != nil {
-			log.WithField("service", result.service).WithError(result.err).Debug("Prepush failed. Proceeding with a full image push")
+// pullSharedBaseImages pulls the base images that are used by multiple
+// services before the services are built, so that the image is only pulled
+// once rather than by each build. Images that already exist locally are only
+// pulled if a build requests it. It returns the services whose base images
+// were pulled.
+func (c client) pullSharedBaseImages(images map[string]build.BuildPushConfig, parallelism int) map[string]bool {
+	users := map[string][]string{}
+	for service, opts := range images {
+		baseImage, err := parseBaseImage(filepath.Join(opts.Context, opts.Dockerfile))
+		if err != nil || baseImage == "scratch" {
+			continue
 		}
+		users[baseImage] = append(users[baseImage], service)
+	}
 
-		imageName := images[result.service].ImageName
+	toPull := map[string][]string{}
+	for image, services := range users {
+		if len(services) < 2 {
+			continue
+		}
 
-		// Push the rest of the layers.
-		digest, err := c.push(imageName)
-		if err != nil {
-			return nil, errors.WithContext("push image after pre-push", err)
+		pullParent := false
+		for _, service := range services {
+			pullParent = pullParent || images[service].PullParent
+		}
+		if pullParent || !c.hasImage(image) {
+			toPull[image] = services
 		}
-		pushedImages[result.service] = build.ReplaceTagWithDigest(imageName, digest)
-		delete(needToPush, result.service)
 	}
 
-	err = <-prePushErr
-	if err != nil {
-		// This should probably not be fatal, but we wouldn't expect it to happen.
-		log.WithError(err).Warn("Pre-push server call failed unexpectedly. Continuing anyways")
+	if parallelism < 1 {
+		parallelism = 1
 	}
 
-	// Push any images that were not prepushed.
-	for service := range needToPush {
-		imageName := images[service].ImageName
-		digest, err := c.push(imageName)
-		if err != nil {
-			return nil, errors.WithContext("push image without pre-push", err)
-		}
-		pushedImages[service] = build.ReplaceTagWithDigest(imageName, digest)
-	}
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	pulled := map[string]bool{}
+	sem := make(chan struct{}, parallelism)
+	for image, services := range toPull {
+		wg.Add(1)
+		go func(image string, services []string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			sort.Strings(services)
+			c.progress.Printf(services[0], "Pulling %s for %s...", image, strings.Join(services, ", "))
+			if err := c.pull(image); err != nil {
+				// The builds will try pulling the image themselves.
+				log.WithError(err).WithField("image", image).Debug("Failed to pull base image")
+				return
+			}
 
-	return pushedImages, nil
+			lock.Lock()
+			for _, service := range services {
+				pulled[service] = true
+			}
+			lock.Unlock()
+		}(image, services)
+	}
+	wg.Wait()
+	return pulled
 }
 
 func (c *client) build(serviceName, imageName string, opts build.BuildPushConfig) error {
-	fmt.Printf("Building image for %s...\n", serviceName)
+	if len(opts.SSH) != 0 || len(opts.Secrets) != 0 {
+		return errors.NewFriendlyError(
+			"Service %s uses the `ssh` or `secrets` build options, which are only supported "+
+				"when building in your sandbox. Build with `--remote-build` instead.", serviceName)
+	}
+
+	c.progress.Printf(serviceName, "Building image for %s...", serviceName)
 	buildContextTar, err := makeTar(opts.Context)
 	if err != nil {
 		return errors.WithContext("tar context", err)
 	}
 
+	// The Docker builder only uses images that exist locally as caches, so
+	// pull the cache images first. It's fine if they don't exist yet.
+	cacheFrom := append([]string{}, opts.CacheFrom...)
+	for _, repo := range opts.CacheImports {
+		image := build.CacheImage(repo, build.CacheTagDocker)
+		if err := c.pull(image); err != nil {
+			log.WithError(err).WithField("image", image).Debug("Failed to pull cache image")
+			continue
+		}
+		cacheFrom = append(cacheFrom, image)
+	}
+
+	// Images built by Docker Compose or previous versions of Blimp may be out
+	// of date, so they're only used as caches rather than in place of the
+	// build.
+	if cached, ok := c.getCachedImage(serviceName); ok {
+		cacheFrom = append(cacheFrom, cached)
+	}
+
 	buildResp, err := c.client.ImageBuild(context.TODO(), buildContextTar, types.ImageBuildOptions{
 		Tags:        []string{imageName},
 		Dockerfile:  opts.Dockerfile,
@@ -175,9 +301,11 @@ func (c *client) build(serviceName, imageName string, opts build.BuildPushConfig
 		BuildArgs:   c.dockerConfig.ParseProxyConfig(c.client.DaemonHost(), opts.Args),
 		Target:      opts.Target,
 		Labels:      opts.Labels,
-		CacheFrom:   opts.CacheFrom,
+		CacheFrom:   cacheFrom,
 		PullParent:  opts.PullParent,
 		NoCache:     opts.NoCache,
+		ExtraHosts:  opts.ExtraHosts,
+		NetworkMode: opts.Network,
 	})
 	if err != nil {
 		return errors.WithContext("start build", err)
@@ -186,18 +314,52 @@ func (c *client) build(serviceName, imageName string, opts build.BuildPushConfig
 
 	// Block until the build completes, and return any errors that happen
 	// during the build.
-	isTerminal := terminal.IsTerminal(int(os.Stdout.Fd()))
-	err = jsonmessage.DisplayJSONMessagesStream(buildResp.Body, os.Stdout, os.Stdout.Fd(), isTerminal, nil)
+	events := &buildEvents{progress: c.progress, service: serviceName}
+	err = c.streamMessages(buildResp.Body, events.handle)
+	events.finishStep("")
 	if err != nil {
 		return errors.NewFriendlyError(
 			"Image build for %q failed. This is likely an error with the Dockerfile, rather than Blimp.\n"+
-				"Make sure that the image successfully builds with `docker build`.\n\n"+
-				"The full error was:\n%s", serviceName, err)
+				"Make sure that the image successfully builds with `%s build`.\n"+
+				"The build log is saved at %s.\n\n"+
+				"The full error was:\n%s", serviceName, c.command, c.progress.LogPath(serviceName), err)
 	}
 	return nil
 }
 
-func (c *client) push(image string) (string, error) {
+// pull pulls the given image, without printing its progress.
+func (c *client) pull(image string) error {
+	var registryAuth string
+	if cred, ok := c.regCreds.LookupByImage(image); ok {
+		var err error
+		registryAuth, err = auth.RegistryAuthHeader(cred)
+		if err != nil {
+			return err
+		}
+	}
+
+	pullResp, err := c.client.ImagePull(context.Background(), image, types.ImagePullOptions{
+		RegistryAuth: registryAuth,
+	})
+	if err != nil {
+		return errors.WithContext("start image pull", err)
+	}
+	defer pullResp.Close()
+
+	return jsonmessage.DisplayJSONMessagesStream(pullResp, ioutil.Discard, 0, false, nil)
+}
+
+// exportCache tags the given image as the cache image, and pushes it.
+func (c *client) exportCache(service, image, cacheImage string) error {
+	if err := c.client.ImageTag(context.Background(), image, cacheImage); err != nil {
+		return errors.WithContext("tag", err)
+	}
+
+	_, err := c.push(service, cacheImage)
+	return err
+}
+
+func (c *client) push(service, image string) (string, error) {
 	cred, ok := c.regCreds.LookupByImage(image)
 	if !ok {
 		return "", errors.New("no credentials for pushing image")
@@ -208,7 +370,7 @@ func (c *client) push(image string) (string, error) {
 		return "", err
 	}
 
-	fmt.Printf("Pushing %s...\n", image)
+	c.progress.Printf(service, "Pushing %s...", image)
 	pushResp, err := c.client.ImagePush(context.Background(), image, types.ImagePushOptions{
 		RegistryAuth: registryAuth,
 	})
@@ -218,7 +380,12 @@ func (c *client) push(image string) (string, error) {
 	defer pushResp.Close()
 
 	var imageDigest string
-	callback := func(msg jsonmessage.JSONMessage) {
+	handle := func(msg jsonmessage.JSONMessage) {
+		if msg.Aux == nil {
+			emitPushEvent(c.progress, service, msg)
+			return
+		}
+
 		var digest struct{ Digest string }
 		if err := json.Unmarshal(*msg.Aux, &digest); err != nil {
 			log.WithError(err).Warn("Failed to parse digest")
@@ -229,11 +396,21 @@ func (c *client) push(image string) (string, error) {
 			imageDigest = digest.Digest
 		}
 	}
-	isTerminal := terminal.IsTerminal(int(os.Stdout.Fd()))
-	err = jsonmessage.DisplayJSONMessagesStream(pushResp, os.Stdout, os.Stdout.Fd(), isTerminal, callback)
+	err = c.streamMessages(pushResp, handle)
 	return imageDigest, err
 }
 
+// pushedImageName returns the name that a pushed image should be referenced
+// by. Podman doesn't always report the digest of pushed images, in which case
+// the image is referenced by its tag. The tag is derived from the image's
+// build inputs, so it still identifies the image.
+func pushedImageName(imageName, digest string) string {
+	if digest == "" {
+		return imageName
+	}
+	return build.ReplaceTagWithDigest(imageName, digest)
+}
+
 // getDockerClient gets a Docker client, and validates that the server will
 // respond to requests. If we're running in WSL, we try to connect to the
 // default Docker location, and to localhost:2375 (which we recommend as a
@@ -342,7 +519,10 @@ func makeTar(dir string) (io.Reader, error) {
 	return &out, err
 }
 
-func getImageCaches(c *docker.Client, project string) (map[string]types.ImageSummary, map[string]types.ImageSummary, error) {
+// getImageCaches returns the images built by previous versions of Blimp, and
+// by Docker Compose. The old Blimp images are keyed by their tag, and the
+// Docker Compose images are keyed by their service.
+func getImageCaches(c *docker.Client, project string) (map[string]string, map[string]string, error) {
 	// See https://github.com/docker/compose/blob/854c14a5bcf566792ee8a972325c37590521656b/compose/service.py#L379
 	ctx, _ := context.WithTimeout(context.Background(), 30*time.Second)
 	opts := types.ImageListOptions{
@@ -361,16 +541,16 @@ func getImageCaches(c *docker.Client, project string) (map[string]types.ImageSum
 		return nil, nil, err
 	}
 
-	oldBlimpCache := map[string]types.ImageSummary{}
+	oldBlimpCache := map[string]string{}
 	for _, image := range images {
 		for _, tag := range image.RepoTags {
 			if strings.HasPrefix(tag, "blimp-cache:") {
-				oldBlimpCache[strings.TrimPrefix(tag, "blimp-cache:")] = image
+				oldBlimpCache[strings.TrimPrefix(tag, "blimp-cache:")] = tag
 			}
 		}
 	}
 
-	composeCache := map[string]types.ImageSummary{}
+	composeCache := map[string]string{}
 	for _, image := range images {
 		for _, tag := range image.RepoTags {
 			prefix := project + "_"
@@ -378,13 +558,15 @@ func getImageCaches(c *docker.Client, project string) (map[string]types.ImageSum
 			if !strings.HasPrefix(tag, prefix) || !strings.HasSuffix(tag, suffix) {
 				continue
 			}
-			composeCache[strings.TrimPrefix(strings.TrimSuffix(tag, suffix), prefix)] = image
+			composeCache[strings.TrimPrefix(strings.TrimSuffix(tag, suffix), prefix)] = tag
 		}
 	}
 	return oldBlimpCache, composeCache, nil
 }
 
-func (c *client) getCachedImage(service string) (types.ImageSummary, bool) {
+// getCachedImage returns the image that was built for the service by a
+// previous version of Blimp, or by Docker Compose.
+func (c *client) getCachedImage(service string) (string, bool) {
 	// Try the old Blimp cache first.
 	tag := build.BlimpServiceTag(c.composePath, service)
 	image, ok := c.oldBlimpImageCache[tag]
@@ -396,3 +578,9 @@ func (c *client) getCachedImage(service string) (types.ImageSummary, bool) {
 	image, ok = c.composeImageCache[service]
 	return image, ok
 }
+
+// hasImage returns whether the given image exists in the local Docker daemon.
+func (c *client) hasImage(image string) bool {
+	_, _, err := c.client.ImageInspectWithRaw(context.Background(), image)
+	return err == nil
+}
@@ -0,0 +1,76 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/cli/cli/config/configfile"
+	docker "github.com/docker/docker/client"
+
+	"github.com/kelda/blimp/pkg/auth"
+	"github.com/kelda/blimp/pkg/build"
+	"github.com/kelda/blimp/pkg/errors"
+	protoAuth "github.com/kelda/blimp/pkg/proto/auth"
+)
+
+// NewPodman returns a builder that builds images with Podman. Podman's API
+// service is compatible with the Docker API, so the builder is otherwise the
+// same as the Docker builder.
+func NewPodman(regCreds auth.RegistryCredentials, dockerConfig *configfile.ConfigFile,
+	blimpAuth *protoAuth.BlimpAuth, progress *build.Progress, cacheOpts CacheOptions) (build.Interface, error) {
+	podmanClient, err := getPodmanClient()
+	if err != nil {
+		return nil, err
+	}
+
+	c := client{
+		client:       podmanClient,
+		command:      "podman",
+		regCreds:     regCreds,
+		dockerConfig: dockerConfig,
+		blimpAuth:    blimpAuth,
+		progress:     progress,
+	}
+	c.loadImageCaches(cacheOpts)
+	return c, nil
+}
+
+// getPodmanClient gets a client for the first Podman API socket that
+// responds to requests.
+func getPodmanClient() (*docker.Client, error) {
+	var lastErr error
+	for _, host := range podmanHosts() {
+		podmanClient, err := docker.NewClientWithOpts(docker.WithHost(host), docker.WithAPIVersionNegotiation())
+		if err != nil {
+			lastErr = errors.WithContext("create podman client", err)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err = podmanClient.Ping(ctx)
+		cancel()
+		if err != nil {
+			lastErr = errors.WithContext("podman ping failed", err)
+			continue
+		}
+		return podmanClient, nil
+	}
+	return nil, lastErr
+}
+
+// podmanHosts returns the addresses that the Podman API service might be
+// listening on. CONTAINER_HOST takes precedence, followed by the rootless
+// socket, and then the system-wide socket.
+func podmanHosts() []string {
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		return []string{host}
+	}
+
+	var hosts []string
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		hosts = append(hosts, "unix://"+filepath.Join(runtimeDir, "podman", "podman.sock"))
+	}
+	return append(hosts, "unix:///run/podman/podman.sock")
+}
@@ -0,0 +1,67 @@
+package docker
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPodmanHosts(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expHosts []string
+	}{
+		{
+			name: "CONTAINER_HOST",
+			env: map[string]string{
+				"CONTAINER_HOST":  "ssh://core@localhost:2222/run/podman/podman.sock",
+				"XDG_RUNTIME_DIR": "/run/user/1000",
+			},
+			expHosts: []string{"ssh://core@localhost:2222/run/podman/podman.sock"},
+		},
+		{
+			name: "rootless",
+			env:  map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000"},
+			expHosts: []string{
+				"unix:///run/user/1000/podman/podman.sock",
+				"unix:///run/podman/podman.sock",
+			},
+		},
+		{
+			name:     "system",
+			expHosts: []string{"unix:///run/podman/podman.sock"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			for _, key := range []string{"CONTAINER_HOST", "XDG_RUNTIME_DIR"} {
+				defer setenv(key, test.env[key])()
+			}
+			assert.Equal(t, test.expHosts, podmanHosts())
+		})
+	}
+}
+
+// setenv sets the environment variable, and returns a function that restores
+// its original value. Empty values unset the variable.
+func setenv(key, value string) (restore func()) {
+	orig, ok := os.LookupEnv(key)
+	restore = func() {
+		if ok {
+			os.Setenv(key, orig)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+
+	if value == "" {
+		os.Unsetenv(key)
+	} else {
+		os.Setenv(key, value)
+	}
+	return restore
+}
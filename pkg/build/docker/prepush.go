@@ -83,28 +83,11 @@ func pushBaseImages(dockerClient *docker.Client, blimpAuth *protoAuth.BlimpAuth,
 }
 
 func getBaseImage(dockerClient *docker.Client, dockerfilePath string) (string, error) {
-	f, err := os.Open(dockerfilePath)
-	if err != nil {
-		return "", errors.WithContext("open dockerfile", err)
-	}
-	defer f.Close()
-
-	dockerfileTree, err := dockerfileParser.Parse(f)
-	if err != nil {
-		return "", errors.WithContext("load dockerfile", err)
-	}
-
-	stages, _, err := instructions.Parse(dockerfileTree.AST)
+	baseImageName, err := parseBaseImage(dockerfilePath)
 	if err != nil {
-		return "", errors.WithContext("parse dockerfile", err)
-	}
-
-	if len(stages) == 0 {
-		return "", errors.New("no base image")
+		return "", err
 	}
 
-	baseImageName := stages[len(stages)-1].BaseName
-
 	// Explicitly specify the image's digest if it's already pulled locally. If
 	// the upstream tag has a newer digest, we want to cache the local version.
 	// TODO: If the image we are going to push is already built, check the
@@ -140,6 +123,32 @@ func getBaseImage(dockerClient *docker.Client, dockerfilePath string) (string, e
 	return baseImageName, nil
 }
 
+// parseBaseImage returns the base image of the final stage in the
+// Dockerfile.
+func parseBaseImage(dockerfilePath string) (string, error) {
+	f, err := os.Open(dockerfilePath)
+	if err != nil {
+		return "", errors.WithContext("open dockerfile", err)
+	}
+	defer f.Close()
+
+	dockerfileTree, err := dockerfileParser.Parse(f)
+	if err != nil {
+		return "", errors.WithContext("load dockerfile", err)
+	}
+
+	stages, _, err := instructions.Parse(dockerfileTree.AST)
+	if err != nil {
+		return "", errors.WithContext("parse dockerfile", err)
+	}
+
+	if len(stages) == 0 {
+		return "", errors.New("no base image")
+	}
+
+	return stages[len(stages)-1].BaseName, nil
+}
+
 func stripTagFromImageURL(imageURL string) string {
 	if atIndex := strings.Index(imageURL, "@"); atIndex != -1 {
 		imageURL = imageURL[:atIndex]
@@ -0,0 +1,155 @@
+package docker
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+
+	"github.com/kelda/blimp/pkg/build"
+)
+
+// streamMessages reads the messages in a Docker response stream, and passes
+// them to `handle`. If the progress is interactive, the messages are also
+// displayed with Docker's progress UI.
+func (c *client) streamMessages(stream io.Reader, handle func(jsonmessage.JSONMessage)) error {
+	if c.progress.Interactive() {
+		pr, pw := io.Pipe()
+		handled := make(chan struct{})
+		go func() {
+			decodeMessages(pr, handle)
+			// Drain the pipe in case the stream couldn't be decoded so that
+			// the display isn't blocked.
+			io.Copy(ioutil.Discard, pr)
+			close(handled)
+		}()
+
+		err := jsonmessage.DisplayJSONMessagesStream(io.TeeReader(stream, pw),
+			os.Stdout, os.Stdout.Fd(), true, nil)
+		pw.Close()
+		<-handled
+		return err
+	}
+
+	decoder := json.NewDecoder(stream)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		handle(msg)
+		if msg.Error != nil {
+			return msg.Error
+		}
+	}
+}
+
+func decodeMessages(stream io.Reader, handle func(jsonmessage.JSONMessage)) {
+	decoder := json.NewDecoder(stream)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err != nil {
+			return
+		}
+		handle(msg)
+	}
+}
+
+// stepPattern matches the line that the Docker builder prints when it starts
+// executing a Dockerfile instruction.
+var stepPattern = regexp.MustCompile(`^Step (\d+)/\d+ : (.*)$`)
+
+// buildEvents translates the output of the Docker builder into build events.
+// Each Dockerfile instruction is treated as a vertex.
+type buildEvents struct {
+	progress *build.Progress
+	service  string
+
+	// The step that's currently executing.
+	vertex string
+	name   string
+	cached bool
+}
+
+func (b *buildEvents) handle(msg jsonmessage.JSONMessage) {
+	if msg.Error != nil {
+		b.finishStep(msg.Error.Message)
+		return
+	}
+
+	if msg.Stream == "" {
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(msg.Stream, "\n"), "\n") {
+		if match := stepPattern.FindStringSubmatch(line); match != nil {
+			b.finishStep("")
+			b.vertex = "step-" + match[1]
+			b.name = match[2]
+			b.emit(build.Event{Type: build.EventVertexStarted, Name: b.name})
+			continue
+		}
+
+		if strings.TrimSpace(line) == "---> Using cache" {
+			b.cached = true
+			continue
+		}
+
+		b.emit(build.Event{Type: build.EventLog, Message: line})
+	}
+}
+
+// finishStep emits the completion event for the current step, if any.
+func (b *buildEvents) finishStep(errMsg string) {
+	if b.vertex == "" {
+		return
+	}
+
+	eventType := build.EventVertexCompleted
+	if b.cached && errMsg == "" {
+		eventType = build.EventCacheHit
+	}
+	b.emit(build.Event{Type: eventType, Name: b.name, Error: errMsg})
+	b.vertex, b.name, b.cached = "", "", false
+}
+
+func (b *buildEvents) emit(event build.Event) {
+	event.Service = b.service
+	if event.Vertex == "" {
+		event.Vertex = b.vertex
+	}
+	b.progress.Emit(event)
+}
+
+// emitPushEvent translates a message from an image push into a build event.
+func emitPushEvent(progress *build.Progress, service string, msg jsonmessage.JSONMessage) {
+	if msg.ID == "" {
+		return
+	}
+
+	event := build.Event{
+		Service: service,
+		Type:    build.EventPushProgress,
+		Name:    msg.ID,
+	}
+	switch msg.Status {
+	case "Pushing":
+		if msg.Progress != nil {
+			event.Current = msg.Progress.Current
+			event.Total = msg.Progress.Total
+		}
+	case "Pushed", "Layer already exists":
+		event.Done = true
+	default:
+		return
+	}
+	progress.Emit(event)
+}
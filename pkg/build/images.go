@@ -7,13 +7,55 @@ import (
 	"github.com/kelda/blimp/pkg/hash"
 )
 
+// BlimpServiceTag returns the tag that previous versions of Blimp gave to
+// the images that they built for the given service.
 func BlimpServiceTag(absComposePath, svc string) string {
 	return hash.DNSCompliant(fmt.Sprintf("%s-%s", absComposePath, svc))
 }
 
-func RemoteImageName(absComposePath, svc, namespace string) string {
-	tag := BlimpServiceTag(absComposePath, svc)
-	return fmt.Sprintf("%s/%s:%s", namespace, svc, tag)
+// RemoteImageName returns the name of the image for the given service. The
+// image is tagged with the digest of its build inputs, so that the image can
+// be reused until the inputs change.
+func RemoteImageName(svc, namespace, digest string) string {
+	return fmt.Sprintf("%s/%s:%s", namespace, svc, digest)
+}
+
+// CacheTag is the tag that a builder stores build caches under. BuildKit's
+// registry cache and the images that Docker uses as caches have incompatible
+// formats, so each builder uses its own tag within the cache repositories.
+type CacheTag string
+
+const (
+	// CacheTagBuildkit is the tag of BuildKit registry caches.
+	CacheTagBuildkit CacheTag = "cache"
+
+	// CacheTagDocker is the tag of the images that the Docker builder uses
+	// as caches.
+	CacheTagDocker CacheTag = "docker-cache"
+)
+
+// CacheImage returns the image in the cache repository that the builder
+// with the given tag stores its cache in.
+func CacheImage(repo string, tag CacheTag) string {
+	return fmt.Sprintf("%s:%s", repo, tag)
+}
+
+// CacheRepos returns the repositories that the service's build cache should
+// be imported from, and the repository that it should be exported to. The
+// cache is stored in the sandbox's image namespace so that it's shared
+// between local and remote builds, and is also imported from the team cache
+// if one is configured.
+func CacheRepos(svc, imageNamespace, teamCache string, exportTeamCache bool) (imports []string, export string) {
+	export = fmt.Sprintf("%s/%s", imageNamespace, svc)
+	imports = []string{export}
+	if teamCache != "" {
+		teamRepo := fmt.Sprintf("%s/%s", teamCache, svc)
+		imports = append(imports, teamRepo)
+		if exportTeamCache {
+			export = teamRepo
+		}
+	}
+	return imports, export
 }
 
 func ReplaceTagWithDigest(imageName, digest string) string {
@@ -0,0 +1,58 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheRepos(t *testing.T) {
+	tests := []struct {
+		name            string
+		teamCache       string
+		exportTeamCache bool
+		expImports      []string
+		expExport       string
+	}{
+		{
+			name:       "sandbox cache",
+			expImports: []string{"blimp-registry.io/ns/web"},
+			expExport:  "blimp-registry.io/ns/web",
+		},
+		{
+			name:      "team cache",
+			teamCache: "registry.example.com/team",
+			expImports: []string{
+				"blimp-registry.io/ns/web",
+				"registry.example.com/team/web",
+			},
+			expExport: "blimp-registry.io/ns/web",
+		},
+		{
+			name:            "export team cache",
+			teamCache:       "registry.example.com/team",
+			exportTeamCache: true,
+			expImports: []string{
+				"blimp-registry.io/ns/web",
+				"registry.example.com/team/web",
+			},
+			expExport: "registry.example.com/team/web",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			imports, export := CacheRepos("web", "blimp-registry.io/ns", test.teamCache, test.exportTeamCache)
+			assert.Equal(t, test.expImports, imports)
+			assert.Equal(t, test.expExport, export)
+		})
+	}
+}
+
+func TestCacheImage(t *testing.T) {
+	assert.Equal(t, "blimp-registry.io/ns/web:cache",
+		CacheImage("blimp-registry.io/ns/web", CacheTagBuildkit))
+	assert.Equal(t, "blimp-registry.io/ns/web:docker-cache",
+		CacheImage("blimp-registry.io/ns/web", CacheTagDocker))
+}
@@ -0,0 +1,76 @@
+// Package local selects the image builder to use on the user's machine.
+package local
+
+import (
+	"github.com/docker/cli/cli/config/configfile"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kelda/blimp/pkg/auth"
+	"github.com/kelda/blimp/pkg/build"
+	"github.com/kelda/blimp/pkg/build/buildkit"
+	"github.com/kelda/blimp/pkg/build/docker"
+	"github.com/kelda/blimp/pkg/errors"
+	protoAuth "github.com/kelda/blimp/pkg/proto/auth"
+)
+
+// New returns the local image builder for the given `builder` setting. If
+// the setting is BuilderAuto, the Docker daemon, Podman, and nerdctl's
+// buildkitd are tried in order, and an error is returned if none of them are
+// available. Builds that require BuildKit, such as those that forward SSH
+// agents, can't be run by Docker or Podman. `cacheOpts` controls which
+// existing images Docker and Podman use as build caches.
+func New(builder build.Builder, requireBuildkit bool, regCreds auth.RegistryCredentials,
+	dockerConfig *configfile.ConfigFile, blimpAuth *protoAuth.BlimpAuth,
+	progress *build.Progress, cacheOpts docker.CacheOptions) (build.Interface, error) {
+	newDocker := func() (build.Interface, error) {
+		return docker.New(regCreds, dockerConfig, blimpAuth, progress, cacheOpts)
+	}
+	newPodman := func() (build.Interface, error) {
+		return docker.NewPodman(regCreds, dockerConfig, blimpAuth, progress, cacheOpts)
+	}
+	newNerdctl := func() (build.Interface, error) {
+		return buildkit.NewLocal(regCreds, progress)
+	}
+
+	switch builder {
+	case build.BuilderDocker, build.BuilderPodman:
+		if requireBuildkit {
+			return nil, errors.NewFriendlyError(
+				"Some services use the `ssh` or `secrets` build options, which aren't supported "+
+					"by the %s builder. Set `builder` in ~/.blimp/blimp.yaml to nerdctl or remote "+
+					"to build them.", builder)
+		}
+
+		if builder == build.BuilderDocker {
+			return newDocker()
+		}
+		return newPodman()
+	case build.BuilderNerdctl:
+		return newNerdctl()
+	case build.BuilderAuto:
+	default:
+		return nil, errors.New("unknown local builder %q", builder)
+	}
+
+	candidates := []struct {
+		builder build.Builder
+		create  func() (build.Interface, error)
+	}{
+		{build.BuilderDocker, newDocker},
+		{build.BuilderPodman, newPodman},
+		{build.BuilderNerdctl, newNerdctl},
+	}
+	for _, candidate := range candidates {
+		if requireBuildkit && candidate.builder != build.BuilderNerdctl {
+			continue
+		}
+
+		builder, err := candidate.create()
+		if err == nil {
+			log.WithField("builder", candidate.builder).Debug("Using local image builder")
+			return builder, nil
+		}
+		log.WithError(err).WithField("builder", candidate.builder).Debug("Local image builder unavailable")
+	}
+	return nil, errors.New("no local image builder is available")
+}
@@ -0,0 +1,108 @@
+package local
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/blimp/pkg/auth"
+	"github.com/kelda/blimp/pkg/build"
+	"github.com/kelda/blimp/pkg/build/docker"
+	"github.com/kelda/blimp/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	// Fake the Podman API service. The builders only ping the daemon when
+	// they're created.
+	podman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.40")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer podman.Close()
+	podmanHost := "tcp://" + strings.TrimPrefix(podman.URL, "http://")
+
+	tests := []struct {
+		name            string
+		builder         build.Builder
+		requireBuildkit bool
+		podmanRunning   bool
+		expErr          error
+	}{
+		{
+			name:          "fall back to podman",
+			builder:       build.BuilderAuto,
+			podmanRunning: true,
+		},
+		{
+			name:    "nothing running",
+			builder: build.BuilderAuto,
+			expErr:  errors.New("no local image builder is available"),
+		},
+		{
+			name:            "podman doesn't support buildkit features",
+			builder:         build.BuilderAuto,
+			requireBuildkit: true,
+			podmanRunning:   true,
+			expErr:          errors.New("no local image builder is available"),
+		},
+		{
+			name:            "explicit builder doesn't support buildkit features",
+			builder:         build.BuilderPodman,
+			requireBuildkit: true,
+			podmanRunning:   true,
+			expErr: errors.NewFriendlyError(
+				"Some services use the `ssh` or `secrets` build options, which aren't supported " +
+					"by the podman builder. Set `builder` in ~/.blimp/blimp.yaml to nerdctl or remote " +
+					"to build them."),
+		},
+		{
+			name:    "unknown builder",
+			builder: build.BuilderRemote,
+			expErr:  errors.New(`unknown local builder "remote"`),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			defer setenv("DOCKER_HOST", "unix:///does/not/exist/docker.sock")()
+			defer setenv("BUILDKIT_HOST", "unix:///does/not/exist/buildkitd.sock")()
+			if test.podmanRunning {
+				defer setenv("CONTAINER_HOST", podmanHost)()
+			} else {
+				defer setenv("CONTAINER_HOST", "unix:///does/not/exist/podman.sock")()
+			}
+
+			builder, err := New(test.builder, test.requireBuildkit, auth.RegistryCredentials{},
+				&configfile.ConfigFile{}, nil, build.NewProgress(build.ProgressPlain, os.Stdout, ""),
+				docker.CacheOptions{})
+			if test.expErr != nil {
+				assert.Equal(t, test.expErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, builder)
+		})
+	}
+}
+
+// setenv sets the environment variable, and returns a function that restores
+// its original value.
+func setenv(key, value string) (restore func()) {
+	orig, ok := os.LookupEnv(key)
+	restore = func() {
+		if ok {
+			os.Setenv(key, orig)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+
+	os.Setenv(key, value)
+	return restore
+}
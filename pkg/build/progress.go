@@ -0,0 +1,291 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/kelda/blimp/pkg/errors"
+)
+
+// ProgressMode controls how build progress is displayed.
+type ProgressMode string
+
+const (
+	// ProgressAuto uses the builders' interactive displays when stdout is a
+	// terminal, and falls back to ProgressPlain otherwise.
+	ProgressAuto ProgressMode = "auto"
+
+	// ProgressPlain prints each event as a line of text.
+	ProgressPlain ProgressMode = "plain"
+
+	// ProgressJSON prints each event as a JSON object on its own line.
+	ProgressJSON ProgressMode = "json"
+)
+
+// ParseProgressMode parses the value of the `--progress` flag.
+func ParseProgressMode(mode string) (ProgressMode, error) {
+	switch parsed := ProgressMode(mode); parsed {
+	case ProgressAuto, ProgressPlain, ProgressJSON:
+		return parsed, nil
+	default:
+		return "", errors.NewFriendlyError(
+			"Invalid progress mode %q. It must be one of auto, plain, or json.", mode)
+	}
+}
+
+// EventType is the type of a build progress event.
+type EventType string
+
+const (
+	// EventVertexStarted is emitted when a build step starts.
+	EventVertexStarted EventType = "vertex-started"
+
+	// EventVertexCompleted is emitted when a build step finishes, including
+	// when it fails.
+	EventVertexCompleted EventType = "vertex-completed"
+
+	// EventCacheHit is emitted instead of EventVertexCompleted when a build
+	// step is satisfied by the build cache.
+	EventCacheHit EventType = "cache-hit"
+
+	// EventLog is emitted for each line of output.
+	EventLog EventType = "log"
+
+	// EventPushProgress is emitted as layers are pushed to the registry.
+	EventPushProgress EventType = "push-progress"
+)
+
+// Event is a single build progress update. Both builders translate their
+// progress into Events so that the output is the same regardless of which
+// builder is used.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Service string    `json:"service"`
+	Type    EventType `json:"type"`
+
+	// Vertex identifies the build step that the event refers to.
+	Vertex string `json:"vertex,omitempty"`
+
+	// Name is the human readable name of the build step, or the layer being
+	// pushed.
+	Name string `json:"name,omitempty"`
+
+	// Message is the line of output for log events.
+	Message string `json:"message,omitempty"`
+
+	// Current and Total are the number of bytes pushed so far, and the total
+	// number of bytes to push.
+	Current int64 `json:"current,omitempty"`
+	Total   int64 `json:"total,omitempty"`
+
+	// Done is set when a push has completed.
+	Done bool `json:"done,omitempty"`
+
+	// Error is set when a build step failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Progress displays the progress events from builders, and saves the build
+// log for each service. It's safe to use from multiple goroutines.
+type Progress struct {
+	mode   ProgressMode
+	out    io.Writer
+	logDir string
+
+	lock sync.Mutex
+	logs map[string]*os.File
+}
+
+// NewProgress creates a Progress that writes events to `out` according to
+// the given mode, and saves build logs in `logDir`.
+func NewProgress(mode ProgressMode, out io.Writer, logDir string) *Progress {
+	if mode == ProgressAuto {
+		if f, ok := out.(*os.File); !ok || !terminal.IsTerminal(int(f.Fd())) {
+			mode = ProgressPlain
+		}
+	}
+
+	return &Progress{
+		mode:   mode,
+		out:    out,
+		logDir: logDir,
+		logs:   map[string]*os.File{},
+	}
+}
+
+// Interactive returns whether builders should use their own interactive
+// progress displays, rather than relying on Progress to print events.
+func (p *Progress) Interactive() bool {
+	return p.mode == ProgressAuto
+}
+
+// StatusOutput returns where output other than build progress, such as
+// spinners, should be written. In ProgressJSON mode, it's stderr so that
+// stdout only contains events.
+func StatusOutput(mode ProgressMode) io.Writer {
+	if mode == ProgressJSON {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// StatusOutput returns where output that isn't sent through the Progress
+// should be written.
+func (p *Progress) StatusOutput() io.Writer {
+	if p.mode == ProgressJSON {
+		return os.Stderr
+	}
+	return p.out
+}
+
+// LogPath returns the path to the build log for the given service.
+func (p *Progress) LogPath(service string) string {
+	return filepath.Join(p.logDir, service+".log")
+}
+
+// Printf prints a status message about the given service, such as whether
+// it's being rebuilt. Unlike events, messages are printed in all modes, and
+// aren't saved to the build log so that the log of the last build isn't
+// overwritten when a service is reused. In ProgressJSON mode, they're
+// printed as log events.
+func (p *Progress) Printf(service, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if p.mode == ProgressJSON {
+		p.emit(Event{Service: service, Type: EventLog, Message: msg}, false)
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	fmt.Fprintln(p.out, msg)
+}
+
+// PrintResults prints whether each service built successfully. Build errors
+// are only printed if `verbose` is set, since they're usually returned to the
+// caller as well.
+func (p *Progress) PrintResults(results Results, verbose bool) {
+	var services []string
+	for service := range results {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	for _, service := range services {
+		switch err := results[service].Err; {
+		case err == nil:
+			p.Printf(service, "Built %s.", service)
+		case err == ErrSkipped:
+			p.Printf(service, "Skipped building %s since another service failed to build.", service)
+		case verbose:
+			p.Printf(service, "Failed to build %s: %s", service, err)
+		default:
+			p.Printf(service, "Failed to build %s.", service)
+		}
+	}
+}
+
+// Emit records the given event.
+func (p *Progress) Emit(event Event) {
+	p.emit(event, true)
+}
+
+func (p *Progress) emit(event Event, saveLog bool) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	line, ok := formatEvent(event)
+	if ok && saveLog {
+		p.writeLog(event.Service, line)
+	}
+
+	switch p.mode {
+	case ProgressPlain:
+		if ok {
+			fmt.Fprintf(p.out, "[%s] %s\n", event.Service, line)
+		}
+	case ProgressJSON:
+		b, err := json.Marshal(event)
+		if err != nil {
+			log.WithError(err).Warn("Failed to marshal build event")
+			return
+		}
+		fmt.Fprintln(p.out, string(b))
+	}
+}
+
+// Close closes the build logs.
+func (p *Progress) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for service, f := range p.logs {
+		f.Close()
+		delete(p.logs, service)
+	}
+}
+
+// writeLog appends the line to the service's build log. The log is
+// truncated the first time it's written to, so it only contains the most
+// recent build. The caller must hold the lock.
+func (p *Progress) writeLog(service, line string) {
+	if p.logDir == "" || service == "" {
+		return
+	}
+
+	f, ok := p.logs[service]
+	if !ok {
+		if err := os.MkdirAll(p.logDir, 0755); err != nil {
+			log.WithError(err).Debug("Failed to create build log directory")
+			return
+		}
+
+		var err error
+		f, err = os.Create(p.LogPath(service))
+		if err != nil {
+			log.WithError(err).WithField("service", service).Debug("Failed to create build log")
+			return
+		}
+		p.logs[service] = f
+	}
+	fmt.Fprintln(f, line)
+}
+
+// formatEvent returns the text representation of the event. Push progress
+// events are only shown once the push completes, to avoid flooding the
+// output.
+func formatEvent(event Event) (string, bool) {
+	switch event.Type {
+	case EventVertexStarted:
+		return event.Name, true
+	case EventVertexCompleted:
+		if event.Error != "" {
+			return fmt.Sprintf("ERROR %s: %s", event.Name, event.Error), true
+		}
+		return fmt.Sprintf("DONE %s", event.Name), true
+	case EventCacheHit:
+		return fmt.Sprintf("CACHED %s", event.Name), true
+	case EventLog:
+		return strings.TrimRight(event.Message, "\n"), true
+	case EventPushProgress:
+		if !event.Done {
+			return "", false
+		}
+		return fmt.Sprintf("PUSHED %s", event.Name), true
+	default:
+		return "", false
+	}
+}
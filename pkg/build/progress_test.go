@@ -0,0 +1,174 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	events := []Event{
+		{Service: "web", Type: EventVertexStarted, Vertex: "1", Name: "RUN make"},
+		{Service: "web", Type: EventLog, Vertex: "1", Message: "compiling\n"},
+		{Service: "web", Type: EventVertexCompleted, Vertex: "1", Name: "RUN make"},
+		{Service: "web", Type: EventCacheHit, Vertex: "2", Name: "COPY . ."},
+		{Service: "web", Type: EventPushProgress, Name: "sha256:abc", Current: 10, Total: 20},
+		{Service: "web", Type: EventPushProgress, Name: "sha256:abc", Current: 20, Total: 20, Done: true},
+		{Service: "db", Type: EventVertexCompleted, Vertex: "3", Name: "RUN false", Error: "exit code 1"},
+	}
+
+	tests := []struct {
+		name   string
+		mode   ProgressMode
+		expOut string
+	}{
+		{
+			name: "plain",
+			mode: ProgressPlain,
+			expOut: "Building web\n" +
+				"[web] RUN make\n" +
+				"[web] compiling\n" +
+				"[web] DONE RUN make\n" +
+				"[web] CACHED COPY . .\n" +
+				"[web] PUSHED sha256:abc\n" +
+				"[db] ERROR RUN false: exit code 1\n",
+		},
+		{
+			name: "auto without a terminal",
+			mode: ProgressAuto,
+			expOut: "Building web\n" +
+				"[web] RUN make\n" +
+				"[web] compiling\n" +
+				"[web] DONE RUN make\n" +
+				"[web] CACHED COPY . .\n" +
+				"[web] PUSHED sha256:abc\n" +
+				"[db] ERROR RUN false: exit code 1\n",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			logDir, err := ioutil.TempDir("", "blimp-build-logs")
+			require.NoError(t, err)
+			defer os.RemoveAll(logDir)
+
+			var out bytes.Buffer
+			progress := NewProgress(test.mode, &out, logDir)
+			assert.False(t, progress.Interactive())
+
+			progress.Printf("web", "Building %s", "web")
+			for _, event := range events {
+				progress.Emit(event)
+			}
+			progress.Close()
+			assert.Equal(t, test.expOut, out.String())
+
+			webLog, err := ioutil.ReadFile(progress.LogPath("web"))
+			require.NoError(t, err)
+			assert.Equal(t, "RUN make\ncompiling\nDONE RUN make\nCACHED COPY . .\nPUSHED sha256:abc\n",
+				string(webLog))
+
+			dbLog, err := ioutil.ReadFile(progress.LogPath("db"))
+			require.NoError(t, err)
+			assert.Equal(t, "ERROR RUN false: exit code 1\n", string(dbLog))
+		})
+	}
+}
+
+func TestProgressJSON(t *testing.T) {
+	var out bytes.Buffer
+	progress := NewProgress(ProgressJSON, &out, "")
+
+	progress.Printf("web", "Building %s", "web")
+	progress.Emit(Event{Service: "web", Type: EventPushProgress, Name: "sha256:abc", Current: 10, Total: 20})
+	progress.Close()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var events []Event
+	for _, line := range lines {
+		var event Event
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		assert.False(t, event.Time.IsZero())
+		event.Time = time.Time{}
+		events = append(events, event)
+	}
+
+	assert.Equal(t, []Event{
+		{Service: "web", Type: EventLog, Message: "Building web"},
+		{Service: "web", Type: EventPushProgress, Name: "sha256:abc", Current: 10, Total: 20},
+	}, events)
+}
+
+func TestProgressJSONStdout(t *testing.T) {
+	getStdout, restoreStdout := capture(t, &os.Stdout)
+	defer restoreStdout()
+	getStderr, restoreStderr := capture(t, &os.Stderr)
+	defer restoreStderr()
+
+	progress := NewProgress(ProgressJSON, os.Stdout, "")
+	progress.Printf("web", "Building %s", "web")
+	progress.Emit(Event{Service: "web", Type: EventVertexStarted, Vertex: "1", Name: "RUN make"})
+	progress.PrintResults(Results{"web": {}}, false)
+	progress.Printf("", "Wrote blimp.lock")
+
+	// Spinners and other status output shouldn't be mixed with the events.
+	fmt.Fprint(progress.StatusOutput(), "Booting remote Docker image builder")
+	fmt.Fprint(StatusOutput(ProgressJSON), "...")
+	assert.Equal(t, os.Stdout, StatusOutput(ProgressPlain))
+	progress.Close()
+
+	lines := strings.Split(strings.TrimSpace(getStdout()), "\n")
+	assert.Len(t, lines, 4)
+	for _, line := range lines {
+		var event Event
+		assert.NoError(t, json.Unmarshal([]byte(line), &event), line)
+	}
+	assert.Equal(t, "Booting remote Docker image builder...", getStderr())
+}
+
+// capture replaces the given file with a pipe. It returns a function that
+// closes the pipe, and returns everything written to it, and a function that
+// restores the original file.
+func capture(t *testing.T, file **os.File) (func() string, func()) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	orig := *file
+	*file = w
+	restore := func() {
+		*file = orig
+		w.Close()
+	}
+
+	contents := make(chan string)
+	go func() {
+		b, _ := ioutil.ReadAll(r)
+		contents <- string(b)
+	}()
+
+	read := func() string {
+		w.Close()
+		return <-contents
+	}
+	return read, restore
+}
+
+func TestParseProgressMode(t *testing.T) {
+	mode, err := ParseProgressMode("json")
+	assert.NoError(t, err)
+	assert.Equal(t, ProgressJSON, mode)
+
+	_, err = ParseProgressMode("tty")
+	assert.EqualError(t, err, `Invalid progress mode "tty". It must be one of auto, plain, or json.`)
+}
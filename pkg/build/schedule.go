@@ -0,0 +1,134 @@
+package build
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/kelda/blimp/pkg/errors"
+)
+
+// ScheduleOptions control how the builds for multiple services are run.
+type ScheduleOptions struct {
+	// Parallelism is the maximum number of services that are built at once.
+	// Values less than one are treated as one.
+	Parallelism int
+
+	// KeepGoing causes the remaining services to be built after a build
+	// fails. Otherwise, no more builds are started after the first failure.
+	KeepGoing bool
+}
+
+// Result is the outcome of building a single service.
+type Result struct {
+	// Image is the name of the pushed image. It's only set if the build
+	// succeeded.
+	Image string
+
+	// Err is the reason that the build failed.
+	Err error
+}
+
+// Results maps service names to the outcome of their builds.
+type Results map[string]Result
+
+// ErrSkipped is the error for services that weren't built because another
+// service failed to build.
+var ErrSkipped = errors.New("skipped because another service failed to build")
+
+// Images returns the pushed images of the services that built successfully.
+func (results Results) Images() map[string]string {
+	images := map[string]string{}
+	for service, result := range results {
+		if result.Err == nil {
+			images[service] = result.Image
+		}
+	}
+	return images
+}
+
+// Failed returns the errors for the services that failed to build, including
+// the services that were skipped.
+func (results Results) Failed() map[string]error {
+	failed := map[string]error{}
+	for service, result := range results {
+		if result.Err != nil {
+			failed[service] = result.Err
+		}
+	}
+	return failed
+}
+
+// Err returns the error of the first service, in alphabetical order, that
+// failed to build. Services that were skipped are ignored, since they didn't
+// fail on their own.
+func (results Results) Err() error {
+	var services []string
+	for service := range results {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	for _, service := range services {
+		if err := results[service].Err; err != nil && err != ErrSkipped {
+			return err
+		}
+	}
+	return nil
+}
+
+// Schedule runs `buildOne` for each service, with up to `opts.Parallelism`
+// builds running at once. `buildOne` builds and pushes the service, and
+// returns the name of the pushed image. Builds are started in alphabetical
+// order so that the output is deterministic when they're run one at a time.
+func Schedule(images map[string]BuildPushConfig, opts ScheduleOptions,
+	buildOne func(service string, opts BuildPushConfig) (string, error)) Results {
+	parallelism := opts.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var lock sync.Mutex
+	results := Results{}
+	failed := false
+
+	var wg sync.WaitGroup
+	services := make(chan string)
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for service := range services {
+				lock.Lock()
+				skip := failed && !opts.KeepGoing
+				lock.Unlock()
+
+				var result Result
+				if skip {
+					result.Err = ErrSkipped
+				} else {
+					result.Image, result.Err = buildOne(service, images[service])
+				}
+
+				lock.Lock()
+				results[service] = result
+				if result.Err != nil {
+					failed = true
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+	var sorted []string
+	for service := range images {
+		sorted = append(sorted, service)
+	}
+	sort.Strings(sorted)
+
+	for _, service := range sorted {
+		services <- service
+	}
+	close(services)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,113 @@
+package build
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kelda/blimp/pkg/errors"
+)
+
+func TestSchedule(t *testing.T) {
+	buildErr := errors.New("build failed")
+	images := map[string]BuildPushConfig{
+		"a": {ImageName: "image-a"},
+		"b": {ImageName: "image-b"},
+		"c": {ImageName: "image-c"},
+	}
+
+	tests := []struct {
+		name       string
+		opts       ScheduleOptions
+		failing    string
+		expResults Results
+		expErr     error
+	}{
+		{
+			name: "success",
+			opts: ScheduleOptions{Parallelism: 2},
+			expResults: Results{
+				"a": {Image: "image-a"},
+				"b": {Image: "image-b"},
+				"c": {Image: "image-c"},
+			},
+		},
+		{
+			name:    "failure stops later builds",
+			opts:    ScheduleOptions{Parallelism: 1},
+			failing: "a",
+			expResults: Results{
+				"a": {Err: buildErr},
+				"b": {Err: ErrSkipped},
+				"c": {Err: ErrSkipped},
+			},
+			expErr: buildErr,
+		},
+		{
+			name:    "keep going",
+			opts:    ScheduleOptions{Parallelism: 1, KeepGoing: true},
+			failing: "a",
+			expResults: Results{
+				"a": {Err: buildErr},
+				"b": {Image: "image-b"},
+				"c": {Image: "image-c"},
+			},
+			expErr: buildErr,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			results := Schedule(images, test.opts, func(service string, opts BuildPushConfig) (string, error) {
+				if service == test.failing {
+					return "", buildErr
+				}
+				return opts.ImageName, nil
+			})
+			assert.Equal(t, test.expResults, results)
+			assert.Equal(t, test.expErr, results.Err())
+		})
+	}
+}
+
+func TestScheduleParallelism(t *testing.T) {
+	images := map[string]BuildPushConfig{}
+	for _, service := range []string{"a", "b", "c", "d", "e"} {
+		images[service] = BuildPushConfig{}
+	}
+
+	var lock sync.Mutex
+	var running, maxRunning int
+	Schedule(images, ScheduleOptions{Parallelism: 2}, func(string, BuildPushConfig) (string, error) {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return "", nil
+	})
+	assert.Equal(t, 2, maxRunning)
+}
+
+func TestResults(t *testing.T) {
+	buildErr := errors.New("build failed")
+	results := Results{
+		"a": {Image: "image-a"},
+		"b": {Err: ErrSkipped},
+		"c": {Err: buildErr},
+	}
+
+	assert.Equal(t, map[string]string{"a": "image-a"}, results.Images())
+	assert.Equal(t, map[string]error{"b": ErrSkipped, "c": buildErr}, results.Failed())
+	assert.Equal(t, buildErr, results.Err())
+}
@@ -2,18 +2,88 @@ package build
 
 import (
 	composeTypes "github.com/kelda/compose-go/types"
+
+	"github.com/kelda/blimp/pkg/errors"
 )
 
 type Interface interface {
 	// BuildAndPush takes a map of service names to their build/push configs,
-	// and returns a mapping from service names to the pushed image names.
-	BuildAndPush(serviceConfigs map[string]BuildPushConfig) (map[string]string, error)
+	// and returns the result of building each service. The error is only
+	// set if the builds couldn't be run at all.
+	BuildAndPush(serviceConfigs map[string]BuildPushConfig, opts ScheduleOptions) (Results, error)
 }
 
 type BuildPushConfig struct {
 	composeTypes.BuildConfig
-	ImageName   string
-	ForceBuild  bool
-	PullParent  bool
-	NoCache     bool
+	ImageName  string
+	ForceBuild bool
+	PullParent bool
+	NoCache    bool
+
+	// SSH maps the IDs of the SSH agents that are forwarded to the build to
+	// the sockets or private keys that back them. An empty path refers to
+	// the agent at SSH_AUTH_SOCK.
+	SSH map[string]string
+
+	// Secrets maps the IDs of the secrets that are exposed to the build to
+	// their contents.
+	Secrets map[string][]byte
+
+	// CacheImports are the repositories that the build cache is imported
+	// from, in addition to the images in CacheFrom. Each builder reads the
+	// cache stored under its CacheTag, and caches that don't exist are
+	// ignored.
+	CacheImports []string
+
+	// CacheExport is the repository that the build cache is exported to, if
+	// any.
+	CacheExport string
+}
+
+// RequiresBuildkit returns whether any of the builds use options that are
+// only supported by BuildKit.
+func RequiresBuildkit(serviceConfigs map[string]BuildPushConfig) bool {
+	for _, opts := range serviceConfigs {
+		if len(opts.SSH) != 0 || len(opts.Secrets) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Builder names the image builder that services are built with.
+type Builder string
+
+const (
+	// BuilderAuto picks the first local builder that's available, and falls
+	// back to building in the sandbox if none are.
+	BuilderAuto Builder = "auto"
+
+	// BuilderDocker builds with the local Docker daemon.
+	BuilderDocker Builder = "docker"
+
+	// BuilderPodman builds with Podman, via its Docker-compatible API socket.
+	BuilderPodman Builder = "podman"
+
+	// BuilderNerdctl builds with the local buildkitd that nerdctl uses to
+	// build images for containerd.
+	BuilderNerdctl Builder = "nerdctl"
+
+	// BuilderRemote builds with the buildkitd in the sandbox.
+	BuilderRemote Builder = "remote"
+)
+
+// ParseBuilder parses the `builder` setting in the Blimp config. An empty
+// setting is equivalent to BuilderAuto.
+func ParseBuilder(builder string) (Builder, error) {
+	switch parsed := Builder(builder); parsed {
+	case "":
+		return BuilderAuto, nil
+	case BuilderAuto, BuilderDocker, BuilderPodman, BuilderNerdctl, BuilderRemote:
+		return parsed, nil
+	default:
+		return "", errors.NewFriendlyError(
+			"Invalid builder %q in ~/.blimp/blimp.yaml. "+
+				"It must be one of auto, docker, podman, nerdctl, or remote.", builder)
+	}
 }
@@ -0,0 +1,51 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBuilder(t *testing.T) {
+	tests := []struct {
+		setting    string
+		expBuilder Builder
+		expErr     string
+	}{
+		{setting: "", expBuilder: BuilderAuto},
+		{setting: "auto", expBuilder: BuilderAuto},
+		{setting: "docker", expBuilder: BuilderDocker},
+		{setting: "podman", expBuilder: BuilderPodman},
+		{setting: "nerdctl", expBuilder: BuilderNerdctl},
+		{setting: "remote", expBuilder: BuilderRemote},
+		{
+			setting: "buildah",
+			expErr: `Invalid builder "buildah" in ~/.blimp/blimp.yaml. ` +
+				"It must be one of auto, docker, podman, nerdctl, or remote.",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.setting, func(t *testing.T) {
+			builder, err := ParseBuilder(test.setting)
+			if test.expErr != "" {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expBuilder, builder)
+		})
+	}
+}
+
+func TestRequiresBuildkit(t *testing.T) {
+	assert.False(t, RequiresBuildkit(map[string]BuildPushConfig{"web": {}}))
+	assert.True(t, RequiresBuildkit(map[string]BuildPushConfig{
+		"web": {},
+		"api": {SSH: map[string]string{"default": ""}},
+	}))
+	assert.True(t, RequiresBuildkit(map[string]BuildPushConfig{
+		"web": {Secrets: map[string][]byte{"token": []byte("secret")}},
+	}))
+}
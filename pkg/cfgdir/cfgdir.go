@@ -20,6 +20,19 @@ type Config struct {
 	KubeHost    string `json:"kube_host"`
 	ManagerHost string `json:"manager_host"`
 	ManagerCert string `json:"manager_cert"`
+
+	// TeamBuildCache is an image repository that build caches are shared
+	// through, such as one that's populated by CI. Builds import the cache
+	// from `<team_build_cache>/<service>:cache`.
+	TeamBuildCache string `json:"team_build_cache"`
+
+	// ExportTeamBuildCache causes builds to export their cache to the team
+	// build cache, rather than the sandbox's cache.
+	ExportTeamBuildCache bool `json:"export_team_build_cache"`
+
+	// Builder is the image builder to use: auto, docker, podman, nerdctl, or
+	// remote. By default, the first local builder that's available is used.
+	Builder string `json:"builder"`
 }
 
 var ConfigDir string
@@ -48,6 +61,12 @@ func CLILogFile() string {
 	return Expand("blimp-cli.log")
 }
 
+// BuildLogDir returns the directory that the build log for each service is
+// saved in.
+func BuildLogDir() string {
+	return Expand("build-logs")
+}
+
 func ParseConfig() (Config, error) {
 	cfgPath := Expand("blimp.yaml")
 	cfgContents, err := ioutil.ReadFile(cfgPath)
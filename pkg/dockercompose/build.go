@@ -0,0 +1,212 @@
+package dockercompose
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/kelda/compose-go/types"
+	homedir "github.com/mitchellh/go-homedir"
+
+	"github.com/kelda/blimp/pkg/errors"
+)
+
+const (
+	// buildSSHKey and buildSecretsKey are the keys in a service's build
+	// Extensions that hold the `ssh` and `secrets` build options. The
+	// compose-go loader doesn't support these fields, so we parse them from
+	// the raw config while loading the Compose file.
+	buildSSHKey     = "x-blimp-ssh"
+	buildSecretsKey = "x-blimp-secrets"
+)
+
+// rawBuildOptions are the build options for a service that aren't parsed by
+// the compose-go loader.
+type rawBuildOptions struct {
+	// ssh maps the IDs of SSH agents to the sockets or keys that back them.
+	ssh map[string]string
+
+	// secrets maps the IDs that secrets are exposed to the build with to
+	// the names of the top-level secrets.
+	secrets map[string]string
+}
+
+// resolveBuildOptions adds the `ssh` and `secrets` build options to the
+// services in the parsed Compose file.
+func resolveBuildOptions(cfg *types.Project, configFiles []types.ConfigFile, workingDir string) error {
+	for svcIdx, svc := range cfg.Services {
+		if svc.Build == nil {
+			continue
+		}
+
+		raw, ok := getRawBuildOptions(svc.Name, configFiles)
+		if !ok {
+			continue
+		}
+
+		ssh := map[string]string{}
+		for id, path := range raw.ssh {
+			if path == "" {
+				ssh[id] = ""
+				continue
+			}
+
+			expanded, err := homedir.Expand(path)
+			if err != nil {
+				return errors.WithContext("expand ssh path", err)
+			}
+			if !filepath.IsAbs(expanded) {
+				expanded = filepath.Join(workingDir, expanded)
+			}
+			ssh[id] = expanded
+		}
+
+		if svc.Build.Extensions == nil {
+			cfg.Services[svcIdx].Build.Extensions = map[string]interface{}{}
+		}
+		cfg.Services[svcIdx].Build.Extensions[buildSSHKey] = ssh
+		cfg.Services[svcIdx].Build.Extensions[buildSecretsKey] = raw.secrets
+	}
+	return nil
+}
+
+// getRawBuildOptions returns the `ssh` and `secrets` build options for the
+// given service. Later files override earlier files.
+func getRawBuildOptions(service string, configFiles []types.ConfigFile) (rawBuildOptions, bool) {
+	var opts rawBuildOptions
+	var found bool
+	for _, configFile := range configFiles {
+		services, ok := configFile.Config["services"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		svc, ok := services[service].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		build, ok := svc["build"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if ssh, ok := parseRawSSH(build["ssh"]); ok {
+			opts.ssh = ssh
+			found = true
+		}
+		if secrets, ok := parseRawBuildSecrets(build["secrets"]); ok {
+			opts.secrets = secrets
+			found = true
+		}
+	}
+	return opts, found
+}
+
+// parseRawSSH parses the `ssh` build option. It's either a list of `id` or
+// `id=path` entries, or a map from IDs to paths. An empty path refers to the
+// SSH agent at SSH_AUTH_SOCK.
+func parseRawSSH(sshIntf interface{}) (map[string]string, bool) {
+	ssh := map[string]string{}
+	switch sshIntf := sshIntf.(type) {
+	case []interface{}:
+		for _, entryIntf := range sshIntf {
+			entry, ok := entryIntf.(string)
+			if !ok {
+				continue
+			}
+
+			parts := strings.SplitN(entry, "=", 2)
+			if len(parts) == 2 {
+				ssh[parts[0]] = parts[1]
+			} else {
+				ssh[parts[0]] = ""
+			}
+		}
+	case map[string]interface{}:
+		for id, pathIntf := range sshIntf {
+			path, _ := pathIntf.(string)
+			ssh[id] = path
+		}
+	default:
+		return nil, false
+	}
+	return ssh, true
+}
+
+// parseRawBuildSecrets parses the `secrets` build option. Each secret is
+// either the name of a top-level secret, or a map with the `source` and
+// `target` fields. Secrets are exposed to the build with the target as their
+// ID, or the source if no target is specified.
+func parseRawBuildSecrets(secretsIntf interface{}) (map[string]string, bool) {
+	refs, ok := secretsIntf.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	secrets := map[string]string{}
+	for _, refIntf := range refs {
+		switch ref := refIntf.(type) {
+		case string:
+			secrets[ref] = ref
+		case map[string]interface{}:
+			source, _ := ref["source"].(string)
+			if source == "" {
+				continue
+			}
+
+			id := source
+			if target, ok := ref["target"].(string); ok && target != "" {
+				id = target
+			}
+			secrets[id] = source
+		}
+	}
+	return secrets, true
+}
+
+// BuildSSH returns the SSH agents that should be forwarded to the service's
+// build. It's a map from the ID that the agent is exposed to the build with
+// to the socket or private key that backs it. Agents without a path use the
+// socket at SSH_AUTH_SOCK.
+func BuildSSH(svc types.ServiceConfig) map[string]string {
+	if svc.Build == nil {
+		return nil
+	}
+
+	ssh, _ := svc.Build.Extensions[buildSSHKey].(map[string]string)
+	return ssh
+}
+
+// ReadBuildSecrets returns the contents of the secrets used by the service's
+// build, keyed by the ID that they're exposed to the build with.
+func ReadBuildSecrets(cfg types.Project, svc types.ServiceConfig) (map[string][]byte, error) {
+	if svc.Build == nil {
+		return nil, nil
+	}
+
+	refs, _ := svc.Build.Extensions[buildSecretsKey].(map[string]string)
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	var referenced []string
+	for _, source := range refs {
+		referenced = append(referenced, source)
+	}
+
+	objects := map[string]types.FileObjectConfig{}
+	for name, secret := range cfg.Secrets {
+		objects[name] = types.FileObjectConfig(secret)
+	}
+
+	contents, err := readFileObjects("Secret", referenced, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := map[string][]byte{}
+	for id, source := range refs {
+		secrets[id] = contents[source]
+	}
+	return secrets, nil
+}
@@ -1,6 +1,8 @@
 package dockercompose
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -24,8 +26,14 @@ var fs = afero.NewOsFs()
 
 // Load loads and merges the given compose files. If `services` is non-empty,
 // the return config only includes the services specified in `services`.
-func Load(composePath string, overridePaths, services []string) (types.Project, error) {
+// Otherwise, it only includes the services that are enabled by `profiles`, or
+// by the COMPOSE_PROFILES environment variable if `profiles` is empty.
+func Load(composePath string, overridePaths, services, profiles []string) (types.Project, error) {
 	var configFiles []types.ConfigFile
+	// svcProfiles maps services to the profiles that enable them. The
+	// loader doesn't support the `profiles` field, so we get it from the raw
+	// config.
+	svcProfiles := map[string][]string{}
 	for _, path := range append([]string{composePath}, overridePaths...) {
 		b, err := afero.ReadFile(fs, path)
 		if err != nil {
@@ -46,6 +54,12 @@ func Load(composePath string, overridePaths, services []string) (types.Project,
 			Filename: filepath.Base(path),
 			Config:   configIntf,
 		})
+
+		// Override files replace the profiles of services defined in
+		// earlier files.
+		for svc, svcProfile := range parseProfiles(configIntf) {
+			svcProfiles[svc] = svcProfile
+		}
 	}
 
 	env := map[string]string{}
@@ -112,6 +126,14 @@ func Load(composePath string, overridePaths, services []string) (types.Project,
 			"The full error was:\n%s", strings.Join(debugCmd, " "), err)
 	}
 
+	if err := resolveFileObjectContents(cfgPtr, configFiles, env); err != nil {
+		return types.Project{}, err
+	}
+
+	if err := resolveBuildOptions(cfgPtr, configFiles, filepath.Dir(composePath)); err != nil {
+		return types.Project{}, err
+	}
+
 	for svcIdx, svc := range cfgPtr.Services {
 		if svc.ContainerName != "" {
 			continue
@@ -195,8 +217,29 @@ func Load(composePath string, overridePaths, services []string) (types.Project,
 		}
 	}
 
-	// If the user specified specific services to boot, modify the config file
-	// to only contain those services, and their dependencies.
+	// Services that are explicitly requested are always booted, regardless
+	// of their profiles.
+	if len(services) == 0 && len(svcProfiles) != 0 {
+		if len(profiles) == 0 {
+			for _, profile := range strings.Split(env["COMPOSE_PROFILES"], ",") {
+				if profile = strings.TrimSpace(profile); profile != "" {
+					profiles = append(profiles, profile)
+				}
+			}
+		}
+
+		services = enabledServices(cfgPtr.Services, svcProfiles, profiles)
+		if len(services) == 0 {
+			return types.Project{}, errors.NewFriendlyError(
+				"No services are enabled by the active profiles (%s).\n"+
+					"Enable profiles with --profile or COMPOSE_PROFILES, or specify the services to boot.",
+				strings.Join(profiles, ", "))
+		}
+	}
+
+	// If the user specified specific services to boot, or some services
+	// aren't enabled by the active profiles, modify the config file to only
+	// contain those services, and their dependencies.
 	if len(services) != 0 {
 		// cfg.WithServices also walks all dependencies of the services.
 		var filtered []types.ServiceConfig
@@ -215,6 +258,68 @@ func Load(composePath string, overridePaths, services []string) (types.Project,
 	return *cfgPtr, nil
 }
 
+// parseProfiles returns the profiles of the services in the given raw Compose
+// config.
+func parseProfiles(configIntf map[string]interface{}) map[string][]string {
+	services, ok := configIntf["services"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	svcProfiles := map[string][]string{}
+	for name, svcIntf := range services {
+		svc, ok := svcIntf.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		profilesIntf, ok := svc["profiles"].([]interface{})
+		if !ok {
+			continue
+		}
+
+		var profiles []string
+		for _, profile := range profilesIntf {
+			if profileStr, ok := profile.(string); ok {
+				profiles = append(profiles, profileStr)
+			}
+		}
+		svcProfiles[name] = profiles
+	}
+	return svcProfiles
+}
+
+// enabledServices returns the names of the services that should be booted
+// given the active profiles. Services without any profiles are always
+// enabled. Dependencies of enabled services are booted by WithServices, even
+// if their profiles aren't active.
+func enabledServices(services types.Services, svcProfiles map[string][]string, activeProfiles []string) []string {
+	active := map[string]struct{}{}
+	for _, profile := range activeProfiles {
+		active[profile] = struct{}{}
+	}
+
+	// Like Docker Compose, the "*" profile enables all services.
+	_, allEnabled := active["*"]
+
+	var enabled []string
+	for _, svc := range services {
+		profiles := svcProfiles[svc.Name]
+		if len(profiles) == 0 || allEnabled {
+			enabled = append(enabled, svc.Name)
+			continue
+		}
+
+		for _, profile := range profiles {
+			if _, ok := active[profile]; ok {
+				enabled = append(enabled, svc.Name)
+				break
+			}
+		}
+	}
+	return enabled
+}
+
 func parseEnvFile(path string) (map[string]string, error) {
 	parsed, err := envfile.Parse(path)
 	if err != nil {
@@ -260,7 +365,34 @@ func Unmarshal(b []byte) (parsed types.Project, err error) {
 // `types.Project` and `types.Config` both have the same field names and types
 // for `Services`, `Networks`, and `Volumes`.
 func Marshal(cfg types.Project) ([]byte, error) {
-	return yaml.Marshal(cfg)
+	// Extensions are omitted by the JSON tags, so add the x-blimp blocks back
+	// in so that they're available to the cluster.
+	cfgJSON, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use json.Number so that large integers (e.g. memory limits) aren't
+	// converted to floats.
+	var cfgMap map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(cfgJSON))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cfgMap); err != nil {
+		return nil, err
+	}
+
+	services, _ := cfgMap["services"].(map[string]interface{})
+	for _, svc := range cfg.Services {
+		ext, ok := svc.Extensions[BlimpExtensionKey]
+		if !ok {
+			continue
+		}
+
+		if svcMap, ok := services[svc.Name].(map[string]interface{}); ok {
+			svcMap[BlimpExtensionKey] = ext
+		}
+	}
+	return yaml.Marshal(cfgMap)
 }
 
 func withSkipValidation(opts *loader.Options) {
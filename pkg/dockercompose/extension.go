@@ -0,0 +1,52 @@
+package dockercompose
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/kelda/compose-go/types"
+
+	"github.com/kelda/blimp/pkg/errors"
+)
+
+// BlimpExtensionKey is the service field containing Blimp-specific settings.
+// For example:
+//
+//	services:
+//	  web:
+//	    image: nginx
+//	    x-blimp:
+//	      restart_unhealthy: true
+const BlimpExtensionKey = "x-blimp"
+
+// BlimpExtension contains the settings that can be set in the x-blimp block.
+type BlimpExtension struct {
+	// RestartUnhealthy makes Blimp restart the service's container when its
+	// healthcheck fails. Docker never restarts unhealthy containers, so this
+	// is disabled by default.
+	RestartUnhealthy bool `json:"restart_unhealthy"`
+}
+
+// ParseBlimpExtension returns the settings in the service's x-blimp block.
+func ParseBlimpExtension(svc types.ServiceConfig) (BlimpExtension, error) {
+	extIntf, ok := svc.Extensions[BlimpExtensionKey]
+	if !ok || extIntf == nil {
+		return BlimpExtension{}, nil
+	}
+
+	// Round trip through JSON so that the block is parsed according to the
+	// struct tags.
+	extJSON, err := json.Marshal(extIntf)
+	if err != nil {
+		return BlimpExtension{}, errors.WithContext("marshal", err)
+	}
+
+	var ext BlimpExtension
+	decoder := json.NewDecoder(bytes.NewReader(extJSON))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&ext); err != nil {
+		return BlimpExtension{}, errors.NewFriendlyError(
+			"Invalid %s block for service %s: %s", BlimpExtensionKey, svc.Name, err)
+	}
+	return ext, nil
+}
@@ -0,0 +1,64 @@
+package dockercompose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kelda/blimp/pkg/errors"
+)
+
+func TestBlimpExtension(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  string
+		exp      BlimpExtension
+		expError error
+	}{
+		{
+			name: "no extension",
+			service: `
+    image: nginx`,
+		},
+		{
+			name: "restart unhealthy",
+			service: `
+    image: nginx
+    x-blimp:
+      restart_unhealthy: true`,
+			exp: BlimpExtension{RestartUnhealthy: true},
+		},
+		{
+			name: "unknown field",
+			service: `
+    image: nginx
+    x-blimp:
+      restart_unhealthyy: true`,
+			expError: errors.NewFriendlyError("Invalid x-blimp block for service web: " +
+				`json: unknown field "restart_unhealthyy"`),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			composeFile := "version: '3'\nservices:\n  web:" + test.service + "\n    mem_limit: 4294967296\n"
+			parsed, err := Unmarshal([]byte(composeFile))
+			require.NoError(t, err)
+
+			// The extension should be preserved when the config is sent to
+			// the cluster.
+			marshalled, err := Marshal(parsed)
+			require.NoError(t, err)
+			parsed, err = Unmarshal(marshalled)
+			require.NoError(t, err)
+			require.Len(t, parsed.Services, 1)
+			assert.Equal(t, int64(4294967296), int64(parsed.Services[0].MemLimit))
+
+			ext, err := ParseBlimpExtension(parsed.Services[0])
+			assert.Equal(t, test.expError, err)
+			assert.Equal(t, test.exp, ext)
+		})
+	}
+}
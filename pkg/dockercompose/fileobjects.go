@@ -0,0 +1,192 @@
+package dockercompose
+
+import (
+	"sort"
+
+	"github.com/kelda/compose-go/template"
+	"github.com/kelda/compose-go/types"
+	"github.com/spf13/afero"
+
+	"github.com/kelda/blimp/pkg/errors"
+)
+
+// fileObjectContentsKey is the key in a secret or config's Extensions that
+// holds the contents of objects that aren't backed by a file. The compose-go
+// loader doesn't support the `environment` or `content` fields, so we
+// resolve them ourselves while loading the Compose file.
+// Extensions aren't serialized by Marshal, so the contents never get sent
+// to the cluster as part of the Compose file.
+const fileObjectContentsKey = "x-blimp-contents"
+
+// resolveFileObjectContents looks up the contents of any secrets or configs
+// that are defined with the `environment` or `content` fields.
+func resolveFileObjectContents(cfg *types.Project, configFiles []types.ConfigFile, env map[string]string) error {
+	for name, contents := range getRawContents("secrets", configFiles) {
+		secret, ok := cfg.Secrets[name]
+		if !ok {
+			continue
+		}
+
+		resolved, err := contents.resolve("Secret", name, env)
+		if err != nil {
+			return err
+		}
+		cfg.Secrets[name] = types.SecretConfig(withContents(types.FileObjectConfig(secret), resolved))
+	}
+
+	for name, contents := range getRawContents("configs", configFiles) {
+		config, ok := cfg.Configs[name]
+		if !ok {
+			continue
+		}
+
+		resolved, err := contents.resolve("Config", name, env)
+		if err != nil {
+			return err
+		}
+		cfg.Configs[name] = types.ConfigObjConfig(withContents(types.FileObjectConfig(config), resolved))
+	}
+	return nil
+}
+
+// rawContents is the unparsed source for a secret or config that isn't
+// backed by a file.
+type rawContents struct {
+	environment string
+	content     string
+}
+
+func (raw rawContents) resolve(kind, name string, env map[string]string) (string, error) {
+	if raw.environment != "" {
+		val, ok := env[raw.environment]
+		if !ok {
+			return "", errors.NewFriendlyError(
+				"%s %s references the environment variable %s, but it isn't set.",
+				kind, name, raw.environment)
+		}
+		return val, nil
+	}
+
+	content, err := template.Substitute(raw.content, func(key string) (string, bool) {
+		val, ok := env[key]
+		return val, ok
+	})
+	if err != nil {
+		return "", errors.NewFriendlyError("Failed to interpolate the content of %s %s.\n\n"+
+			"The full error was:\n%s", kind, name, err)
+	}
+	return content, nil
+}
+
+// getRawContents returns the objects in the given top-level section that
+// aren't backed by a file. Later files override earlier files.
+func getRawContents(section string, configFiles []types.ConfigFile) map[string]rawContents {
+	contents := map[string]rawContents{}
+	for _, configFile := range configFiles {
+		objects, ok := configFile.Config[section].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for name, objIntf := range objects {
+			obj, ok := objIntf.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			if envVar, ok := obj["environment"].(string); ok {
+				contents[name] = rawContents{environment: envVar}
+			} else if content, ok := obj["content"].(string); ok {
+				contents[name] = rawContents{content: content}
+			}
+		}
+	}
+	return contents
+}
+
+func withContents(obj types.FileObjectConfig, contents string) types.FileObjectConfig {
+	// The loader sets File to the working directory when no file is
+	// specified.
+	obj.File = ""
+	if obj.Extensions == nil {
+		obj.Extensions = map[string]interface{}{}
+	}
+	obj.Extensions[fileObjectContentsKey] = contents
+	return obj
+}
+
+// ReadSecrets returns the contents of the top-level secrets that are
+// referenced by the services in the given Compose file.
+func ReadSecrets(cfg types.Project) (map[string][]byte, error) {
+	var referenced []string
+	for _, svc := range cfg.Services {
+		for _, ref := range svc.Secrets {
+			referenced = append(referenced, ref.Source)
+		}
+	}
+
+	objects := map[string]types.FileObjectConfig{}
+	for name, secret := range cfg.Secrets {
+		objects[name] = types.FileObjectConfig(secret)
+	}
+	return readFileObjects("Secret", referenced, objects)
+}
+
+// ReadConfigs returns the contents of the top-level configs that are
+// referenced by the services in the given Compose file.
+func ReadConfigs(cfg types.Project) (map[string][]byte, error) {
+	var referenced []string
+	for _, svc := range cfg.Services {
+		for _, ref := range svc.Configs {
+			referenced = append(referenced, ref.Source)
+		}
+	}
+
+	objects := map[string]types.FileObjectConfig{}
+	for name, config := range cfg.Configs {
+		objects[name] = types.FileObjectConfig(config)
+	}
+	return readFileObjects("Config", referenced, objects)
+}
+
+func readFileObjects(kind string, referenced []string, objects map[string]types.FileObjectConfig) (
+	map[string][]byte, error) {
+	sort.Strings(referenced)
+
+	contents := map[string][]byte{}
+	for _, name := range referenced {
+		if _, ok := contents[name]; ok {
+			continue
+		}
+
+		obj, ok := objects[name]
+		if !ok {
+			return nil, errors.NewFriendlyError(
+				"%s %s is used by a service, but isn't defined in the top-level section.",
+				kind, name)
+		}
+
+		inlineContents, isInline := obj.Extensions[fileObjectContentsKey].(string)
+		switch {
+		case obj.External.External:
+			return nil, errors.NewFriendlyError(
+				"%s %s is external. Blimp only supports objects that are defined by a "+
+					"file, an environment variable, or inline content.", kind, name)
+		case isInline:
+			contents[name] = []byte(inlineContents)
+		case obj.File != "":
+			b, err := afero.ReadFile(fs, obj.File)
+			if err != nil {
+				return nil, errors.NewFriendlyError(
+					"Failed to read %s %s from %s.\n\nThe full error was:\n%s",
+					kind, name, obj.File, err)
+			}
+			contents[name] = b
+		default:
+			return nil, errors.NewFriendlyError(
+				"%s %s must specify either a file, an environment variable, or inline content.",
+				kind, name)
+		}
+	}
+	return contents, nil
+}
@@ -0,0 +1,164 @@
+package dockercompose
+
+import (
+	"testing"
+
+	"github.com/kelda/compose-go/types"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kelda/blimp/pkg/errors"
+)
+
+func TestResolveFileObjectContents(t *testing.T) {
+	configFiles := []types.ConfigFile{
+		{
+			Config: map[string]interface{}{
+				"secrets": map[string]interface{}{
+					"token":  map[string]interface{}{"environment": "API_TOKEN"},
+					"inline": map[string]interface{}{"content": "user=${USER}"},
+					"file":   map[string]interface{}{"file": "./token"},
+				},
+			},
+		},
+		{
+			// Later files override earlier files.
+			Config: map[string]interface{}{
+				"configs": map[string]interface{}{
+					"nginx": map[string]interface{}{"content": "listen 80;"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		expSecrets map[string]string
+		expConfigs map[string]string
+		expErr     error
+	}{
+		{
+			name: "resolved",
+			env:  map[string]string{"API_TOKEN": "secret", "USER": "kevin"},
+			expSecrets: map[string]string{
+				"token":  "secret",
+				"inline": "user=kevin",
+			},
+			expConfigs: map[string]string{
+				"nginx": "listen 80;",
+			},
+		},
+		{
+			name: "missing environment variable",
+			env:  map[string]string{"USER": "kevin"},
+			expErr: errors.NewFriendlyError(
+				"Secret token references the environment variable API_TOKEN, but it isn't set."),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			cfg := types.Project{
+				Secrets: map[string]types.SecretConfig{
+					"token":  {File: "/cwd"},
+					"inline": {File: "/cwd"},
+					"file":   {File: "/cwd/token"},
+				},
+				Configs: map[string]types.ConfigObjConfig{
+					"nginx": {File: "/cwd"},
+				},
+			}
+
+			err := resolveFileObjectContents(&cfg, configFiles, test.env)
+			if test.expErr != nil {
+				assert.Equal(t, test.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+
+			for name, exp := range test.expSecrets {
+				assert.Empty(t, cfg.Secrets[name].File)
+				assert.Equal(t, exp, cfg.Secrets[name].Extensions[fileObjectContentsKey])
+			}
+			for name, exp := range test.expConfigs {
+				assert.Empty(t, cfg.Configs[name].File)
+				assert.Equal(t, exp, cfg.Configs[name].Extensions[fileObjectContentsKey])
+			}
+
+			// Secrets that are backed by a file are left as is.
+			assert.Equal(t, types.SecretConfig{File: "/cwd/token"}, cfg.Secrets["file"])
+		})
+	}
+}
+
+func TestReadSecrets(t *testing.T) {
+	inline := func(contents string) types.SecretConfig {
+		return types.SecretConfig{
+			Extensions: map[string]interface{}{fileObjectContentsKey: contents},
+		}
+	}
+	withSecrets := func(names ...string) types.Services {
+		var refs []types.ServiceSecretConfig
+		for _, name := range names {
+			refs = append(refs, types.ServiceSecretConfig{Source: name})
+		}
+		return types.Services{{Name: "web", Secrets: refs}}
+	}
+
+	tests := []struct {
+		name        string
+		services    types.Services
+		secrets     map[string]types.SecretConfig
+		expContents map[string][]byte
+		expErr      error
+	}{
+		{
+			name:     "file and inline",
+			services: withSecrets("token", "cert"),
+			secrets: map[string]types.SecretConfig{
+				"token":  inline("secret"),
+				"cert":   {File: "/certs/cert.pem"},
+				"unused": {File: "/does/not/exist"},
+			},
+			expContents: map[string][]byte{
+				"token": []byte("secret"),
+				"cert":  []byte("certificate"),
+			},
+		},
+		{
+			name:     "undefined",
+			services: withSecrets("token"),
+			secrets:  map[string]types.SecretConfig{},
+			expErr: errors.NewFriendlyError(
+				"Secret token is used by a service, but isn't defined in the top-level section."),
+		},
+		{
+			name:     "external",
+			services: withSecrets("token"),
+			secrets: map[string]types.SecretConfig{
+				"token": {External: types.External{External: true}},
+			},
+			expErr: errors.NewFriendlyError(
+				"Secret token is external. Blimp only supports objects that are defined by a " +
+					"file, an environment variable, or inline content."),
+		},
+	}
+
+	fs = afero.NewMemMapFs()
+	defer func() {
+		fs = afero.NewOsFs()
+	}()
+	require.NoError(t, afero.WriteFile(fs, "/certs/cert.pem", []byte("certificate"), 0644))
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			contents, err := ReadSecrets(types.Project{Services: test.services, Secrets: test.secrets})
+			assert.Equal(t, test.expErr, err)
+			assert.Equal(t, test.expContents, contents)
+		})
+	}
+}
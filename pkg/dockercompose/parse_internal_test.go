@@ -3,6 +3,9 @@
 package dockercompose
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/kelda/compose-go/types"
@@ -38,9 +41,144 @@ ignoreme:
 		t.Run(test.name, func(t *testing.T) {
 			fs = afero.NewMemMapFs()
 			assert.NoError(t, afero.WriteFile(fs, "docker-compose.yml", []byte(test.composeFile), 0644))
-			config, err := Load("docker-compose.yml", nil, nil)
+			config, err := Load("docker-compose.yml", nil, nil, nil)
 			assert.Equal(t, test.expError, err)
 			assert.Equal(t, test.expConfig, config)
 		})
 	}
 }
+
+func TestProfiles(t *testing.T) {
+	composeFile := `version: "3"
+services:
+  web:
+    image: web
+    depends_on:
+      - db
+  db:
+    image: db
+    profiles: ["backend"]
+  debug:
+    image: debug
+    profiles: ["debug", "tools"]
+  seed:
+    image: seed
+    depends_on:
+      - db
+    profiles: ["seed"]
+`
+
+	tests := []struct {
+		name        string
+		services    []string
+		profiles    []string
+		envProfiles string
+		expServices []string
+		expError    error
+	}{
+		{
+			name:        "no active profiles",
+			expServices: []string{"db", "web"},
+		},
+		{
+			name:        "active profile",
+			profiles:    []string{"tools"},
+			expServices: []string{"db", "debug", "web"},
+		},
+		{
+			name:        "profile from environment",
+			envProfiles: "debug, seed",
+			expServices: []string{"db", "debug", "seed", "web"},
+		},
+		{
+			name:        "flag takes precedence over environment",
+			profiles:    []string{"seed"},
+			envProfiles: "debug",
+			expServices: []string{"db", "seed", "web"},
+		},
+		{
+			name:        "all profiles",
+			profiles:    []string{"*"},
+			expServices: []string{"db", "debug", "seed", "web"},
+		},
+		{
+			name:        "explicit services ignore profiles",
+			services:    []string{"debug"},
+			expServices: []string{"debug"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			fs = afero.NewMemMapFs()
+			assert.NoError(t, afero.WriteFile(fs, "docker-compose.yml", []byte(composeFile), 0644))
+			os.Setenv("COMPOSE_PROFILES", test.envProfiles)
+			defer os.Unsetenv("COMPOSE_PROFILES")
+
+			config, err := Load("docker-compose.yml", nil, test.services, test.profiles)
+			assert.Equal(t, test.expError, err)
+			assert.ElementsMatch(t, test.expServices, config.ServiceNames())
+		})
+	}
+}
+
+func TestBuildOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blimp-build-options")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	composeFile := `version: "3"
+services:
+  web:
+    build:
+      context: .
+      ssh:
+        - default
+        - github=keys/github
+      secrets:
+        - npmrc
+        - source: token
+          target: api_token
+  worker:
+    build: .
+secrets:
+  npmrc:
+    file: ./npmrc
+  token:
+    environment: API_TOKEN
+`
+
+	fs = afero.NewOsFs()
+	composePath := filepath.Join(dir, "docker-compose.yml")
+	assert.NoError(t, ioutil.WriteFile(composePath, []byte(composeFile), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), nil, 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "npmrc"), []byte("registry"), 0644))
+	os.Setenv("API_TOKEN", "token")
+	defer os.Unsetenv("API_TOKEN")
+
+	config, err := Load(composePath, nil, nil, nil)
+	assert.NoError(t, err)
+
+	web, err := config.GetService("web")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"default": "",
+		"github":  filepath.Join(dir, "keys/github"),
+	}, BuildSSH(web))
+
+	secrets, err := ReadBuildSecrets(config, web)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"npmrc":     []byte("registry"),
+		"api_token": []byte("token"),
+	}, secrets)
+
+	worker, err := config.GetService("worker")
+	assert.NoError(t, err)
+	assert.Empty(t, BuildSSH(worker))
+
+	secrets, err = ReadBuildSecrets(config, worker)
+	assert.NoError(t, err)
+	assert.Empty(t, secrets)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -82,18 +83,12 @@ func DeployPod(kubeClient kubernetes.Interface, pod corev1.Pod, opts DeployPodOp
 		// If the currently deployed pod is already up to date, we don't have
 		// to do anything.
 		if !opts.ForceRestart {
-			// Make a copy to avoid modifying the desired pod, since that pod is used
-			// to deploy.
-			sanitized := (&pod).DeepCopy()
-			for _, sanitize := range opts.Sanitizers {
-				sanitized = sanitize(sanitized, curr)
-			}
-			annot, err := runtime.Encode(unstructured.UnstructuredJSONScheme, sanitized)
+			upToDate, err := PodUpToDate(pod, *curr, opts.Sanitizers)
 			if err != nil {
-				return errors.WithContext("make apply annotation", err)
+				return err
 			}
 
-			if string(annot) == curr.Annotations["blimp.appliedObject"] {
+			if upToDate {
 				return nil
 			}
 		}
@@ -125,6 +120,25 @@ func DeployPod(kubeClient kubernetes.Interface, pod corev1.Pod, opts DeployPodOp
 	return nil
 }
 
+// PodUpToDate returns whether the currently deployed pod was deployed from
+// the desired pod's spec. The sanitizers are applied to the desired pod
+// before comparing, so that they can ignore fields that are expected to
+// differ.
+func PodUpToDate(desired, curr corev1.Pod, sanitizers []Sanitizer) (bool, error) {
+	// Make a copy to avoid modifying the desired pod, since that pod is used
+	// to deploy.
+	sanitized := (&desired).DeepCopy()
+	for _, sanitize := range sanitizers {
+		sanitized = sanitize(sanitized, &curr)
+	}
+	annot, err := runtime.Encode(unstructured.UnstructuredJSONScheme, sanitized)
+	if err != nil {
+		return false, errors.WithContext("make apply annotation", err)
+	}
+
+	return string(annot) == curr.Annotations["blimp.appliedObject"], nil
+}
+
 func DeployServiceAccount(kubeClient kubernetes.Interface, sa corev1.ServiceAccount, roles ...rbacv1.Role) error {
 	return deployServiceAccount(kubeClient, sa, roles, nil)
 }
@@ -223,6 +237,34 @@ func DeployConfigMap(kubeClient kubernetes.Interface, configMap corev1.ConfigMap
 	return nil
 }
 
+func DeploySecret(kubeClient kubernetes.Interface, secret corev1.Secret) error {
+	secretClient := kubeClient.CoreV1().Secrets(secret.Namespace)
+	currSecret, err := secretClient.Get(secret.Name, metav1.GetOptions{})
+	if err == nil {
+		secret.ResourceVersion = currSecret.ResourceVersion
+		if _, err := secretClient.Update(&secret); err != nil {
+			return errors.WithContext("update secret", err)
+		}
+	} else if _, err := secretClient.Create(&secret); err != nil {
+		return errors.WithContext("create secret", err)
+	}
+	return nil
+}
+
+func DeployNetworkPolicy(kubeClient kubernetes.Interface, policy networkingv1.NetworkPolicy) error {
+	policyClient := kubeClient.NetworkingV1().NetworkPolicies(policy.Namespace)
+	currPolicy, err := policyClient.Get(policy.Name, metav1.GetOptions{})
+	if err == nil {
+		policy.ResourceVersion = currPolicy.ResourceVersion
+		if _, err := policyClient.Update(&policy); err != nil {
+			return errors.WithContext("update network policy", err)
+		}
+	} else if _, err := policyClient.Create(&policy); err != nil {
+		return errors.WithContext("create network policy", err)
+	}
+	return nil
+}
+
 func SanitizeIgnoreInitContainerImages(desired, curr *corev1.Pod) *corev1.Pod {
 	currImages := map[string]string{}
 	for _, c := range curr.Spec.InitContainers {
@@ -1,7 +1,15 @@
 package kube
 
+import (
+	"fmt"
+	"regexp"
+)
+
 const (
 	ContainerNameCopyVCP                   = "copy-vcp"
+	ContainerNameCopySecrets               = "copy-secrets"
+	ContainerNameCopyConfigs               = "copy-configs"
+	ContainerNameCopyTini                  = "copy-tini"
 	ContainerNameInitializeVolumeFromImage = "vcp"
 	ContainerNameWaitDependsOn             = "wait-depends-on"
 	ContainerNameWaitInitialSync           = "wait-sync"
@@ -16,3 +24,21 @@ const (
 	PodNameSyncthing = "syncthing"
 	PodNameBuildkitd = "buildkitd"
 )
+
+var initContainerSuffix = regexp.MustCompile(`-[0-9]+$`)
+
+// InitContainerName returns the name of the given type of init container
+// (e.g. ContainerNameWaitDependsOn) for the i'th service in a pod. Pods that
+// run multiple services have a copy of the init containers for each service.
+func InitContainerName(containerType string, i int) string {
+	if i == 0 {
+		return containerType
+	}
+	return fmt.Sprintf("%s-%d", containerType, i)
+}
+
+// InitContainerType returns the type of the init container with the given
+// name. It's the inverse of InitContainerName.
+func InitContainerType(name string) string {
+	return initContainerSuffix.ReplaceAllString(name, "")
+}
@@ -0,0 +1,339 @@
+// Package lockfile records the exact images that a sandbox ran, so that
+// other sandboxes can boot the same images.
+package lockfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	composeTypes "github.com/kelda/compose-go/types"
+
+	"github.com/kelda/blimp/pkg/auth"
+	"github.com/kelda/blimp/pkg/build"
+	"github.com/kelda/blimp/pkg/errors"
+)
+
+// Filename is the name of the lockfile. It's stored alongside the Compose
+// file.
+const Filename = "blimp.lock"
+
+const header = "# This file is generated by `blimp build --lock`. Don't edit it by hand.\n"
+
+// Lockfile maps services to the images that they run.
+type Lockfile struct {
+	Services map[string]Service `json:"services"`
+}
+
+// Service is the locked image for a single service.
+type Service struct {
+	// Image is the image in the Compose file, for services that pull an
+	// image rather than building it.
+	Image string `json:"image,omitempty"`
+
+	// BuildDigest is the digest of the build inputs, for services that are
+	// built. See build.Digest.
+	BuildDigest string `json:"build_digest,omitempty"`
+
+	// Digest is the digest of the image that the service ran.
+	Digest string `json:"digest"`
+
+	// Reference is the full reference to the image that the service ran,
+	// pinned to Digest. `blimp up --locked` runs this image rather than
+	// building or pulling the service's image.
+	Reference string `json:"reference"`
+}
+
+// Path returns the path to the lockfile for the given Compose file.
+func Path(composePath string) string {
+	return filepath.Join(filepath.Dir(composePath), Filename)
+}
+
+// Read parses the lockfile at the given path.
+func Read(path string) (Lockfile, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Lockfile{}, errors.NewFriendlyError(
+				"%s doesn't exist. Create it with `blimp build --lock`.", path)
+		}
+		return Lockfile{}, errors.WithContext("read lockfile", err)
+	}
+
+	var lock Lockfile
+	if err := yaml.Unmarshal(contents, &lock); err != nil {
+		return Lockfile{}, errors.WithContext("parse lockfile", err)
+	}
+	if lock.Services == nil {
+		lock.Services = map[string]Service{}
+	}
+	return lock, nil
+}
+
+// ReadIfExists is like Read, but returns an empty lockfile if it doesn't
+// exist.
+func ReadIfExists(path string) (Lockfile, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return Lockfile{Services: map[string]Service{}}, nil
+	}
+	return Read(path)
+}
+
+// Write writes the lockfile to the given path.
+func (lock Lockfile) Write(path string) error {
+	contents, err := yaml.Marshal(lock)
+	if err != nil {
+		return errors.WithContext("marshal lockfile", err)
+	}
+
+	if err := ioutil.WriteFile(path, append([]byte(header), contents...), 0644); err != nil {
+		return errors.WithContext("write lockfile", err)
+	}
+	return nil
+}
+
+// Update records the images for the services in the Compose file. The images
+// of built services are taken from `builtImages`, and the tags of pulled
+// images are resolved to their current digests. Services that aren't in the
+// Compose file are left as is. It returns a description of each locked
+// image that changed.
+//
+// Built images are pushed to the sandbox of the user who built them, which
+// other users can't pull from. If `publishRepo` is set, the built images are
+// copied to `<publishRepo>/<service>` and locked there instead, so that
+// anyone with access to the repository can run them.
+func (lock *Lockfile) Update(cfg composeTypes.Project, builtImages map[string]string,
+	publishRepo string, regCreds auth.RegistryCredentials) ([]string, error) {
+	if lock.Services == nil {
+		lock.Services = map[string]Service{}
+	}
+
+	var changes []string
+	for _, svc := range cfg.Services {
+		var locked Service
+		if svc.Build != nil {
+			builtImage, ok := builtImages[svc.Name]
+			if !ok {
+				continue
+			}
+
+			buildDigest, err := build.Digest(*svc.Build)
+			if err != nil {
+				return nil, errors.WithContext(fmt.Sprintf("compute build digest for %s", svc.Name), err)
+			}
+
+			if publishRepo != "" {
+				published := build.RemoteImageName(svc.Name, publishRepo, buildDigest)
+				if err := copyImage(builtImage, published, regCreds); err != nil {
+					return nil, errors.WithContext(fmt.Sprintf("publish image for %s", svc.Name), err)
+				}
+				builtImage = published
+			}
+
+			digest, err := ImageDigest(builtImage, regCreds)
+			if err != nil {
+				return nil, errors.WithContext(fmt.Sprintf("resolve image for %s", svc.Name), err)
+			}
+
+			ref, err := pinImage(builtImage, digest)
+			if err != nil {
+				return nil, errors.WithContext(fmt.Sprintf("pin image for %s", svc.Name), err)
+			}
+			locked = Service{BuildDigest: buildDigest, Digest: digest, Reference: ref}
+		} else {
+			digest, err := ImageDigest(svc.Image, regCreds)
+			if err != nil {
+				return nil, errors.WithContext(fmt.Sprintf("resolve image for %s", svc.Name), err)
+			}
+
+			ref, err := pinImage(svc.Image, digest)
+			if err != nil {
+				return nil, errors.WithContext(fmt.Sprintf("pin image for %s", svc.Name), err)
+			}
+			locked = Service{Image: svc.Image, Digest: digest, Reference: ref}
+		}
+
+		old, ok := lock.Services[svc.Name]
+		switch {
+		case ok && old.Image != "" && old.Image == locked.Image && old.Digest != locked.Digest:
+			changes = append(changes, fmt.Sprintf("%s: %s moved from %s to %s",
+				svc.Name, locked.Image, old.Digest, locked.Digest))
+		case ok && old.Digest != locked.Digest:
+			changes = append(changes, fmt.Sprintf("%s: updated from %s to %s",
+				svc.Name, old.Digest, locked.Digest))
+		}
+		lock.Services[svc.Name] = locked
+	}
+	sort.Strings(changes)
+	return changes, nil
+}
+
+// Pin updates the services in the Compose file to run the locked images.
+// Services that are built run the image from when the lockfile was written
+// rather than being rebuilt, so that they run byte-identical images. It
+// returns an error if any services aren't in the lockfile, or have changed
+// since the lockfile was written.
+func (lock Lockfile) Pin(cfg *composeTypes.Project) error {
+	for i, svc := range cfg.Services {
+		locked, ok := lock.Services[svc.Name]
+		if !ok {
+			return errors.NewFriendlyError(
+				"Service %s isn't in %s. Update it with `blimp build --lock`.", svc.Name, Filename)
+		}
+
+		if svc.Build != nil {
+			buildDigest, err := build.Digest(*svc.Build)
+			if err != nil {
+				return errors.WithContext(fmt.Sprintf("compute build digest for %s", svc.Name), err)
+			}
+
+			if buildDigest != locked.BuildDigest {
+				return errors.NewFriendlyError(
+					"The build context, Dockerfile, or build options of %s changed since %s was written. "+
+						"Update it with `blimp build --lock`.", svc.Name, Filename)
+			}
+		} else if svc.Image != locked.Image {
+			return errors.NewFriendlyError(
+				"The image of %s changed from %s to %s since %s was written. "+
+					"Update it with `blimp build --lock`.", svc.Name, locked.Image, svc.Image, Filename)
+		}
+
+		if locked.Reference == "" {
+			return errors.NewFriendlyError(
+				"%s doesn't record the image for %s. Update it with `blimp build --lock`.",
+				Filename, svc.Name)
+		}
+
+		cfg.Services[i].Image = locked.Reference
+		cfg.Services[i].Build = nil
+	}
+	return nil
+}
+
+// CheckBuiltImages returns an error if the locked images of any of the built
+// services in the Compose file can't be pulled. Unless the lockfile was
+// written with a team build cache, built images are in the sandbox of the
+// user who wrote the lockfile, so other users don't have access to them.
+func (lock Lockfile) CheckBuiltImages(cfg composeTypes.Project, regCreds auth.RegistryCredentials) error {
+	for _, svc := range cfg.Services {
+		locked, ok := lock.Services[svc.Name]
+		if !ok || locked.BuildDigest == "" {
+			continue
+		}
+
+		ref, err := name.ParseReference(locked.Reference)
+		if err != nil {
+			return errors.WithContext(fmt.Sprintf("parse image name for %s", svc.Name), err)
+		}
+
+		if _, err := remote.Get(ref, remote.WithAuth(authenticator(locked.Reference, regCreds))); err != nil {
+			return errors.NewFriendlyError(
+				"Failed to pull the image for %s (%s) recorded in %s:\n%s\n\n"+
+					"Images are pushed to the sandbox of the user who built them, so they might "+
+					"not be accessible to you. Update %s with `blimp build --lock`.\n"+
+					"To share locked images with your team, set `team_build_cache` and "+
+					"`export_team_build_cache` in ~/.blimp/blimp.yaml before running "+
+					"`blimp build --lock`.",
+				svc.Name, locked.Reference, Filename, err, Filename)
+		}
+	}
+	return nil
+}
+
+// TagDrift returns a description of each pulled image whose tag no longer
+// points at the locked digest. Images that can't be resolved are ignored.
+func (lock Lockfile) TagDrift(regCreds auth.RegistryCredentials) []string {
+	var drift []string
+	for svc, locked := range lock.Services {
+		if locked.Image == "" {
+			continue
+		}
+
+		digest, err := ImageDigest(locked.Image, regCreds)
+		if err != nil || digest == locked.Digest {
+			continue
+		}
+
+		drift = append(drift, fmt.Sprintf("%s: %s now points to %s, but %s pins %s",
+			svc, locked.Image, digest, Filename, locked.Digest))
+	}
+	sort.Strings(drift)
+	return drift
+}
+
+// ImageDigest returns the digest of the given image. If the image is already
+// pinned to a digest, the digest is returned as is. Otherwise, the tag is
+// resolved with the registry.
+func ImageDigest(image string, regCreds auth.RegistryCredentials) (string, error) {
+	if parts := strings.SplitN(image, "@", 2); len(parts) == 2 {
+		return parts[1], nil
+	}
+
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", errors.WithContext("parse image name", err)
+	}
+
+	desc, err := remote.Get(ref, remote.WithAuth(authenticator(image, regCreds)))
+	if err != nil {
+		return "", errors.WithContext("get image manifest", err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// authenticator returns the authenticator for pulling the given image.
+func authenticator(image string, regCreds auth.RegistryCredentials) authn.Authenticator {
+	cred, ok := regCreds.LookupByImage(image)
+	if !ok {
+		return authn.Anonymous
+	}
+
+	return authn.FromConfig(authn.AuthConfig{
+		Username:      cred.Username,
+		Password:      cred.Password,
+		Auth:          cred.Auth,
+		IdentityToken: cred.IdentityToken,
+		RegistryToken: cred.RegistryToken,
+	})
+}
+
+// copyImage copies the image to the given destination. The manifest is
+// copied as is, so the copy has the same digest as the original.
+func copyImage(src, dst string, regCreds auth.RegistryCredentials) error {
+	srcRef, err := name.ParseReference(src)
+	if err != nil {
+		return errors.WithContext("parse image name", err)
+	}
+
+	dstRef, err := name.ParseReference(dst)
+	if err != nil {
+		return errors.WithContext("parse image name", err)
+	}
+
+	img, err := remote.Image(srcRef, remote.WithAuth(authenticator(src, regCreds)))
+	if err != nil {
+		return errors.WithContext("get image", err)
+	}
+
+	if err := remote.Write(dstRef, img, remote.WithAuth(authenticator(dst, regCreds))); err != nil {
+		return errors.WithContext("push image", err)
+	}
+	return nil
+}
+
+// pinImage returns the reference to the image's repository at the given
+// digest.
+func pinImage(image, digest string) (string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", errors.WithContext("parse image name", err)
+	}
+	return fmt.Sprintf("%s@%s", ref.Context().Name(), digest), nil
+}
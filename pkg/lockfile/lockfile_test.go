@@ -0,0 +1,233 @@
+package lockfile
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	composeTypes "github.com/kelda/compose-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kelda/blimp/pkg/auth"
+	"github.com/kelda/blimp/pkg/build"
+)
+
+const (
+	digestA = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	digestB = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+func TestLockfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blimp-lockfile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine"), 0644))
+
+	buildCfg := composeTypes.BuildConfig{Context: dir, Dockerfile: "Dockerfile"}
+	cfg := composeTypes.Project{
+		Services: composeTypes.Services{
+			{Name: "web", Build: &buildCfg},
+			{Name: "db", Image: "postgres@" + digestA},
+		},
+	}
+
+	// Record the images, and check that the lockfile survives a round trip.
+	var lock Lockfile
+	changes, err := lock.Update(cfg, map[string]string{"web": "blimp-registry.kelda.io/ns/web@" + digestB}, "",
+		auth.RegistryCredentials{})
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	path := filepath.Join(dir, Filename)
+	require.NoError(t, lock.Write(path))
+	parsed, err := Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, lock, parsed)
+	assert.Equal(t, "postgres@"+digestA, parsed.Services["db"].Image)
+	assert.Equal(t, digestB, parsed.Services["web"].Digest)
+	assert.Equal(t, "blimp-registry.kelda.io/ns/web@"+digestB, parsed.Services["web"].Reference)
+	assert.Equal(t, "index.docker.io/library/postgres@"+digestA, parsed.Services["db"].Reference)
+
+	// Rebuilding the service to a different image is reported as a change.
+	changes, err = lock.Update(cfg, map[string]string{"web": "blimp-registry.kelda.io/ns/web@" + digestA}, "",
+		auth.RegistryCredentials{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web: updated from " + digestB + " to " + digestA}, changes)
+	assert.Equal(t, "blimp-registry.kelda.io/ns/web@"+digestA, lock.Services["web"].Reference)
+}
+
+func TestPin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blimp-lockfile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine"), 0644))
+
+	buildCfg := composeTypes.BuildConfig{Context: dir, Dockerfile: "Dockerfile"}
+	var lock Lockfile
+	_, err = lock.Update(composeTypes.Project{
+		Services: composeTypes.Services{{Name: "web", Build: &buildCfg}},
+	}, map[string]string{"web": "blimp-registry.kelda.io/ns/web@" + digestB}, "", auth.RegistryCredentials{})
+	require.NoError(t, err)
+	lock.Services["db"] = Service{
+		Image:     "postgres:12",
+		Digest:    digestA,
+		Reference: "index.docker.io/library/postgres@" + digestA,
+	}
+	lock.Services["cache"] = Service{Image: "redis", Digest: digestA}
+
+	tests := []struct {
+		name      string
+		services  composeTypes.Services
+		expImages map[string]string
+		expBuilds map[string]bool
+		expErr    string
+	}{
+		{
+			name: "pinned",
+			services: composeTypes.Services{
+				{Name: "web", Build: &buildCfg},
+				{Name: "db", Image: "postgres:12"},
+			},
+			// Built services run the locked image rather than being rebuilt.
+			expImages: map[string]string{
+				"web": "blimp-registry.kelda.io/ns/web@" + digestB,
+				"db":  "index.docker.io/library/postgres@" + digestA,
+			},
+			expBuilds: map[string]bool{"web": false, "db": false},
+		},
+		{
+			name:     "missing service",
+			services: composeTypes.Services{{Name: "worker", Image: "worker"}},
+			expErr:   "Service worker isn't in blimp.lock. Update it with `blimp build --lock`.",
+		},
+		{
+			name:     "missing reference",
+			services: composeTypes.Services{{Name: "cache", Image: "redis"}},
+			expErr:   "blimp.lock doesn't record the image for cache. Update it with `blimp build --lock`.",
+		},
+		{
+			name:     "changed image",
+			services: composeTypes.Services{{Name: "db", Image: "postgres:13"}},
+			expErr: "The image of db changed from postgres:12 to postgres:13 since blimp.lock was written. " +
+				"Update it with `blimp build --lock`.",
+		},
+		{
+			name: "changed build",
+			services: composeTypes.Services{
+				{Name: "web", Build: &composeTypes.BuildConfig{Context: dir, Dockerfile: "Dockerfile", Target: "dev"}},
+			},
+			expErr: "The build context, Dockerfile, or build options of web changed since blimp.lock was written. " +
+				"Update it with `blimp build --lock`.",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			cfg := composeTypes.Project{Services: test.services}
+			err := lock.Pin(&cfg)
+			if test.expErr != "" {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			images := map[string]string{}
+			builds := map[string]bool{}
+			for _, svc := range cfg.Services {
+				images[svc.Name] = svc.Image
+				builds[svc.Name] = svc.Build != nil
+			}
+			assert.Equal(t, test.expImages, images)
+			assert.Equal(t, test.expBuilds, builds)
+		})
+	}
+}
+
+func TestCheckBuiltImages(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(ioutil.Discard, "", 0))))
+	defer server.Close()
+
+	// Push an image to the registry.
+	repo := strings.TrimPrefix(server.URL, "http://") + "/ns/web"
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+	tag, err := name.ParseReference(repo + ":latest")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(tag, img))
+	digest, err := img.Digest()
+	require.NoError(t, err)
+
+	cfg := composeTypes.Project{
+		Services: composeTypes.Services{{Name: "web"}, {Name: "db"}},
+	}
+	lock := Lockfile{
+		Services: map[string]Service{
+			"web": {BuildDigest: "build", Digest: digest.String(), Reference: repo + "@" + digest.String()},
+			// Pulled images aren't checked.
+			"db": {Image: "postgres", Digest: digestA, Reference: repo + "@" + digestA},
+		},
+	}
+	assert.NoError(t, lock.CheckBuiltImages(cfg, auth.RegistryCredentials{}))
+
+	lock.Services["web"] = Service{BuildDigest: "build", Digest: digestB, Reference: repo + "@" + digestB}
+	err = lock.CheckBuiltImages(cfg, auth.RegistryCredentials{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Failed to pull the image for web ("+repo+"@"+digestB+")")
+		assert.Contains(t, err.Error(), "set `team_build_cache` and `export_team_build_cache`")
+	}
+}
+
+func TestUpdatePublishesBuiltImages(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(ioutil.Discard, "", 0))))
+	defer server.Close()
+	registryHost := strings.TrimPrefix(server.URL, "http://")
+
+	dir, err := ioutil.TempDir("", "blimp-lockfile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine"), 0644))
+
+	// Push the built image to the sandbox's image namespace.
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+	builtImage := registryHost + "/sandbox/web:latest"
+	tag, err := name.ParseReference(builtImage)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(tag, img))
+	digest, err := img.Digest()
+	require.NoError(t, err)
+
+	buildCfg := composeTypes.BuildConfig{Context: dir, Dockerfile: "Dockerfile"}
+	cfg := composeTypes.Project{
+		Services: composeTypes.Services{{Name: "web", Build: &buildCfg}},
+	}
+	teamRepo := registryHost + "/team"
+
+	var lock Lockfile
+	_, err = lock.Update(cfg, map[string]string{"web": builtImage}, teamRepo, auth.RegistryCredentials{})
+	require.NoError(t, err)
+
+	// The image is locked in the team repository, with the same digest as
+	// the image that was built.
+	locked := lock.Services["web"]
+	assert.Equal(t, digest.String(), locked.Digest)
+	assert.Equal(t, teamRepo+"/web@"+digest.String(), locked.Reference)
+	assert.NoError(t, lock.CheckBuiltImages(cfg, auth.RegistryCredentials{}))
+
+	// The published image is tagged with the build digest.
+	buildDigest, err := build.Digest(buildCfg)
+	require.NoError(t, err)
+	publishedDigest, err := ImageDigest(teamRepo+"/web:"+buildDigest, auth.RegistryCredentials{})
+	require.NoError(t, err)
+	assert.Equal(t, digest.String(), publishedDigest)
+}
@@ -1,15 +1,108 @@
 package metadata
 
 import (
+	"encoding/json"
+	"sort"
 	"strings"
+
+	"github.com/kelda/blimp/pkg/names"
 )
 
 const AliasesKey = "io.kelda.blimp/aliases"
 
+// NetworkAliasesKey is the annotation containing the aliases that are only
+// visible to pods on a specific network. It's a JSON map from the network's
+// ID (see NetworkID) to the aliases.
+const NetworkAliasesKey = "io.kelda.blimp/network-aliases"
+
+// NetworkLabelPrefix is the prefix for the pod labels that denote which
+// Compose networks the pod is connected to.
+const NetworkLabelPrefix = "network.blimp/"
+
+// ServiceLabelPrefix is the prefix for the pod labels that denote which
+// services run in the pod, other than the service in the `blimp.service`
+// label. The label's value is the replica that the pod runs.
+const ServiceLabelPrefix = "service.blimp/"
+
+// ColocatedServicesKey is the annotation containing the names of the
+// services that run in the pod alongside the service in the `blimp.service`
+// label, because they share its network or PID namespace. It's a comma
+// separated list.
+const ColocatedServicesKey = "io.kelda.blimp/colocated-services"
+
+// ProjectKey is the annotation containing the name of the Compose project
+// that the pod is part of. The pod's hostnames are also resolvable with the
+// project name as a suffix.
+const ProjectKey = "io.kelda.blimp/project"
+
+// DNSServersKey is the annotation containing the upstream DNS servers that
+// the sandbox's DNS server should use to resolve external hostnames for the
+// pod. It's a comma separated list of addresses.
+const DNSServersKey = "io.kelda.blimp/dns-servers"
+
+// LabelAnnotationsKey is the annotation containing the keys of the
+// annotations that were converted from service labels. It's a comma
+// separated list.
+const LabelAnnotationsKey = "io.kelda.blimp/label-annotations"
+
+// BuildErrorsKey is the namespace annotation containing the services that
+// weren't deployed because their images failed to build. It's a JSON map from
+// the service names to the build errors.
+const BuildErrorsKey = "io.kelda.blimp/build-errors"
+
+// Kubernetes configures the seccomp and AppArmor profiles for containers via
+// pod annotations. The container name is appended to the prefix.
+const (
+	SeccompAnnotationPrefix  = "container.seccomp.security.alpha.kubernetes.io/"
+	AppArmorAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+)
+
 // CustomPodAnnotations contains all annotations that Blimp could apply to pods
 // that should persist across restarts, except blimp.appliedObject.
 var CustomPodAnnotations = []string{
 	AliasesKey,
+	NetworkAliasesKey,
+	ProjectKey,
+	DNSServersKey,
+	LabelAnnotationsKey,
+	ColocatedServicesKey,
+}
+
+// IsCustomPodAnnotation returns whether the given annotation was applied by
+// Blimp, and should persist across restarts.
+func IsCustomPodAnnotation(key string) bool {
+	for _, custom := range CustomPodAnnotations {
+		if key == custom {
+			return true
+		}
+	}
+	return strings.HasPrefix(key, SeccompAnnotationPrefix) ||
+		strings.HasPrefix(key, AppArmorAnnotationPrefix)
+}
+
+// FilterCustomPodAnnotations returns the annotations that should persist
+// across restarts, including the annotations converted from service labels.
+func FilterCustomPodAnnotations(annotations map[string]string) map[string]string {
+	labelAnnotations := map[string]struct{}{}
+	if keys, ok := annotations[LabelAnnotationsKey]; ok {
+		for _, key := range strings.Split(keys, ",") {
+			labelAnnotations[key] = struct{}{}
+		}
+	}
+
+	var filtered map[string]string
+	for key, value := range annotations {
+		_, isLabel := labelAnnotations[key]
+		if !isLabel && !IsCustomPodAnnotation(key) {
+			continue
+		}
+
+		if filtered == nil {
+			filtered = map[string]string{}
+		}
+		filtered[key] = value
+	}
+	return filtered
 }
 
 func ParseAliases(aliases string) []string {
@@ -19,3 +112,66 @@ func ParseAliases(aliases string) []string {
 func Aliases(aliases []string) string {
 	return strings.Join(aliases, ",")
 }
+
+// NetworkID returns the identifier used for the given Compose network in pod
+// labels and annotations.
+func NetworkID(network string) string {
+	return names.ToDNS1123(network)
+}
+
+// NetworkLabel returns the label that's set on pods that are connected to the
+// given Compose network.
+func NetworkLabel(network string) string {
+	return NetworkLabelPrefix + NetworkID(network)
+}
+
+// ParseNetworks returns the IDs of the networks that the pod with the given
+// labels is connected to.
+func ParseNetworks(labels map[string]string) []string {
+	var networks []string
+	for key := range labels {
+		if strings.HasPrefix(key, NetworkLabelPrefix) {
+			networks = append(networks, strings.TrimPrefix(key, NetworkLabelPrefix))
+		}
+	}
+	sort.Strings(networks)
+	return networks
+}
+
+// ServiceLabel returns the label that's set on pods that run the given
+// service alongside another service.
+func ServiceLabel(service string) string {
+	return ServiceLabelPrefix + names.ToDNS1123(service)
+}
+
+func ParseColocatedServices(services string) []string {
+	return strings.Split(services, ",")
+}
+
+func ColocatedServices(services []string) string {
+	return strings.Join(services, ",")
+}
+
+func ParseNetworkAliases(aliases string) (map[string][]string, error) {
+	var parsed map[string][]string
+	err := json.Unmarshal([]byte(aliases), &parsed)
+	return parsed, err
+}
+
+func NetworkAliases(aliases map[string][]string) string {
+	// json.Marshal sorts map keys, so the annotation is deterministic.
+	marshalled, _ := json.Marshal(aliases)
+	return string(marshalled)
+}
+
+func ParseBuildErrors(buildErrors string) (map[string]string, error) {
+	var parsed map[string]string
+	err := json.Unmarshal([]byte(buildErrors), &parsed)
+	return parsed, err
+}
+
+func BuildErrors(buildErrors map[string]string) string {
+	// json.Marshal sorts map keys, so the annotation is deterministic.
+	marshalled, _ := json.Marshal(buildErrors)
+	return string(marshalled)
+}
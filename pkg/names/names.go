@@ -48,3 +48,22 @@ func ToDNS1123(id string) string {
 
 	return fmt.Sprintf("%s-%s", sanitized, h)
 }
+
+// PodName returns the name of the pod for the given replica of a service.
+// The first replica's pod is named after the service, so that the service
+// can be referenced by its pod name regardless of how many replicas it has.
+func PodName(service string, replica int) string {
+	name := ToDNS1123(service)
+	if replica == 0 {
+		return name
+	}
+
+	// Trim the sanitized prefix if the suffix pushes the name past the 63
+	// character limit. The hash at the end of the name is what makes it
+	// unique, so it's always retained.
+	suffix := fmt.Sprintf("-%d", replica)
+	if overflow := len(name) + len(suffix) - 63; overflow > 0 {
+		name = strings.TrimLeft(name[overflow:], "-")
+	}
+	return name + suffix
+}
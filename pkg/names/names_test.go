@@ -1,6 +1,7 @@
 package names_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -76,3 +77,14 @@ func TestToDNS1123(t *testing.T) {
 		assert.Equal(t, test.expOutput, podName, test.name)
 	}
 }
+
+func TestPodName(t *testing.T) {
+	assert.Equal(t, names.ToDNS1123("web"), names.PodName("web", 0))
+	assert.Equal(t, names.ToDNS1123("web")+"-2", names.PodName("web", 2))
+
+	longName := strings.Repeat("a", 70)
+	podName := names.PodName(longName, 100)
+	assert.Len(t, podName, 63)
+	assert.True(t, strings.HasSuffix(podName, "-100"))
+	assert.NotEqual(t, podName, names.PodName(longName, 10))
+}
@@ -51,6 +51,37 @@ func (CLIAction) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_d156d5389f4d1cd6, []int{0}
 }
 
+type DeployChange int32
+
+const (
+	DeployChange_UNCHANGED DeployChange = 0
+	DeployChange_CREATED   DeployChange = 1
+	DeployChange_UPDATED   DeployChange = 2
+	DeployChange_DELETED   DeployChange = 3
+)
+
+var DeployChange_name = map[int32]string{
+	0: "UNCHANGED",
+	1: "CREATED",
+	2: "UPDATED",
+	3: "DELETED",
+}
+
+var DeployChange_value = map[string]int32{
+	"UNCHANGED": 0,
+	"CREATED":   1,
+	"UPDATED":   2,
+	"DELETED":   3,
+}
+
+func (x DeployChange) String() string {
+	return proto.EnumName(DeployChange_name, int32(x))
+}
+
+func (DeployChange) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_d156d5389f4d1cd6, []int{1}
+}
+
 type ServicePhase int32
 
 const (
@@ -63,6 +94,7 @@ const (
 	ServicePhase_EXITED               ServicePhase = 6
 	ServicePhase_UNHEALTHY            ServicePhase = 7
 	ServicePhase_UNSCHEDULABLE        ServicePhase = 8
+	ServicePhase_BUILD_FAILED         ServicePhase = 9
 )
 
 var ServicePhase_name = map[int32]string{
@@ -75,6 +107,7 @@ var ServicePhase_name = map[int32]string{
 	6: "EXITED",
 	7: "UNHEALTHY",
 	8: "UNSCHEDULABLE",
+	9: "BUILD_FAILED",
 }
 
 var ServicePhase_value = map[string]int32{
@@ -87,6 +120,7 @@ var ServicePhase_value = map[string]int32{
 	"EXITED":               6,
 	"UNHEALTHY":            7,
 	"UNSCHEDULABLE":        8,
+	"BUILD_FAILED":         9,
 }
 
 func (x ServicePhase) String() string {
@@ -94,7 +128,7 @@ func (x ServicePhase) String() string {
 }
 
 func (ServicePhase) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_d156d5389f4d1cd6, []int{1}
+	return fileDescriptor_d156d5389f4d1cd6, []int{2}
 }
 
 type SandboxStatus_SandboxPhase int32
@@ -234,14 +268,17 @@ func (m *CheckVersionResponse) GetError() *errors.Error {
 }
 
 type CreateSandboxRequest struct {
-	OldToken             string                         `protobuf:"bytes,1,opt,name=old_token,json=oldToken,proto3" json:"old_token,omitempty"`
-	Auth                 *auth.BlimpAuth                `protobuf:"bytes,5,opt,name=auth,proto3" json:"auth,omitempty"`
-	ComposeFile          string                         `protobuf:"bytes,2,opt,name=composeFile,proto3" json:"composeFile,omitempty"`
-	RegistryCredentials  map[string]*RegistryCredential `protobuf:"bytes,3,rep,name=registryCredentials,proto3" json:"registryCredentials,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-	SyncedFolders        map[string]string              `protobuf:"bytes,4,rep,name=syncedFolders,proto3" json:"syncedFolders,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-	XXX_NoUnkeyedLiteral struct{}                       `json:"-"`
-	XXX_unrecognized     []byte                         `json:"-"`
-	XXX_sizecache        int32                          `json:"-"`
+	OldToken            string                         `protobuf:"bytes,1,opt,name=old_token,json=oldToken,proto3" json:"old_token,omitempty"`
+	Auth                *auth.BlimpAuth                `protobuf:"bytes,5,opt,name=auth,proto3" json:"auth,omitempty"`
+	ComposeFile         string                         `protobuf:"bytes,2,opt,name=composeFile,proto3" json:"composeFile,omitempty"`
+	RegistryCredentials map[string]*RegistryCredential `protobuf:"bytes,3,rep,name=registryCredentials,proto3" json:"registryCredentials,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	SyncedFolders       map[string]string              `protobuf:"bytes,4,rep,name=syncedFolders,proto3" json:"syncedFolders,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// secrets maps the names of the top-level secrets in the Compose file to
+	// their contents.
+	Secrets              map[string][]byte `protobuf:"bytes,6,rep,name=secrets,proto3" json:"secrets,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *CreateSandboxRequest) Reset()         { *m = CreateSandboxRequest{} }
@@ -304,6 +341,13 @@ func (m *CreateSandboxRequest) GetSyncedFolders() map[string]string {
 	return nil
 }
 
+func (m *CreateSandboxRequest) GetSecrets() map[string][]byte {
+	if m != nil {
+		return m.Secrets
+	}
+	return nil
+}
+
 type RegistryCredential struct {
 	Username             string   `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
 	Password             string   `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
@@ -549,10 +593,20 @@ func (m *CreateSandboxResponse) GetAction() CLIAction {
 }
 
 type DeployRequest struct {
-	OldToken             string            `protobuf:"bytes,1,opt,name=old_token,json=oldToken,proto3" json:"old_token,omitempty"`
-	Auth                 *auth.BlimpAuth   `protobuf:"bytes,4,opt,name=auth,proto3" json:"auth,omitempty"`
-	ComposeFile          string            `protobuf:"bytes,2,opt,name=composeFile,proto3" json:"composeFile,omitempty"`
-	BuiltImages          map[string]string `protobuf:"bytes,3,rep,name=builtImages,proto3" json:"builtImages,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	OldToken    string            `protobuf:"bytes,1,opt,name=old_token,json=oldToken,proto3" json:"old_token,omitempty"`
+	Auth        *auth.BlimpAuth   `protobuf:"bytes,4,opt,name=auth,proto3" json:"auth,omitempty"`
+	ComposeFile string            `protobuf:"bytes,2,opt,name=composeFile,proto3" json:"composeFile,omitempty"`
+	BuiltImages map[string]string `protobuf:"bytes,3,rep,name=builtImages,proto3" json:"builtImages,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// configs maps the names of the top-level configs in the Compose file to
+	// their contents.
+	Configs map[string][]byte `protobuf:"bytes,5,rep,name=configs,proto3" json:"configs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// projectName is the name of the Compose project. Services can be reached
+	// at `<service>.<projectName>` in addition to their short names.
+	ProjectName string `protobuf:"bytes,6,opt,name=projectName,proto3" json:"projectName,omitempty"`
+	// buildErrors maps the names of the services whose images failed to build
+	// to the build errors. These services aren't deployed, and are reported as
+	// BUILD_FAILED instead.
+	BuildErrors          map[string]string `protobuf:"bytes,7,rep,name=buildErrors,proto3" json:"buildErrors,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
 	XXX_unrecognized     []byte            `json:"-"`
 	XXX_sizecache        int32             `json:"-"`
@@ -611,11 +665,36 @@ func (m *DeployRequest) GetBuiltImages() map[string]string {
 	return nil
 }
 
+func (m *DeployRequest) GetConfigs() map[string][]byte {
+	if m != nil {
+		return m.Configs
+	}
+	return nil
+}
+
+func (m *DeployRequest) GetProjectName() string {
+	if m != nil {
+		return m.ProjectName
+	}
+	return ""
+}
+
+func (m *DeployRequest) GetBuildErrors() map[string]string {
+	if m != nil {
+		return m.BuildErrors
+	}
+	return nil
+}
+
 type DeployResponse struct {
-	Error                *errors.Error `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
-	XXX_unrecognized     []byte        `json:"-"`
-	XXX_sizecache        int32         `json:"-"`
+	Error *errors.Error `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	// changes maps the names of the services in the sandbox to how their pods
+	// changed. Services that were removed from the Compose file are reported
+	// as DELETED.
+	Changes              map[string]DeployChange `protobuf:"bytes,2,rep,name=changes,proto3" json:"changes,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3,enum=blimp.cluster.v0.DeployChange"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
 }
 
 func (m *DeployResponse) Reset()         { *m = DeployResponse{} }
@@ -650,6 +729,13 @@ func (m *DeployResponse) GetError() *errors.Error {
 	return nil
 }
 
+func (m *DeployResponse) GetChanges() map[string]DeployChange {
+	if m != nil {
+		return m.Changes
+	}
+	return nil
+}
+
 type KubeCredentials struct {
 	Host                 string   `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
 	CaCrt                string   `protobuf:"bytes,2,opt,name=caCrt,proto3" json:"caCrt,omitempty"`
@@ -949,12 +1035,19 @@ func (m *SandboxStatus) GetPhase() SandboxStatus_SandboxPhase {
 }
 
 type ServiceStatus struct {
-	Phase                ServicePhase `protobuf:"varint,1,opt,name=phase,proto3,enum=blimp.cluster.v0.ServicePhase" json:"phase,omitempty"`
-	Msg                  string       `protobuf:"bytes,2,opt,name=msg,proto3" json:"msg,omitempty"`
-	HasStarted           bool         `protobuf:"varint,3,opt,name=has_started,json=hasStarted,proto3" json:"has_started,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
-	XXX_unrecognized     []byte       `json:"-"`
-	XXX_sizecache        int32        `json:"-"`
+	Phase      ServicePhase `protobuf:"varint,1,opt,name=phase,proto3,enum=blimp.cluster.v0.ServicePhase" json:"phase,omitempty"`
+	Msg        string       `protobuf:"bytes,2,opt,name=msg,proto3" json:"msg,omitempty"`
+	HasStarted bool         `protobuf:"varint,3,opt,name=has_started,json=hasStarted,proto3" json:"has_started,omitempty"`
+	// The number of pods deployed for the service, and how many of them are
+	// ready. The phase and msg describe the first replica.
+	Replicas      uint32 `protobuf:"varint,4,opt,name=replicas,proto3" json:"replicas,omitempty"`
+	ReadyReplicas uint32 `protobuf:"varint,5,opt,name=ready_replicas,json=readyReplicas,proto3" json:"ready_replicas,omitempty"`
+	// The pod that runs the first replica. Services that share the network or
+	// PID namespace of another service run in the other service's pod.
+	PodName              string   `protobuf:"bytes,6,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *ServiceStatus) Reset()         { *m = ServiceStatus{} }
@@ -1003,6 +1096,27 @@ func (m *ServiceStatus) GetHasStarted() bool {
 	return false
 }
 
+func (m *ServiceStatus) GetReplicas() uint32 {
+	if m != nil {
+		return m.Replicas
+	}
+	return 0
+}
+
+func (m *ServiceStatus) GetReadyReplicas() uint32 {
+	if m != nil {
+		return m.ReadyReplicas
+	}
+	return 0
+}
+
+func (m *ServiceStatus) GetPodName() string {
+	if m != nil {
+		return m.PodName
+	}
+	return ""
+}
+
 type RestartRequest struct {
 	OldToken             string          `protobuf:"bytes,1,opt,name=old_token,json=oldToken,proto3" json:"old_token,omitempty"`
 	Auth                 *auth.BlimpAuth `protobuf:"bytes,3,opt,name=auth,proto3" json:"auth,omitempty"`
@@ -1659,6 +1773,8 @@ type BlimpUpPreviewRequest struct {
 	Repo                 string            `protobuf:"bytes,2,opt,name=repo,proto3" json:"repo,omitempty"`
 	ComposeFiles         []string          `protobuf:"bytes,3,rep,name=composeFiles,proto3" json:"composeFiles,omitempty"`
 	Env                  map[string]string `protobuf:"bytes,4,rep,name=env,proto3" json:"env,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Profiles             []string          `protobuf:"bytes,6,rep,name=profiles,proto3" json:"profiles,omitempty"`
+	Locked               bool              `protobuf:"varint,7,opt,name=locked,proto3" json:"locked,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
 	XXX_unrecognized     []byte            `json:"-"`
 	XXX_sizecache        int32             `json:"-"`
@@ -1717,6 +1833,20 @@ func (m *BlimpUpPreviewRequest) GetEnv() map[string]string {
 	return nil
 }
 
+func (m *BlimpUpPreviewRequest) GetProfiles() []string {
+	if m != nil {
+		return m.Profiles
+	}
+	return nil
+}
+
+func (m *BlimpUpPreviewRequest) GetLocked() bool {
+	if m != nil {
+		return m.Locked
+	}
+	return false
+}
+
 type BlimpUpPreviewResponse struct {
 	Error                *errors.Error `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
 	StartedCli           bool          `protobuf:"varint,2,opt,name=started_cli,json=startedCli,proto3" json:"started_cli,omitempty"`
@@ -1774,20 +1904,25 @@ func (m *BlimpUpPreviewResponse) GetOutput() []byte {
 
 func init() {
 	proto.RegisterEnum("blimp.cluster.v0.CLIAction", CLIAction_name, CLIAction_value)
+	proto.RegisterEnum("blimp.cluster.v0.DeployChange", DeployChange_name, DeployChange_value)
 	proto.RegisterEnum("blimp.cluster.v0.ServicePhase", ServicePhase_name, ServicePhase_value)
 	proto.RegisterEnum("blimp.cluster.v0.SandboxStatus_SandboxPhase", SandboxStatus_SandboxPhase_name, SandboxStatus_SandboxPhase_value)
 	proto.RegisterType((*CheckVersionRequest)(nil), "blimp.cluster.v0.CheckVersionRequest")
 	proto.RegisterType((*CheckVersionResponse)(nil), "blimp.cluster.v0.CheckVersionResponse")
 	proto.RegisterType((*CreateSandboxRequest)(nil), "blimp.cluster.v0.CreateSandboxRequest")
 	proto.RegisterMapType((map[string]*RegistryCredential)(nil), "blimp.cluster.v0.CreateSandboxRequest.RegistryCredentialsEntry")
+	proto.RegisterMapType((map[string][]byte)(nil), "blimp.cluster.v0.CreateSandboxRequest.SecretsEntry")
 	proto.RegisterMapType((map[string]string)(nil), "blimp.cluster.v0.CreateSandboxRequest.SyncedFoldersEntry")
 	proto.RegisterType((*RegistryCredential)(nil), "blimp.cluster.v0.RegistryCredential")
 	proto.RegisterType((*AttachToSandboxRequest)(nil), "blimp.cluster.v0.AttachToSandboxRequest")
 	proto.RegisterType((*AttachToSandboxResponse)(nil), "blimp.cluster.v0.AttachToSandboxResponse")
 	proto.RegisterType((*CreateSandboxResponse)(nil), "blimp.cluster.v0.CreateSandboxResponse")
 	proto.RegisterType((*DeployRequest)(nil), "blimp.cluster.v0.DeployRequest")
+	proto.RegisterMapType((map[string]string)(nil), "blimp.cluster.v0.DeployRequest.BuildErrorsEntry")
 	proto.RegisterMapType((map[string]string)(nil), "blimp.cluster.v0.DeployRequest.BuiltImagesEntry")
+	proto.RegisterMapType((map[string][]byte)(nil), "blimp.cluster.v0.DeployRequest.ConfigsEntry")
 	proto.RegisterType((*DeployResponse)(nil), "blimp.cluster.v0.DeployResponse")
+	proto.RegisterMapType((map[string]DeployChange)(nil), "blimp.cluster.v0.DeployResponse.ChangesEntry")
 	proto.RegisterType((*KubeCredentials)(nil), "blimp.cluster.v0.KubeCredentials")
 	proto.RegisterType((*DeleteSandboxRequest)(nil), "blimp.cluster.v0.DeleteSandboxRequest")
 	proto.RegisterType((*DeleteSandboxResponse)(nil), "blimp.cluster.v0.DeleteSandboxResponse")
@@ -1820,115 +1955,131 @@ func init() {
 }
 
 var fileDescriptor_d156d5389f4d1cd6 = []byte{
-	// 1724 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x19, 0x5d, 0x73, 0xda, 0xc6,
-	0xd6, 0x02, 0x8c, 0xe1, 0x60, 0x40, 0x59, 0x3b, 0xbe, 0x5c, 0x25, 0x37, 0x76, 0x74, 0x6f, 0x12,
-	0x5f, 0xdf, 0x5c, 0xec, 0x71, 0xee, 0x67, 0x3a, 0x93, 0x04, 0x83, 0xe2, 0x50, 0xdb, 0xb2, 0x47,
-	0x80, 0xf3, 0x51, 0x77, 0x18, 0x01, 0x3b, 0xa0, 0x41, 0x20, 0xa2, 0x15, 0x24, 0xee, 0x4b, 0xa7,
-	0x4f, 0xcd, 0x63, 0x7f, 0x45, 0x5f, 0xfb, 0x07, 0xfa, 0xde, 0xf7, 0x3e, 0xf6, 0xcf, 0xa4, 0xb3,
-	0x5a, 0x49, 0x96, 0x40, 0xb6, 0x31, 0x13, 0x67, 0xa6, 0x4f, 0xec, 0x9e, 0x3d, 0xdf, 0x7b, 0xce,
-	0xd9, 0x73, 0x04, 0xdc, 0x69, 0xe8, 0x5a, 0x6f, 0xb0, 0xd9, 0xd4, 0x87, 0xc4, 0xc2, 0xe6, 0xe6,
-	0x68, 0x6b, 0xb3, 0xa7, 0xf6, 0xd5, 0x36, 0x36, 0xf3, 0x03, 0xd3, 0xb0, 0x0c, 0xc4, 0xdb, 0xe7,
-	0x79, 0xe7, 0x3c, 0x3f, 0xda, 0x12, 0x72, 0x8c, 0x42, 0x1d, 0x5a, 0x1d, 0x8a, 0x4e, 0x7f, 0x19,
-	0xae, 0x70, 0x9b, 0x9d, 0x60, 0xd3, 0x34, 0x4c, 0x42, 0xcf, 0xd8, 0x8a, 0x9d, 0x8a, 0x9b, 0xb0,
-	0x54, 0xec, 0xe0, 0x66, 0xf7, 0x18, 0x9b, 0x44, 0x33, 0xfa, 0x0a, 0x7e, 0x3b, 0xc4, 0xc4, 0x42,
-	0x39, 0x58, 0x18, 0x31, 0x48, 0x8e, 0x5b, 0xe3, 0xd6, 0x93, 0x8a, 0xbb, 0x15, 0x7f, 0xe6, 0x60,
-	0x39, 0x48, 0x41, 0x06, 0x46, 0x9f, 0xe0, 0xf3, 0x49, 0xd0, 0x03, 0xc8, 0xb6, 0x34, 0x32, 0xd0,
-	0xd5, 0xd3, 0x7a, 0x0f, 0x13, 0xa2, 0xb6, 0x71, 0x2e, 0x62, 0x63, 0x64, 0x1c, 0xf0, 0x01, 0x83,
-	0xa2, 0x47, 0x10, 0x57, 0x9b, 0x16, 0xe5, 0x10, 0x5d, 0xe3, 0xd6, 0x33, 0xdb, 0xb7, 0xf2, 0xe3,
-	0x76, 0xe6, 0x8b, 0xfb, 0xe5, 0x82, 0x8d, 0xa2, 0x38, 0xa8, 0xe8, 0x21, 0xcc, 0xdb, 0x16, 0xe5,
-	0x62, 0x6b, 0xdc, 0x7a, 0x6a, 0x7b, 0xc5, 0xa1, 0x71, 0xac, 0x1c, 0x6d, 0xe5, 0x25, 0xba, 0x52,
-	0x18, 0x92, 0xf8, 0x7d, 0x0c, 0x96, 0x8b, 0x26, 0x56, 0x2d, 0x5c, 0x51, 0xfb, 0xad, 0x86, 0xf1,
-	0xde, 0xb5, 0xf8, 0x16, 0x24, 0x0d, 0xbd, 0x55, 0xb7, 0x8c, 0x2e, 0x76, 0x0d, 0x48, 0x18, 0x7a,
-	0xab, 0x4a, 0xf7, 0xe8, 0x21, 0xc4, 0xa8, 0x47, 0x73, 0xf3, 0xb6, 0x88, 0x9c, 0x23, 0xc2, 0x76,
-	0xf2, 0x68, 0x2b, 0xbf, 0x43, 0x77, 0x85, 0xa1, 0xd5, 0x51, 0x6c, 0x2c, 0xb4, 0x06, 0xa9, 0xa6,
-	0xd1, 0x1b, 0x18, 0x04, 0x3f, 0xd7, 0x74, 0xd7, 0x56, 0x3f, 0x08, 0xbd, 0x85, 0x25, 0x13, 0xb7,
-	0x35, 0x62, 0x99, 0xa7, 0x45, 0x13, 0xb7, 0x70, 0xdf, 0xd2, 0x54, 0x9d, 0xe4, 0xa2, 0x6b, 0xd1,
-	0xf5, 0xd4, 0xf6, 0xd3, 0x10, 0xab, 0x43, 0x34, 0xce, 0x2b, 0x93, 0x1c, 0xa4, 0xbe, 0x65, 0x9e,
-	0x2a, 0x61, 0xbc, 0x51, 0x1d, 0xd2, 0xe4, 0xb4, 0xdf, 0xc4, 0xad, 0xe7, 0x86, 0xde, 0xc2, 0x26,
-	0xc9, 0xc5, 0x6c, 0x61, 0xff, 0x9f, 0x52, 0x58, 0xc5, 0x4f, 0xcb, 0xc4, 0x04, 0xf9, 0x09, 0x3a,
-	0xe4, 0xce, 0xd3, 0x08, 0xf1, 0x10, 0xed, 0xe2, 0x53, 0xc7, 0xad, 0x74, 0x89, 0x1e, 0xc3, 0xfc,
-	0x48, 0xd5, 0x87, 0xcc, 0x3b, 0xa9, 0xed, 0xbf, 0x4d, 0xaa, 0x31, 0xc9, 0x4c, 0x61, 0x24, 0x8f,
-	0x23, 0xff, 0xe3, 0x84, 0x67, 0x80, 0x26, 0x55, 0x0a, 0x91, 0xb3, 0xec, 0x97, 0x93, 0xf4, 0x71,
-	0x10, 0xf7, 0x01, 0x4d, 0x8a, 0x40, 0x02, 0x24, 0x86, 0x04, 0x9b, 0x7d, 0xb5, 0x87, 0xdd, 0x28,
-	0x70, 0xf7, 0xf4, 0x6c, 0xa0, 0x12, 0xf2, 0xce, 0x30, 0x5b, 0x0e, 0x3b, 0x6f, 0x2f, 0x36, 0x61,
-	0xa5, 0x60, 0x59, 0x6a, 0xb3, 0x53, 0x35, 0x66, 0x09, 0xac, 0xc8, 0x34, 0x81, 0x25, 0xfe, 0xca,
-	0xc1, 0x9f, 0x26, 0xa4, 0x38, 0xe9, 0xe7, 0xa5, 0x01, 0x37, 0x45, 0x1a, 0xd0, 0x10, 0x95, 0x8d,
-	0x16, 0x2e, 0xb4, 0x5a, 0x26, 0x26, 0xc4, 0x0d, 0x51, 0x1f, 0x88, 0x1a, 0x4b, 0xb7, 0x45, 0x6c,
-	0x5a, 0x76, 0x36, 0x26, 0x15, 0x6f, 0x8f, 0xf6, 0x20, 0xdb, 0x1d, 0x36, 0xb0, 0x3f, 0x74, 0x59,
-	0xf2, 0xdd, 0x9d, 0xbc, 0xc6, 0xbd, 0x20, 0xa2, 0x32, 0x4e, 0x29, 0xfe, 0x12, 0x81, 0x9b, 0x63,
-	0x21, 0xf7, 0x07, 0x37, 0x09, 0xdd, 0x87, 0x4c, 0xb9, 0xa7, 0xb6, 0xb1, 0xac, 0xf6, 0x30, 0x19,
-	0xa8, 0x4d, 0x6c, 0x17, 0x8e, 0xa4, 0x32, 0x06, 0xa5, 0x25, 0xd3, 0x2d, 0x88, 0x71, 0x56, 0x32,
-	0x7b, 0x13, 0x95, 0x70, 0x61, 0xea, 0x4a, 0x28, 0xfe, 0x10, 0x81, 0x74, 0x09, 0x0f, 0x74, 0xe3,
-	0xf4, 0x4a, 0xb1, 0x17, 0xfb, 0x44, 0x45, 0x4d, 0x81, 0x54, 0x63, 0xa8, 0xe9, 0x96, 0x6d, 0xa4,
-	0x5b, 0xcc, 0xb6, 0x26, 0x15, 0x0f, 0xa8, 0x98, 0xdf, 0x39, 0x23, 0x61, 0x65, 0xc5, 0xcf, 0x44,
-	0x78, 0x02, 0xfc, 0x38, 0xc2, 0x95, 0x92, 0xfc, 0x09, 0x64, 0x5c, 0x71, 0xb3, 0x04, 0x95, 0x68,
-	0x40, 0x76, 0xec, 0xb6, 0x11, 0x82, 0x58, 0xc7, 0x20, 0x96, 0x23, 0xdf, 0x5e, 0x53, 0x05, 0x9a,
-	0x6a, 0xd1, 0xb4, 0x5c, 0x05, 0xec, 0x0d, 0x85, 0x32, 0xcf, 0xb3, 0x60, 0x63, 0x1b, 0x74, 0x1b,
-	0x92, 0x7d, 0x2f, 0x2e, 0x62, 0xf6, 0xc9, 0x19, 0x40, 0xfc, 0xc0, 0xc1, 0x72, 0x09, 0xeb, 0x78,
-	0xb6, 0xf7, 0x29, 0x3a, 0xd5, 0x55, 0xde, 0x83, 0x4c, 0xcb, 0x16, 0x51, 0x1f, 0x19, 0xfa, 0xb0,
-	0x87, 0x59, 0xb2, 0x24, 0x94, 0x34, 0x83, 0x1e, 0x33, 0xa0, 0x28, 0xc1, 0xcd, 0x31, 0x4d, 0x66,
-	0x72, 0xe1, 0xd7, 0xc0, 0xef, 0x62, 0xab, 0x62, 0xa9, 0xd6, 0x90, 0x5c, 0x43, 0x4d, 0xfc, 0x06,
-	0x6e, 0xf8, 0xd8, 0xcf, 0x54, 0x39, 0xfe, 0x0b, 0x71, 0x62, 0xd3, 0x3b, 0x22, 0x57, 0x27, 0x63,
-	0xd6, 0x71, 0x81, 0x23, 0xc6, 0x41, 0x17, 0x7f, 0x8b, 0x40, 0x3a, 0x70, 0x82, 0xca, 0x90, 0x20,
-	0xd8, 0x1c, 0x69, 0x4d, 0x4c, 0x72, 0x9c, 0x9d, 0x00, 0xff, 0xbc, 0x84, 0x59, 0xbe, 0xe2, 0xe0,
-	0xb3, 0xe8, 0xf7, 0xc8, 0xd1, 0x0e, 0xcc, 0x0f, 0x3a, 0x2a, 0x61, 0x41, 0x9d, 0xd9, 0x7e, 0x78,
-	0x29, 0x1f, 0xb6, 0x3b, 0xa2, 0x34, 0x0a, 0x23, 0x15, 0x4e, 0x20, 0x1d, 0x60, 0x1f, 0x92, 0x3b,
-	0xff, 0x0e, 0x3e, 0xc4, 0x61, 0xb6, 0x33, 0x0e, 0x8e, 0xed, 0xbe, 0xe4, 0x3a, 0x81, 0x45, 0xbf,
-	0x50, 0x94, 0x82, 0x85, 0x9a, 0xbc, 0x27, 0x1f, 0xbe, 0x94, 0xf9, 0x39, 0xba, 0x51, 0x6a, 0xb2,
-	0x5c, 0x96, 0x77, 0x79, 0x0e, 0x65, 0x21, 0x55, 0x95, 0x94, 0x83, 0xb2, 0x5c, 0xa8, 0x52, 0x40,
-	0x04, 0x21, 0xc8, 0x94, 0x0e, 0xa5, 0x4a, 0x5d, 0x3e, 0xac, 0xd6, 0xa5, 0x57, 0xe5, 0x4a, 0x95,
-	0x8f, 0xa2, 0x34, 0x24, 0x8f, 0x14, 0xe9, 0xa8, 0xa0, 0x50, 0x94, 0x98, 0xf8, 0xde, 0xd3, 0xdd,
-	0xf1, 0xed, 0xbf, 0x5c, 0x87, 0x70, 0xb6, 0x43, 0xee, 0x9c, 0xab, 0xa9, 0xdf, 0x05, 0xd4, 0xe2,
-	0x1e, 0x69, 0x3b, 0x89, 0x49, 0x97, 0x68, 0x15, 0x52, 0x1d, 0x95, 0xd4, 0x89, 0xa5, 0x9a, 0x16,
-	0x6e, 0xd9, 0x39, 0x93, 0x50, 0xa0, 0xa3, 0x92, 0x0a, 0x83, 0x88, 0x43, 0xc8, 0x28, 0xd8, 0x3e,
-	0xbe, 0x86, 0xe4, 0xcb, 0xc1, 0x82, 0x73, 0xc5, 0x8e, 0x4e, 0xee, 0x56, 0x7c, 0x0a, 0x59, 0x4f,
-	0xec, 0x4c, 0x99, 0x56, 0x81, 0x6c, 0x55, 0x6d, 0xdb, 0xa5, 0xd2, 0xd7, 0xc7, 0xbb, 0xd2, 0xb8,
-	0x80, 0x34, 0x5a, 0x9c, 0xb4, 0xde, 0x59, 0x2b, 0xce, 0x36, 0xd4, 0x5b, 0x96, 0xda, 0x76, 0x0a,
-	0x16, 0x5d, 0x8a, 0x1f, 0x23, 0xc0, 0xbb, 0x5c, 0xc9, 0x35, 0xbc, 0x2b, 0x45, 0x48, 0x59, 0x6a,
-	0xdb, 0x61, 0x4c, 0x33, 0x30, 0x1a, 0xfe, 0xe8, 0x8e, 0x59, 0xa6, 0xf8, 0xa9, 0x50, 0xef, 0xa2,
-	0x7e, 0xfa, 0x8b, 0xf3, 0x99, 0x91, 0x99, 0x7a, 0xe9, 0xcf, 0xdb, 0xea, 0x8a, 0x5f, 0xc1, 0x0d,
-	0x9f, 0xbe, 0x67, 0xd3, 0xd6, 0x39, 0x17, 0xeb, 0xc5, 0x4c, 0x64, 0x9a, 0x98, 0xf9, 0xc0, 0x41,
-	0x5a, 0x7a, 0x4f, 0xdf, 0xf0, 0x6b, 0xb8, 0xdb, 0x73, 0x63, 0x9d, 0x3e, 0xa2, 0x03, 0xc3, 0x69,
-	0xc3, 0xd2, 0x8a, 0xbd, 0x16, 0x15, 0xc8, 0xb8, 0x9a, 0xcc, 0x54, 0xc6, 0x11, 0xc4, 0x74, 0xad,
-	0xdf, 0x75, 0x44, 0xd9, 0x6b, 0xf1, 0x04, 0xb2, 0xb5, 0x3e, 0xbe, 0xba, 0x7d, 0xd3, 0xbd, 0x3d,
-	0xcf, 0x80, 0x3f, 0xe3, 0x3e, 0x53, 0xca, 0x62, 0xc8, 0xed, 0x62, 0x2b, 0xd8, 0x16, 0x5e, 0x83,
-	0xa2, 0x6d, 0xf8, 0x73, 0x88, 0x98, 0x99, 0xbc, 0x1c, 0x68, 0x5f, 0x22, 0xe3, 0xed, 0x4b, 0x1d,
-	0xd0, 0x2e, 0xb6, 0x68, 0xcb, 0xd6, 0xea, 0x6a, 0xd6, 0x35, 0x58, 0xf2, 0x1d, 0x07, 0x4b, 0x01,
-	0x09, 0x9f, 0x7f, 0x56, 0x10, 0x3f, 0x72, 0x70, 0xd3, 0xd6, 0xab, 0x36, 0x38, 0x32, 0xf1, 0x48,
-	0xc3, 0xef, 0x5c, 0x43, 0xaf, 0xf6, 0x9d, 0x00, 0x41, 0xcc, 0xc4, 0x03, 0xc3, 0x0d, 0x58, 0xba,
-	0x46, 0x22, 0x2c, 0xfa, 0x7a, 0x6a, 0x56, 0xc2, 0x92, 0x4a, 0x00, 0x86, 0x76, 0x20, 0x8a, 0xfb,
-	0x23, 0x67, 0x80, 0x0f, 0x69, 0xb0, 0x43, 0x75, 0xcb, 0x4b, 0xfd, 0x11, 0x2b, 0x69, 0x94, 0x58,
-	0xf8, 0x0f, 0x24, 0x5c, 0xc0, 0x55, 0x1a, 0xea, 0x2f, 0x63, 0x09, 0x8e, 0x8f, 0x88, 0xdf, 0xc2,
-	0xca, 0xb8, 0x90, 0x99, 0xee, 0x61, 0x15, 0x52, 0xce, 0x33, 0x5c, 0x6f, 0xea, 0x9a, 0xd3, 0x86,
-	0x82, 0x03, 0x2a, 0xea, 0x1a, 0x5a, 0x81, 0xb8, 0x31, 0xb4, 0x06, 0x43, 0x76, 0x09, 0x8b, 0x8a,
-	0xb3, 0xdb, 0xf8, 0x0b, 0x24, 0xbd, 0xf9, 0x07, 0xc5, 0x21, 0x72, 0xb8, 0xc7, 0xcf, 0xa1, 0x04,
-	0xc4, 0xa4, 0x57, 0xe5, 0x2a, 0xcf, 0x6d, 0xfc, 0xc8, 0xc1, 0xa2, 0xbf, 0x19, 0x08, 0xb6, 0x26,
-	0x39, 0x58, 0x2e, 0xcb, 0xe5, 0x6a, 0xb9, 0xb0, 0x5f, 0x7e, 0x53, 0x96, 0x77, 0xeb, 0xc7, 0x87,
-	0xfb, 0xb5, 0x03, 0xa9, 0xc2, 0x73, 0x68, 0x09, 0xb2, 0x2f, 0x0b, 0xe5, 0x6a, 0xbd, 0x24, 0x1d,
-	0x49, 0x72, 0xa9, 0x52, 0x3f, 0x94, 0x59, 0xaf, 0x62, 0x03, 0x2b, 0xaf, 0xe5, 0x62, 0x7d, 0xa7,
-	0x2c, 0x97, 0xf8, 0x28, 0xe5, 0x47, 0x31, 0xec, 0x4e, 0xc5, 0xdf, 0xea, 0xcc, 0x23, 0x80, 0x38,
-	0x55, 0x42, 0x2a, 0xf1, 0x71, 0xda, 0xd1, 0xd4, 0xe4, 0x17, 0x52, 0x61, 0xbf, 0xfa, 0xe2, 0x35,
-	0xbf, 0x80, 0x6e, 0x40, 0xba, 0x26, 0x57, 0x8a, 0x2f, 0xa4, 0x52, 0x6d, 0xbf, 0xb0, 0xb3, 0x2f,
-	0xf1, 0x89, 0xed, 0x9f, 0x00, 0x16, 0x0e, 0xd8, 0xa7, 0x3d, 0xd4, 0x81, 0xec, 0xd8, 0x70, 0x8f,
-	0xd6, 0x27, 0x2f, 0x37, 0xfc, 0x2b, 0x83, 0xf0, 0xf7, 0x29, 0x30, 0xd9, 0x15, 0x89, 0x73, 0xa8,
-	0x0d, 0x99, 0xe0, 0xf5, 0xa1, 0x07, 0x53, 0x46, 0x91, 0xb0, 0x7e, 0x39, 0xa2, 0x2b, 0x66, 0x8b,
-	0x43, 0x0d, 0x48, 0x07, 0x46, 0x7b, 0x74, 0x7f, 0xba, 0xcf, 0x4d, 0xc2, 0x83, 0x4b, 0xf1, 0x3c,
-	0x63, 0x8e, 0x21, 0xcb, 0x46, 0xbc, 0x33, 0xb7, 0xad, 0x5e, 0x32, 0x74, 0x0a, 0x6b, 0xe7, 0x23,
-	0x78, 0x7c, 0x1b, 0x74, 0x98, 0xf6, 0x8d, 0x3f, 0x61, 0xba, 0x87, 0x4d, 0x6a, 0x61, 0xba, 0x87,
-	0xce, 0x51, 0xe2, 0x1c, 0x3a, 0x81, 0x94, 0xaf, 0x98, 0xa1, 0x90, 0xd6, 0x60, 0xb2, 0x9a, 0x0a,
-	0xf7, 0x2e, 0xc1, 0xf2, 0x79, 0x26, 0xe9, 0x8d, 0x46, 0x48, 0x0c, 0xa5, 0x0a, 0x8c, 0x65, 0xc2,
-	0x5f, 0x2f, 0xc4, 0xf1, 0xf8, 0xf6, 0xed, 0x91, 0x6b, 0xec, 0x5b, 0xc6, 0x46, 0x28, 0x6d, 0xe8,
-	0xcb, 0x26, 0xfc, 0x63, 0x2a, 0x5c, 0x4f, 0xde, 0x1b, 0x48, 0xbd, 0x54, 0xad, 0x66, 0xe7, 0x93,
-	0x5b, 0xb2, 0xc5, 0xa1, 0x3a, 0x2c, 0xfa, 0xbf, 0x66, 0xa3, 0x10, 0xe7, 0x86, 0x7c, 0x1f, 0x17,
-	0xee, 0x5f, 0x86, 0xe6, 0x29, 0x7f, 0x04, 0x0b, 0x4e, 0x57, 0x8f, 0xd6, 0xc2, 0x3a, 0x3f, 0xff,
-	0x9c, 0x21, 0xdc, 0xbd, 0x00, 0xc3, 0xe3, 0xf8, 0x0a, 0x92, 0x5e, 0x3f, 0x18, 0xe6, 0x8c, 0xf1,
-	0xe6, 0x36, 0xcc, 0x19, 0x13, 0x0d, 0xa5, 0xed, 0x8c, 0x03, 0x88, 0xb3, 0x0e, 0x2c, 0x2c, 0x83,
-	0x02, 0x5d, 0x62, 0x58, 0x06, 0x05, 0x9b, 0x37, 0x71, 0x0e, 0x55, 0x20, 0xe1, 0xb6, 0x47, 0x28,
-	0xc4, 0xb2, 0xb1, 0xc6, 0x4c, 0x10, 0x2f, 0x42, 0x71, 0x99, 0xee, 0x6c, 0xbc, 0x59, 0x6f, 0x6b,
-	0x56, 0x67, 0xd8, 0xc8, 0x37, 0x8d, 0xde, 0x66, 0x17, 0xeb, 0x2d, 0x75, 0x93, 0xfd, 0xc3, 0x31,
-	0xe8, 0xb6, 0x37, 0xed, 0x3f, 0x35, 0xdc, 0xff, 0x4d, 0x1a, 0x71, 0x7b, 0xfb, 0xe8, 0xf7, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0xf6, 0x90, 0x1d, 0xe1, 0x4f, 0x19, 0x00, 0x00,
+	// 1984 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x39, 0xcd, 0x73, 0xdb, 0xd6,
+	0xf1, 0x06, 0x45, 0xf1, 0x63, 0x29, 0x92, 0xf0, 0xb3, 0xec, 0x1f, 0x83, 0xe4, 0x17, 0x2b, 0x48,
+	0x63, 0xab, 0xaa, 0x4b, 0x69, 0xe4, 0x7e, 0xba, 0x33, 0x4d, 0x28, 0x12, 0x96, 0x59, 0x53, 0x90,
+	0x06, 0x24, 0x6d, 0xc7, 0x75, 0x87, 0x03, 0x01, 0x2f, 0x24, 0x2b, 0x90, 0x40, 0xf0, 0x40, 0x26,
+	0xea, 0xa5, 0xd3, 0x5b, 0xce, 0xfd, 0x47, 0x7a, 0xe8, 0xa5, 0x87, 0xde, 0x3b, 0xd3, 0x63, 0x0f,
+	0x3d, 0xf4, 0xda, 0x3f, 0xa4, 0x9d, 0xf7, 0x1e, 0x00, 0x01, 0x24, 0x28, 0x52, 0x9c, 0x28, 0x33,
+	0x3d, 0x11, 0xbb, 0xd8, 0x6f, 0xec, 0xee, 0xdb, 0x7d, 0x84, 0x0f, 0xcf, 0xad, 0xe1, 0xc8, 0xd9,
+	0x37, 0xac, 0x09, 0xf1, 0xb0, 0xbb, 0x3f, 0x3d, 0xd8, 0x1f, 0xe9, 0x63, 0xbd, 0x8f, 0xdd, 0xaa,
+	0xe3, 0xda, 0x9e, 0x8d, 0x44, 0xf6, 0xbe, 0xea, 0xbf, 0xaf, 0x4e, 0x0f, 0xa4, 0x0a, 0xe7, 0xd0,
+	0x27, 0xde, 0x80, 0x92, 0xd3, 0x5f, 0x4e, 0x2b, 0x7d, 0xc0, 0xdf, 0x60, 0xd7, 0xb5, 0x5d, 0x42,
+	0xdf, 0xf1, 0x27, 0xfe, 0x56, 0xde, 0x87, 0x7b, 0xf5, 0x01, 0x36, 0x2e, 0x5e, 0x61, 0x97, 0x0c,
+	0xed, 0xb1, 0x86, 0xbf, 0x9c, 0x60, 0xe2, 0xa1, 0x0a, 0x64, 0xa7, 0x1c, 0x53, 0x11, 0x76, 0x84,
+	0xdd, 0xbc, 0x16, 0x80, 0xf2, 0x5f, 0x05, 0xd8, 0x8e, 0x73, 0x10, 0xc7, 0x1e, 0x13, 0xbc, 0x98,
+	0x05, 0x3d, 0x86, 0xb2, 0x39, 0x24, 0x8e, 0xa5, 0x5f, 0xf6, 0x46, 0x98, 0x10, 0xbd, 0x8f, 0x2b,
+	0x29, 0x46, 0x51, 0xf2, 0xd1, 0x27, 0x1c, 0x8b, 0x9e, 0x42, 0x46, 0x37, 0x3c, 0x2a, 0x61, 0x63,
+	0x47, 0xd8, 0x2d, 0x1d, 0xbe, 0x5f, 0x9d, 0xf5, 0xb3, 0x5a, 0x6f, 0x35, 0x6b, 0x8c, 0x44, 0xf3,
+	0x49, 0xd1, 0x13, 0xd8, 0x64, 0x1e, 0x55, 0xd2, 0x3b, 0xc2, 0x6e, 0xe1, 0xf0, 0x81, 0xcf, 0xe3,
+	0x7b, 0x39, 0x3d, 0xa8, 0x2a, 0xf4, 0x49, 0xe3, 0x44, 0xf2, 0x1f, 0x37, 0x61, 0xbb, 0xee, 0x62,
+	0xdd, 0xc3, 0x6d, 0x7d, 0x6c, 0x9e, 0xdb, 0x5f, 0x07, 0x1e, 0xbf, 0x0f, 0x79, 0xdb, 0x32, 0x7b,
+	0x9e, 0x7d, 0x81, 0x03, 0x07, 0x72, 0xb6, 0x65, 0x76, 0x28, 0x8c, 0x9e, 0x40, 0x9a, 0x46, 0xb4,
+	0xb2, 0xc9, 0x54, 0x54, 0x7c, 0x15, 0x2c, 0xc8, 0xd3, 0x83, 0xea, 0x11, 0x85, 0x6a, 0x13, 0x6f,
+	0xa0, 0x31, 0x2a, 0xb4, 0x03, 0x05, 0xc3, 0x1e, 0x39, 0x36, 0xc1, 0xcf, 0x87, 0x56, 0xe0, 0x6b,
+	0x14, 0x85, 0xbe, 0x84, 0x7b, 0x2e, 0xee, 0x0f, 0x89, 0xe7, 0x5e, 0xd6, 0x5d, 0x6c, 0xe2, 0xb1,
+	0x37, 0xd4, 0x2d, 0x52, 0xd9, 0xd8, 0xd9, 0xd8, 0x2d, 0x1c, 0x7e, 0x9a, 0xe0, 0x75, 0x82, 0xc5,
+	0x55, 0x6d, 0x5e, 0x82, 0x32, 0xf6, 0xdc, 0x4b, 0x2d, 0x49, 0x36, 0xea, 0x41, 0x91, 0x5c, 0x8e,
+	0x0d, 0x6c, 0x3e, 0xb7, 0x2d, 0x13, 0xbb, 0xa4, 0x92, 0x66, 0xca, 0x7e, 0xbe, 0xa2, 0xb2, 0x76,
+	0x94, 0x97, 0xab, 0x89, 0xcb, 0x43, 0x27, 0x90, 0x25, 0xd8, 0x70, 0xb1, 0x47, 0x2a, 0x19, 0x26,
+	0xfa, 0xe9, 0xaa, 0xa2, 0x39, 0x17, 0x17, 0x1a, 0xc8, 0x90, 0x2c, 0xa8, 0x2c, 0x72, 0x10, 0x89,
+	0xb0, 0x71, 0x81, 0x2f, 0xfd, 0xaf, 0x44, 0x1f, 0xd1, 0x33, 0xd8, 0x9c, 0xea, 0xd6, 0x84, 0x07,
+	0xbb, 0x70, 0xf8, 0xbd, 0x79, 0xd5, 0xf3, 0xc2, 0x34, 0xce, 0xf2, 0x2c, 0xf5, 0x33, 0x41, 0xfa,
+	0x0c, 0xd0, 0xbc, 0x87, 0x09, 0x7a, 0xb6, 0xa3, 0x7a, 0xf2, 0x51, 0x09, 0xcf, 0x60, 0x2b, 0xea,
+	0xc8, 0x32, 0xde, 0xad, 0x08, 0xaf, 0xdc, 0x02, 0x34, 0x6f, 0x1e, 0x92, 0x20, 0x37, 0x21, 0xd8,
+	0x1d, 0xeb, 0x23, 0x1c, 0x24, 0x64, 0x00, 0xd3, 0x77, 0x8e, 0x4e, 0xc8, 0x57, 0xb6, 0x6b, 0xfa,
+	0xa6, 0x84, 0xb0, 0x6c, 0xc0, 0x83, 0x9a, 0xe7, 0xe9, 0xc6, 0xa0, 0x63, 0xaf, 0x93, 0xe3, 0xa9,
+	0x55, 0x72, 0x5c, 0xfe, 0x87, 0x00, 0xff, 0x37, 0xa7, 0xc5, 0xef, 0x04, 0x61, 0x45, 0x0a, 0x2b,
+	0x54, 0x24, 0xad, 0x16, 0xd5, 0x36, 0x71, 0xcd, 0x34, 0x5d, 0x4c, 0x48, 0x50, 0x2d, 0x11, 0x14,
+	0x75, 0x96, 0x82, 0x75, 0xec, 0x7a, 0xac, 0x31, 0xe4, 0xb5, 0x10, 0x46, 0x2f, 0xa1, 0x7c, 0x31,
+	0x39, 0xc7, 0xd1, 0x2a, 0xe2, 0x7d, 0xe0, 0xa3, 0xf9, 0x14, 0x78, 0x19, 0x27, 0xd4, 0x66, 0x39,
+	0xe5, 0xbf, 0xa5, 0xe0, 0xfe, 0x4c, 0x8a, 0xfe, 0x8f, 0xbb, 0x84, 0x1e, 0x41, 0xa9, 0x39, 0xd2,
+	0xfb, 0x58, 0xd5, 0x47, 0x98, 0x38, 0xba, 0x81, 0x59, 0x0f, 0xcb, 0x6b, 0x33, 0x58, 0xda, 0xbd,
+	0x83, 0xde, 0x9c, 0xe1, 0xdd, 0x7b, 0x34, 0xd7, 0x94, 0xb3, 0x2b, 0x37, 0x65, 0xf9, 0xef, 0x69,
+	0x28, 0x36, 0xb0, 0x63, 0xd9, 0x97, 0x37, 0xca, 0xbd, 0xf4, 0xb7, 0xd4, 0x5f, 0x35, 0x28, 0x9c,
+	0x4f, 0x86, 0x96, 0xc7, 0x9c, 0x0c, 0xfa, 0xea, 0xc1, 0xbc, 0xe1, 0x31, 0x13, 0xab, 0x47, 0x57,
+	0x2c, 0xbc, 0x19, 0x45, 0x85, 0xa0, 0xe7, 0x90, 0x35, 0xec, 0xf1, 0x17, 0xc3, 0x3e, 0xa9, 0x6c,
+	0x32, 0x79, 0x4f, 0x96, 0xc9, 0xab, 0x73, 0x72, 0xbf, 0xb1, 0xf9, 0xcc, 0xd4, 0x7a, 0xc7, 0xb5,
+	0x7f, 0x8b, 0x0d, 0x8f, 0x46, 0xdf, 0x8f, 0x76, 0x14, 0x15, 0x58, 0x6f, 0xb2, 0x9c, 0x22, 0x95,
+	0xec, 0xea, 0xd6, 0xfb, 0x2c, 0x11, 0xeb, 0x7d, 0x8c, 0xf4, 0x4b, 0x10, 0x67, 0xdd, 0xbb, 0x69,
+	0x7b, 0x8b, 0xba, 0x73, 0x93, 0xf6, 0x16, 0xe8, 0x8e, 0x1a, 0x77, 0x13, 0xdd, 0xf2, 0xbf, 0x05,
+	0x28, 0x05, 0xbe, 0xae, 0x55, 0x8f, 0xc7, 0x90, 0x35, 0x06, 0xfa, 0x98, 0xa6, 0x42, 0x8a, 0x05,
+	0xf3, 0x87, 0x8b, 0x83, 0xc9, 0x15, 0x54, 0xeb, 0x9c, 0x3e, 0xf8, 0x76, 0x1c, 0x92, 0xde, 0xc2,
+	0x56, 0xf4, 0x45, 0x82, 0x17, 0x3f, 0x8a, 0x7a, 0x51, 0x3a, 0xfc, 0x70, 0x91, 0x22, 0x2e, 0x26,
+	0xea, 0xa5, 0x0d, 0xe5, 0x99, 0x6a, 0x46, 0x08, 0xd2, 0x03, 0x9b, 0x78, 0xbe, 0x7c, 0xf6, 0x4c,
+	0xc3, 0x64, 0xe8, 0x75, 0xd7, 0x0b, 0xc2, 0xc4, 0x00, 0x8a, 0xe5, 0x95, 0xc5, 0x9b, 0x09, 0x07,
+	0xd0, 0x07, 0x90, 0x1f, 0x87, 0x75, 0x9f, 0x66, 0x6f, 0xae, 0x10, 0xf2, 0x37, 0x02, 0x6c, 0x37,
+	0xb0, 0x85, 0xd7, 0x1b, 0x85, 0x36, 0x56, 0x2a, 0xd5, 0x4f, 0xa0, 0x64, 0x32, 0x15, 0xbd, 0xa9,
+	0x6d, 0x4d, 0x46, 0x98, 0x37, 0xc3, 0x9c, 0x56, 0xe4, 0xd8, 0x57, 0x1c, 0x29, 0x2b, 0x70, 0x7f,
+	0xc6, 0x92, 0x75, 0xbe, 0xb3, 0xfc, 0x1b, 0x10, 0x8f, 0xb1, 0xd7, 0xf6, 0x74, 0x6f, 0x42, 0x6e,
+	0xe1, 0xcc, 0xfb, 0x1d, 0xdc, 0x8d, 0x88, 0x5f, 0x2b, 0x13, 0x7f, 0x0a, 0x19, 0xc2, 0xf8, 0x7d,
+	0x95, 0x0f, 0xe7, 0xf3, 0xc3, 0x0f, 0x81, 0xaf, 0xc6, 0x27, 0x97, 0xff, 0x95, 0x82, 0x62, 0xec,
+	0x0d, 0x6a, 0x42, 0x8e, 0x60, 0x77, 0x3a, 0x34, 0x30, 0xa9, 0x08, 0x8b, 0xb2, 0x3a, 0xc6, 0x52,
+	0x6d, 0xfb, 0xf4, 0x3c, 0xab, 0x43, 0x76, 0x74, 0x04, 0x9b, 0xce, 0x40, 0x27, 0x41, 0xd2, 0x3e,
+	0x59, 0x2a, 0x87, 0x43, 0x67, 0x94, 0x47, 0xe3, 0xac, 0xd2, 0x3b, 0x28, 0xc6, 0xc4, 0x27, 0xd4,
+	0xc6, 0x8f, 0xe3, 0x43, 0x5a, 0x92, 0xef, 0x5c, 0x82, 0xef, 0x7b, 0xa4, 0x38, 0xde, 0xc1, 0x56,
+	0x54, 0x29, 0x2a, 0x40, 0xb6, 0xab, 0xbe, 0x54, 0x4f, 0x5f, 0xab, 0xe2, 0x1d, 0x0a, 0x68, 0x5d,
+	0x55, 0x6d, 0xaa, 0xc7, 0xa2, 0x80, 0xca, 0x50, 0xe8, 0x28, 0xda, 0x49, 0x53, 0xad, 0x75, 0x28,
+	0x22, 0x85, 0x10, 0x94, 0x1a, 0xa7, 0x4a, 0xbb, 0xa7, 0x9e, 0x76, 0x7a, 0xca, 0x9b, 0x66, 0xbb,
+	0x23, 0x6e, 0xa0, 0x22, 0xe4, 0xcf, 0x34, 0xe5, 0xac, 0xa6, 0x51, 0x92, 0xb4, 0xfc, 0x4f, 0x01,
+	0x8a, 0x31, 0xd5, 0xb4, 0x8c, 0x79, 0x44, 0x84, 0x45, 0x65, 0xec, 0xd3, 0x47, 0x63, 0x40, 0x5d,
+	0x1e, 0x91, 0xbe, 0x5f, 0x99, 0xf4, 0x11, 0x3d, 0x84, 0xc2, 0x40, 0x27, 0x3d, 0xe2, 0xe9, 0xae,
+	0x87, 0x4d, 0x56, 0x34, 0x39, 0x0d, 0x06, 0x3a, 0x69, 0x73, 0x0c, 0x1d, 0x04, 0x5c, 0xec, 0x58,
+	0x43, 0x43, 0xe7, 0xa7, 0x7c, 0x51, 0x0b, 0x61, 0x5a, 0x3c, 0x2e, 0xd6, 0xcd, 0xcb, 0x5e, 0x48,
+	0xb1, 0xc9, 0x28, 0x8a, 0x0c, 0xab, 0x05, 0x64, 0xef, 0x41, 0xce, 0xb1, 0xcd, 0xde, 0xf8, 0xea,
+	0x34, 0xc9, 0x3a, 0xb6, 0x49, 0x4f, 0x12, 0x79, 0x02, 0x25, 0x0d, 0x33, 0xe5, 0xb7, 0x50, 0xdb,
+	0x15, 0x3a, 0xf0, 0xb3, 0x20, 0xf8, 0x1e, 0x07, 0xa0, 0xfc, 0x29, 0x94, 0x43, 0xb5, 0x6b, 0x15,
+	0x72, 0x1b, 0xca, 0x1d, 0xbd, 0xcf, 0xce, 0xaa, 0xc8, 0x46, 0x1a, 0x68, 0x13, 0x62, 0xda, 0x68,
+	0xef, 0x1b, 0x8e, 0xae, 0x96, 0x4a, 0x0e, 0xd0, 0x6f, 0xe1, 0xe9, 0x7d, 0xbf, 0x1f, 0xd2, 0x47,
+	0xf9, 0x3f, 0x29, 0x10, 0x03, 0xa9, 0xe4, 0x16, 0xc6, 0x92, 0x3a, 0x14, 0x3c, 0xbd, 0xef, 0x0b,
+	0x0e, 0x4e, 0x9a, 0x84, 0x99, 0x6d, 0xc6, 0x33, 0x2d, 0xca, 0x85, 0x46, 0xd7, 0x6d, 0x86, 0xbf,
+	0x58, 0x2c, 0x8c, 0xac, 0xb5, 0x15, 0x7e, 0xb7, 0x5b, 0x96, 0xfc, 0x6b, 0xb8, 0x1b, 0xb1, 0xf7,
+	0xea, 0xde, 0x60, 0xc1, 0x87, 0x0d, 0x73, 0x26, 0xb5, 0x4a, 0xce, 0x7c, 0x23, 0x40, 0x51, 0xf9,
+	0x9a, 0x8e, 0x80, 0xb7, 0xf0, 0x6d, 0x17, 0xe6, 0x3a, 0x3d, 0xa3, 0x1d, 0xdb, 0x9f, 0xe2, 0x8b,
+	0x1a, 0x7b, 0x96, 0x35, 0x28, 0x05, 0x96, 0xac, 0x75, 0x4a, 0x20, 0x48, 0x5b, 0xc3, 0xf1, 0x85,
+	0xaf, 0x8a, 0x3d, 0xcb, 0xef, 0xa0, 0xdc, 0x1d, 0xe3, 0x9b, 0xfb, 0xb7, 0xda, 0xd1, 0xf6, 0x19,
+	0x88, 0x57, 0xd2, 0xd7, 0x2a, 0x59, 0x0c, 0x95, 0x63, 0xec, 0xc5, 0xb7, 0x8a, 0x5b, 0x30, 0xb4,
+	0x0f, 0xef, 0x25, 0xa8, 0x59, 0x2b, 0xca, 0xb1, 0xe9, 0x28, 0x35, 0x3b, 0x1d, 0xf5, 0x00, 0x1d,
+	0x63, 0x8f, 0xcd, 0xad, 0x17, 0x43, 0xef, 0x16, 0x3c, 0xf9, 0x83, 0x00, 0xf7, 0x62, 0x1a, 0xbe,
+	0xfb, 0x55, 0x53, 0xfe, 0x73, 0x0a, 0xee, 0x33, 0xbb, 0xba, 0xce, 0x99, 0x8b, 0xa7, 0x43, 0xfc,
+	0x55, 0xe0, 0xe8, 0xcd, 0x6e, 0xbc, 0x10, 0xa4, 0x5d, 0xec, 0xd8, 0x41, 0xc2, 0xd2, 0x67, 0x24,
+	0xc3, 0x56, 0x64, 0x25, 0xe3, 0x2d, 0x2c, 0xaf, 0xc5, 0x70, 0xe8, 0x08, 0x36, 0xf0, 0x78, 0x5a,
+	0x49, 0x2f, 0xda, 0x70, 0x12, 0x6d, 0xab, 0x2a, 0xe3, 0x29, 0x6f, 0x69, 0x94, 0x99, 0xfa, 0xe7,
+	0xb8, 0xf6, 0x17, 0x4c, 0x47, 0x86, 0xe9, 0x08, 0x61, 0xf4, 0x00, 0x32, 0x96, 0x6d, 0x5c, 0x60,
+	0x93, 0xed, 0xae, 0x39, 0xcd, 0x87, 0xa4, 0x9f, 0x40, 0x2e, 0x10, 0x72, 0x93, 0x4d, 0xe4, 0x57,
+	0xe9, 0x9c, 0x20, 0xa6, 0xe4, 0xdf, 0xc3, 0x83, 0x59, 0xc3, 0xd6, 0xfa, 0x76, 0x0f, 0xa1, 0xe0,
+	0x0f, 0x06, 0x3d, 0xc3, 0x1a, 0xfa, 0x93, 0x31, 0xf8, 0xa8, 0xba, 0x35, 0xa4, 0xe6, 0xdb, 0x13,
+	0xcf, 0x99, 0xf0, 0x0f, 0xb7, 0xa5, 0xf9, 0xd0, 0xde, 0xff, 0x43, 0x3e, 0x5c, 0xb9, 0x51, 0x06,
+	0x52, 0xa7, 0x2f, 0xc5, 0x3b, 0x28, 0x07, 0x69, 0xe5, 0x4d, 0xb3, 0x23, 0x0a, 0x7b, 0x0d, 0xd8,
+	0x8a, 0x2e, 0x19, 0x74, 0xda, 0xe9, 0xaa, 0xf5, 0x17, 0x35, 0xf5, 0x58, 0x69, 0xf0, 0x71, 0xa9,
+	0xae, 0x29, 0xb5, 0x8e, 0xd2, 0x10, 0x05, 0x0a, 0x74, 0xcf, 0x1a, 0x0c, 0x48, 0x51, 0xa0, 0xa1,
+	0xb4, 0x14, 0x0a, 0x6c, 0xec, 0xfd, 0x45, 0xa0, 0x37, 0x5a, 0x57, 0x43, 0x4e, 0x7c, 0xe6, 0xaa,
+	0xc0, 0x76, 0x53, 0x6d, 0x76, 0x9a, 0xb5, 0x56, 0xf3, 0x6d, 0x53, 0x3d, 0xee, 0xbd, 0x3a, 0x6d,
+	0x75, 0x4f, 0x94, 0xb6, 0x28, 0xa0, 0x7b, 0x50, 0x7e, 0x5d, 0x6b, 0x76, 0x7a, 0x0d, 0xe5, 0x4c,
+	0x51, 0x1b, 0xed, 0xde, 0xa9, 0xca, 0x87, 0x30, 0x86, 0x6c, 0x7f, 0xae, 0xd6, 0x7b, 0x47, 0x4d,
+	0xb5, 0x21, 0x6e, 0x50, 0x79, 0x94, 0x82, 0x8d, 0x60, 0xd1, 0x19, 0x6e, 0x13, 0x01, 0x64, 0xa8,
+	0x2b, 0x4a, 0x43, 0xcc, 0x70, 0xe3, 0x5f, 0x28, 0xb5, 0x56, 0xe7, 0xc5, 0xe7, 0x62, 0x16, 0xdd,
+	0x85, 0x62, 0x57, 0x6d, 0xd7, 0x5f, 0x28, 0x8d, 0x6e, 0xab, 0x76, 0xd4, 0x52, 0xc4, 0x1c, 0x12,
+	0x61, 0xeb, 0xa8, 0xdb, 0x6c, 0x35, 0x7a, 0xcf, 0x6b, 0xcd, 0x96, 0xd2, 0x10, 0xf3, 0x87, 0x7f,
+	0x02, 0xc8, 0x9e, 0xf0, 0x0b, 0x73, 0x34, 0x80, 0xf2, 0xcc, 0x3d, 0x15, 0xda, 0x9d, 0x4f, 0xb4,
+	0xe4, 0x0b, 0x33, 0xe9, 0xfb, 0x2b, 0x50, 0xf2, 0x4f, 0x2f, 0xdf, 0x41, 0x7d, 0x28, 0xc5, 0xd3,
+	0x02, 0x3d, 0x5e, 0x31, 0xa3, 0xa5, 0xdd, 0xe5, 0x84, 0x81, 0x9a, 0x03, 0x01, 0x9d, 0x43, 0x31,
+	0x76, 0x4b, 0x85, 0x1e, 0xad, 0x76, 0xd3, 0x2a, 0x3d, 0x5e, 0x4a, 0x17, 0x3a, 0xf3, 0x0a, 0xca,
+	0x3c, 0x87, 0xae, 0xc2, 0xf6, 0x70, 0xc9, 0x0d, 0x84, 0xb4, 0xb3, 0x6c, 0xab, 0x96, 0xef, 0x50,
+	0xdb, 0x63, 0x9b, 0x5e, 0x92, 0xed, 0x49, 0x4b, 0xa9, 0xf4, 0x78, 0x29, 0x5d, 0xa8, 0xe3, 0x1d,
+	0x14, 0x22, 0x8d, 0x15, 0x25, 0x8c, 0x29, 0xf3, 0x9d, 0x5d, 0xfa, 0x64, 0x09, 0x55, 0x24, 0x32,
+	0xf9, 0x70, 0x0b, 0x44, 0x72, 0x22, 0x57, 0x6c, 0x03, 0x95, 0x3e, 0xbe, 0x96, 0x26, 0x94, 0x3b,
+	0x86, 0xbb, 0x73, 0x27, 0x1b, 0xda, 0x4b, 0xe4, 0x4d, 0x3c, 0x65, 0xa5, 0x1f, 0xac, 0x44, 0x1b,
+	0xea, 0x7b, 0x0b, 0x85, 0xd7, 0xba, 0x67, 0x0c, 0xbe, 0x75, 0x4f, 0x0e, 0x04, 0xd4, 0x83, 0xad,
+	0xe8, 0x7f, 0x44, 0x28, 0x21, 0xb8, 0x09, 0xff, 0x3a, 0x49, 0x8f, 0x96, 0x91, 0x85, 0xc6, 0x9f,
+	0x41, 0xd6, 0xdf, 0x30, 0xd0, 0x4e, 0xd2, 0x14, 0x1a, 0xdd, 0x79, 0xa4, 0x8f, 0xae, 0xa1, 0x08,
+	0x25, 0xbe, 0x81, 0x7c, 0x38, 0x9b, 0x26, 0x05, 0x63, 0x76, 0xd0, 0x96, 0x3e, 0xbe, 0x96, 0x26,
+	0x12, 0x8c, 0x13, 0xc8, 0xf0, 0x69, 0x30, 0xa9, 0x82, 0x62, 0x13, 0xab, 0xb4, 0xb3, 0x98, 0x20,
+	0x34, 0xb4, 0x0d, 0xb9, 0x60, 0x54, 0x43, 0x09, 0x9e, 0xcd, 0x0c, 0x89, 0x92, 0x7c, 0x1d, 0x49,
+	0x20, 0xf4, 0x68, 0xef, 0xed, 0x6e, 0x7f, 0xe8, 0x0d, 0x26, 0xe7, 0x55, 0xc3, 0x1e, 0xed, 0x5f,
+	0x60, 0xcb, 0xd4, 0xf7, 0xf9, 0xff, 0x86, 0xce, 0x45, 0x7f, 0x9f, 0xfd, 0x55, 0x18, 0xfc, 0x1b,
+	0x79, 0x9e, 0x61, 0xe0, 0xd3, 0xff, 0x0e, 0x00, 0x6e, 0xf3, 0x5b, 0x64, 0xa5, 0x1c, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
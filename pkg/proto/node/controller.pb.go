@@ -26,11 +26,39 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type TunnelProtocol int32
+
+const (
+	TunnelProtocol_TCP TunnelProtocol = 0
+	// In UDP mode, each buf sent over the tunnel contains exactly one
+	// datagram.
+	TunnelProtocol_UDP TunnelProtocol = 1
+)
+
+var TunnelProtocol_name = map[int32]string{
+	0: "TCP",
+	1: "UDP",
+}
+
+var TunnelProtocol_value = map[string]int32{
+	"TCP": 0,
+	"UDP": 1,
+}
+
+func (x TunnelProtocol) String() string {
+	return proto.EnumName(TunnelProtocol_name, int32(x))
+}
+
+func (TunnelProtocol) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_ffe3c8ce6343e9a1, []int{0}
+}
+
 type TunnelHeader struct {
 	Name                 string          `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	Port                 uint32          `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
 	OldToken             string          `protobuf:"bytes,3,opt,name=old_token,json=oldToken,proto3" json:"old_token,omitempty"`
 	Auth                 *auth.BlimpAuth `protobuf:"bytes,5,opt,name=auth,proto3" json:"auth,omitempty"`
+	Protocol             TunnelProtocol  `protobuf:"varint,6,opt,name=protocol,proto3,enum=blimp.node.v0.TunnelProtocol" json:"protocol,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
 	XXX_unrecognized     []byte          `json:"-"`
 	XXX_sizecache        int32           `json:"-"`
@@ -89,6 +117,13 @@ func (m *TunnelHeader) GetAuth() *auth.BlimpAuth {
 	return nil
 }
 
+func (m *TunnelHeader) GetProtocol() TunnelProtocol {
+	if m != nil {
+		return m.Protocol
+	}
+	return TunnelProtocol_TCP
+}
+
 type ExposedTunnelHeader struct {
 	Token                string   `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
 	Namespace            string   `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
@@ -422,6 +457,7 @@ func (m *GetSyncStatusRequest) XXX_DiscardUnknown() {
 var xxx_messageInfo_GetSyncStatusRequest proto.InternalMessageInfo
 
 func init() {
+	proto.RegisterEnum("blimp.node.v0.TunnelProtocol", TunnelProtocol_name, TunnelProtocol_value)
 	proto.RegisterType((*TunnelHeader)(nil), "blimp.node.v0.TunnelHeader")
 	proto.RegisterType((*ExposedTunnelHeader)(nil), "blimp.node.v0.ExposedTunnelHeader")
 	proto.RegisterType((*EOF)(nil), "blimp.node.v0.EOF")
@@ -435,40 +471,43 @@ func init() {
 }
 
 var fileDescriptor_ffe3c8ce6343e9a1 = []byte{
-	// 526 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0x51, 0x6f, 0xd3, 0x30,
-	0x10, 0x4e, 0x96, 0xa4, 0x6a, 0xae, 0x2b, 0x02, 0x33, 0x4d, 0x51, 0x37, 0x50, 0x09, 0x02, 0xfa,
-	0x80, 0x9c, 0xa8, 0x88, 0x1f, 0x40, 0x51, 0x47, 0x06, 0x1a, 0x93, 0xb2, 0x3d, 0xf1, 0x32, 0xa5,
-	0x89, 0x9b, 0x46, 0x4d, 0xed, 0x10, 0x3b, 0x15, 0x7b, 0x45, 0x48, 0xfc, 0x6b, 0x84, 0x6c, 0x87,
-	0xd1, 0x96, 0xc2, 0x0b, 0x4f, 0xbd, 0xf3, 0x7d, 0x77, 0xfe, 0xee, 0xfb, 0xea, 0xc0, 0xe3, 0x59,
-	0x59, 0xac, 0xaa, 0x80, 0xb2, 0x8c, 0x04, 0xeb, 0x30, 0x48, 0x19, 0x15, 0x35, 0x2b, 0x4b, 0x52,
-	0xe3, 0xaa, 0x66, 0x82, 0xa1, 0xbe, 0xaa, 0x63, 0x59, 0xc7, 0xeb, 0x70, 0xe0, 0x69, 0x78, 0xd2,
-	0x88, 0x85, 0x84, 0xcb, 0x5f, 0x0d, 0x1c, 0x9c, 0xea, 0x0a, 0xa9, 0x6b, 0x56, 0x73, 0x59, 0xd3,
-	0x91, 0xae, 0xfa, 0xdf, 0x4d, 0x38, 0xbc, 0x6e, 0x28, 0x25, 0x65, 0x44, 0x92, 0x8c, 0xd4, 0x08,
-	0x81, 0x4d, 0x93, 0x15, 0xf1, 0xcc, 0xa1, 0x39, 0x72, 0x63, 0x15, 0xcb, 0xb3, 0x8a, 0xd5, 0xc2,
-	0x3b, 0x18, 0x9a, 0xa3, 0x7e, 0xac, 0x62, 0x74, 0x02, 0x2e, 0x2b, 0xb3, 0x1b, 0xc1, 0x96, 0x84,
-	0x7a, 0x96, 0x02, 0x77, 0x59, 0x99, 0x5d, 0xcb, 0x1c, 0xbd, 0x04, 0x5b, 0x32, 0xf0, 0x9c, 0xa1,
-	0x39, 0xea, 0x8d, 0x3d, 0xac, 0xb9, 0x2a, 0x52, 0xeb, 0x10, 0x4f, 0x64, 0xf6, 0xa6, 0x11, 0x8b,
-	0x58, 0xa1, 0xde, 0xdb, 0x5d, 0xfb, 0xbe, 0xe3, 0x9f, 0xc3, 0xc3, 0xe9, 0x97, 0x8a, 0x71, 0x92,
-	0x6d, 0xf1, 0x39, 0x02, 0x47, 0xdf, 0xa1, 0x09, 0xe9, 0x04, 0x9d, 0x82, 0x2b, 0x99, 0xf1, 0x2a,
-	0x49, 0x89, 0xa2, 0xe5, 0xc6, 0xbf, 0x0f, 0x7c, 0x07, 0xac, 0xe9, 0xe5, 0x99, 0xff, 0xf5, 0x00,
-	0x5c, 0x3d, 0xeb, 0x82, 0xe7, 0x08, 0x83, 0xa3, 0x36, 0x57, 0x83, 0x7a, 0xe3, 0xe3, 0x96, 0x54,
-	0xab, 0xc6, 0x3a, 0xc4, 0x53, 0x19, 0x45, 0x46, 0xac, 0x61, 0xe8, 0x35, 0x74, 0x16, 0x8a, 0x82,
-	0x9a, 0xdf, 0x1b, 0x9f, 0xe0, 0x2d, 0xc5, 0xf1, 0x26, 0xcb, 0xc8, 0x88, 0x5b, 0x30, 0xfa, 0x00,
-	0xf7, 0x88, 0x5e, 0xe3, 0xa6, 0x6d, 0xd7, 0x22, 0xf8, 0x3b, 0xed, 0x7b, 0x76, 0x8d, 0x8c, 0xb8,
-	0xdf, 0xf6, 0xde, 0x99, 0x61, 0xcd, 0x9a, 0xb9, 0x92, 0xf7, 0x30, 0x32, 0x62, 0x99, 0xa0, 0xe7,
-	0x60, 0x11, 0x36, 0xf7, 0x6c, 0x35, 0x15, 0xed, 0x4e, 0xbd, 0x3c, 0x93, 0x38, 0xc2, 0xe6, 0x13,
-	0x07, 0xac, 0x15, 0xcf, 0xfd, 0x6f, 0x26, 0xa0, 0xab, 0x5b, 0x9a, 0x5e, 0x89, 0x44, 0x34, 0x3c,
-	0x26, 0xbc, 0x62, 0x94, 0x13, 0xf4, 0x68, 0xd3, 0x3e, 0x25, 0x6d, 0x64, 0x6c, 0x18, 0x88, 0x5b,
-	0x03, 0xad, 0x7f, 0x1b, 0x18, 0x19, 0xda, 0x42, 0xe4, 0x41, 0x87, 0xdf, 0xd2, 0x94, 0x64, 0x4a,
-	0xac, 0xae, 0xd4, 0x43, 0xe7, 0xbf, 0x68, 0x1c, 0xc3, 0xd1, 0x3b, 0x22, 0x36, 0x89, 0x7c, 0x6e,
-	0x08, 0x17, 0xe3, 0x1f, 0x26, 0xc0, 0xdb, 0xbb, 0xff, 0x36, 0x9a, 0x40, 0x47, 0x2b, 0x82, 0xbc,
-	0xbd, 0x72, 0x5f, 0xf0, 0x7c, 0xf0, 0xd7, 0x8a, 0x6f, 0x8c, 0xcc, 0xd0, 0x44, 0xe7, 0xd0, 0xdf,
-	0x12, 0xf7, 0x3f, 0x46, 0x25, 0xf0, 0x40, 0x52, 0xfe, 0xc8, 0x44, 0x31, 0x2f, 0xd2, 0x44, 0x14,
-	0x8c, 0x72, 0xf4, 0x64, 0xa7, 0xe9, 0x4f, 0x75, 0x07, 0x4f, 0x77, 0x20, 0xfb, 0x56, 0xd7, 0x57,
-	0x4c, 0x5e, 0x7c, 0x7a, 0x96, 0x17, 0x62, 0xd1, 0xcc, 0x70, 0xca, 0x56, 0xc1, 0x92, 0x94, 0x59,
-	0x12, 0xe8, 0x17, 0x5b, 0x2d, 0xf3, 0x40, 0x3d, 0x52, 0xf5, 0x11, 0x98, 0x75, 0x54, 0xfc, 0xea,
-	0x67, 0x00, 0x00, 0x00, 0xff, 0xff, 0x2b, 0x8d, 0x67, 0x05, 0x19, 0x04, 0x00, 0x00,
+	// 570 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0x5f, 0x8f, 0xd2, 0x4e,
+	0x14, 0xed, 0x6c, 0x29, 0x3f, 0xb8, 0x2c, 0x84, 0xdf, 0xb8, 0xd9, 0x34, 0xec, 0xae, 0xc1, 0x1a,
+	0x95, 0x18, 0x33, 0x25, 0x18, 0x1f, 0x7c, 0x94, 0x95, 0xb5, 0xab, 0x59, 0x97, 0x74, 0xf1, 0xc5,
+	0x97, 0x4d, 0x69, 0x87, 0x3f, 0xa1, 0x74, 0x6a, 0x67, 0x4a, 0xdc, 0x57, 0xe3, 0x27, 0xf3, 0x83,
+	0x19, 0x33, 0x33, 0x15, 0x01, 0xd1, 0x17, 0x9f, 0xb8, 0x77, 0xee, 0xb9, 0x97, 0x73, 0xee, 0xe9,
+	0x0c, 0xdc, 0x1f, 0xc7, 0xf3, 0x65, 0xea, 0x26, 0x2c, 0xa2, 0xee, 0xaa, 0xeb, 0x86, 0x2c, 0x11,
+	0x19, 0x8b, 0x63, 0x9a, 0x91, 0x34, 0x63, 0x82, 0xe1, 0xba, 0xaa, 0x13, 0x59, 0x27, 0xab, 0x6e,
+	0xcb, 0xd6, 0xf0, 0x20, 0x17, 0x33, 0x09, 0x97, 0xbf, 0x1a, 0xd8, 0x3a, 0xd5, 0x15, 0x9a, 0x65,
+	0x2c, 0xe3, 0xb2, 0xa6, 0x23, 0x5d, 0x75, 0xbe, 0x21, 0x38, 0x1c, 0xe5, 0x49, 0x42, 0x63, 0x8f,
+	0x06, 0x11, 0xcd, 0x30, 0x86, 0x52, 0x12, 0x2c, 0xa9, 0x8d, 0xda, 0xa8, 0x53, 0xf5, 0x55, 0x2c,
+	0xcf, 0x52, 0x96, 0x09, 0xfb, 0xa0, 0x8d, 0x3a, 0x75, 0x5f, 0xc5, 0xf8, 0x04, 0xaa, 0x2c, 0x8e,
+	0x6e, 0x05, 0x5b, 0xd0, 0xc4, 0x36, 0x15, 0xb8, 0xc2, 0xe2, 0x68, 0x24, 0x73, 0xfc, 0x0c, 0x4a,
+	0x92, 0x81, 0x6d, 0xb5, 0x51, 0xa7, 0xd6, 0xb3, 0x89, 0xe6, 0xaa, 0x48, 0xad, 0xba, 0xa4, 0x2f,
+	0xb3, 0x57, 0xb9, 0x98, 0xf9, 0x0a, 0x85, 0x5f, 0x42, 0x45, 0x91, 0x09, 0x59, 0x6c, 0x97, 0xdb,
+	0xa8, 0xd3, 0xe8, 0x9d, 0x91, 0x2d, 0x75, 0x44, 0x33, 0x1c, 0x16, 0x20, 0x7f, 0x0d, 0x7f, 0x5b,
+	0xaa, 0x94, 0x9a, 0x96, 0x73, 0x09, 0xf7, 0x06, 0x9f, 0x53, 0xc6, 0x69, 0xb4, 0x25, 0xe5, 0x08,
+	0x2c, 0x4d, 0x4f, 0x6b, 0xd1, 0x09, 0x3e, 0x85, 0xaa, 0x14, 0xc5, 0xd3, 0x20, 0xa4, 0x4a, 0x51,
+	0xd5, 0xff, 0x75, 0xe0, 0x58, 0x60, 0x0e, 0xae, 0x2f, 0x9c, 0x2f, 0x07, 0x50, 0xd5, 0xb3, 0xae,
+	0xf8, 0x14, 0x13, 0xb0, 0xd4, 0xd2, 0xd4, 0xa0, 0x5a, 0xef, 0xb8, 0x60, 0x57, 0x2c, 0x72, 0xd5,
+	0x25, 0x03, 0x19, 0x79, 0x86, 0xaf, 0x61, 0xf8, 0x05, 0x94, 0x67, 0x8a, 0x82, 0x9a, 0x5f, 0xeb,
+	0x9d, 0xec, 0x95, 0xa3, 0x59, 0x7a, 0x86, 0x5f, 0x80, 0xf1, 0x3b, 0x68, 0x50, 0x2d, 0xe3, 0xb6,
+	0x68, 0xd7, 0xfb, 0x73, 0x76, 0xda, 0xf7, 0x68, 0xf5, 0x0c, 0xbf, 0x5e, 0xf4, 0xae, 0x7d, 0x34,
+	0xc7, 0xf9, 0x44, 0x39, 0x73, 0xe8, 0x19, 0xbe, 0x4c, 0xf0, 0x63, 0x30, 0x29, 0x9b, 0xd8, 0x25,
+	0x35, 0x15, 0xef, 0x4e, 0xbd, 0xbe, 0x90, 0x38, 0xca, 0x26, 0x7d, 0x0b, 0xcc, 0x25, 0x9f, 0x3a,
+	0x5f, 0x11, 0xe0, 0x9b, 0xbb, 0x24, 0xbc, 0x11, 0x81, 0xc8, 0xb9, 0x4f, 0x79, 0xca, 0x12, 0x4e,
+	0xf1, 0xd9, 0xa6, 0xf3, 0x6a, 0xb5, 0x9e, 0xb1, 0xe1, 0x3d, 0x29, 0xbc, 0x37, 0xff, 0xee, 0xbd,
+	0x67, 0x14, 0xee, 0xdb, 0x50, 0xe6, 0x77, 0x49, 0x48, 0x23, 0xb5, 0xac, 0x8a, 0xdc, 0x87, 0xce,
+	0x7f, 0xd2, 0x38, 0x86, 0xa3, 0x37, 0x54, 0x6c, 0x12, 0xf9, 0x94, 0x53, 0x2e, 0x9e, 0x3a, 0xd0,
+	0xd8, 0xfe, 0x2e, 0xf0, 0x7f, 0x60, 0x8e, 0xce, 0x87, 0x4d, 0x43, 0x06, 0x1f, 0x5e, 0x0f, 0x9b,
+	0xa8, 0xf7, 0x1d, 0x01, 0x9c, 0xaf, 0xaf, 0x0e, 0xee, 0x43, 0x59, 0xb7, 0x60, 0x7b, 0xaf, 0x25,
+	0x57, 0x7c, 0xda, 0xfa, 0x63, 0xc5, 0x31, 0x3a, 0xa8, 0x8b, 0xf0, 0x25, 0xd4, 0xb7, 0x0c, 0xf8,
+	0x87, 0x51, 0x01, 0xfc, 0x2f, 0x65, 0xbd, 0x67, 0x62, 0x3e, 0x99, 0x87, 0x81, 0x98, 0xb3, 0x84,
+	0xe3, 0x07, 0x3b, 0x4d, 0xbf, 0x3b, 0xd0, 0x7a, 0xb8, 0x03, 0xd9, 0xb7, 0x1e, 0xfd, 0x17, 0xfd,
+	0x27, 0x1f, 0x1f, 0x4d, 0xe7, 0x62, 0x96, 0x8f, 0x49, 0xc8, 0x96, 0xee, 0x82, 0xc6, 0x51, 0xe0,
+	0xea, 0x07, 0x21, 0x5d, 0x4c, 0x5d, 0x75, 0x8f, 0xd4, 0x1b, 0x33, 0x2e, 0xab, 0xf8, 0xf9, 0x8f,
+	0x01, 0x00, 0xec, 0xe2, 0x99, 0x6b, 0x78, 0x04, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
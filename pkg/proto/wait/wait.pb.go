@@ -134,8 +134,12 @@ func (m *WaitSpec) GetFinishedVolumeInit() []string {
 }
 
 type ServiceCondition struct {
-	Service              string   `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
-	Condition            string   `protobuf:"bytes,2,opt,name=condition,proto3" json:"condition,omitempty"`
+	Service   string `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	Condition string `protobuf:"bytes,2,opt,name=condition,proto3" json:"condition,omitempty"`
+	// pod is the pod that runs the service, if the service runs in another
+	// service's pod because they share a namespace. In that case, the
+	// condition only applies to the service's container.
+	Pod                  string   `protobuf:"bytes,3,opt,name=pod,proto3" json:"pod,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -180,6 +184,13 @@ func (m *ServiceCondition) GetCondition() string {
 	return ""
 }
 
+func (m *ServiceCondition) GetPod() string {
+	if m != nil {
+		return m.Pod
+	}
+	return ""
+}
+
 type CheckReadyResponse struct {
 	Error *errors.Error `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
 	Ready bool          `protobuf:"varint,2,opt,name=ready,proto3" json:"ready,omitempty"`
@@ -249,32 +260,33 @@ func init() {
 }
 
 var fileDescriptor_d3a1998debca718e = []byte{
-	// 399 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0x51, 0x8b, 0xd4, 0x30,
-	0x10, 0xc7, 0xed, 0x2d, 0x77, 0x6e, 0x67, 0x15, 0x34, 0x1c, 0x67, 0x38, 0x0e, 0xec, 0x15, 0xc4,
-	0x3e, 0x48, 0x5b, 0xaa, 0xcf, 0x3e, 0xdc, 0xe1, 0x83, 0xbe, 0x08, 0x39, 0x54, 0xf0, 0xa5, 0xb4,
-	0xe9, 0xb8, 0x1b, 0x76, 0x9b, 0xc4, 0x24, 0xdb, 0xc3, 0x4f, 0xe3, 0x57, 0x95, 0x26, 0x2d, 0xab,
-	0x2b, 0xdc, 0x53, 0x67, 0xfe, 0xf9, 0x65, 0xa6, 0xf9, 0xcf, 0x00, 0x6d, 0x77, 0xa2, 0xd7, 0xc5,
-	0x7d, 0x23, 0x5c, 0x31, 0x94, 0xfe, 0x9b, 0x6b, 0xa3, 0x9c, 0x22, 0x4f, 0xfd, 0x49, 0xee, 0x95,
-	0xa1, 0xbc, 0xbc, 0x0a, 0x20, 0x1a, 0xa3, 0x8c, 0x1d, 0xd1, 0x10, 0x05, 0x38, 0x5d, 0xc3, 0xf3,
-	0xdb, 0x0d, 0xf2, 0x2d, 0xc3, 0xa6, 0xfb, 0xc5, 0xf0, 0xe7, 0x1e, 0xad, 0x23, 0x57, 0x10, 0xcb,
-	0xa6, 0x47, 0xab, 0x1b, 0x8e, 0x34, 0x4a, 0xa2, 0x2c, 0x66, 0x07, 0x81, 0xbc, 0x83, 0x78, 0xac,
-	0x5d, 0x5b, 0x8d, 0x9c, 0x9e, 0x24, 0x51, 0xb6, 0xaa, 0x5e, 0xe4, 0xff, 0xf4, 0xcc, 0xbf, 0x35,
-	0xc2, 0xdd, 0x69, 0xe4, 0x6c, 0x79, 0x3f, 0x45, 0xe9, 0xef, 0x08, 0x96, 0xb3, 0x4c, 0xde, 0x03,
-	0x74, 0xa8, 0x51, 0x76, 0xb6, 0x56, 0x92, 0x46, 0xc9, 0x22, 0x5b, 0x55, 0x2f, 0x8f, 0x6a, 0xdc,
-	0xa1, 0x19, 0x04, 0xc7, 0x5b, 0x25, 0x3b, 0xe1, 0x84, 0x92, 0x2c, 0x9e, 0xae, 0x7c, 0x96, 0xe4,
-	0x1a, 0x9e, 0xb4, 0x42, 0x76, 0xf5, 0xa0, 0x76, 0xfb, 0x1e, 0x2d, 0x3d, 0x49, 0x16, 0x59, 0xcc,
-	0x56, 0xa3, 0xf6, 0x35, 0x48, 0xa4, 0x84, 0xf3, 0x1f, 0x42, 0x0a, 0xbb, 0xc1, 0x19, 0xab, 0x85,
-	0x14, 0x8e, 0x2e, 0x3c, 0x4a, 0xe6, 0xb3, 0x80, 0x7f, 0x94, 0xc2, 0xa5, 0x9f, 0xe0, 0xd9, 0x71,
-	0x4f, 0x42, 0xe1, 0xb1, 0x0d, 0xda, 0xe4, 0xc3, 0x9c, 0x8e, 0x1e, 0xf1, 0x19, 0xf3, 0x2e, 0xc4,
-	0xec, 0x20, 0xa4, 0x1a, 0xc8, 0xdf, 0xb6, 0x5a, 0xad, 0xa4, 0x45, 0xf2, 0x06, 0x4e, 0xbd, 0xf9,
-	0xbe, 0xd6, 0xaa, 0xba, 0x98, 0x5e, 0x3c, 0x0d, 0x64, 0x28, 0xf3, 0x0f, 0x63, 0xc4, 0x02, 0x44,
-	0xce, 0xe1, 0xd4, 0x8c, 0xd7, 0x7d, 0xf5, 0x25, 0x0b, 0x09, 0xb9, 0x80, 0x33, 0x83, 0x8d, 0x55,
-	0x92, 0x2e, 0x7c, 0xd3, 0x29, 0xab, 0x38, 0xc0, 0x8d, 0x52, 0x6e, 0xb4, 0x18, 0x0d, 0xf9, 0x02,
-	0x70, 0xe8, 0x4f, 0x92, 0x23, 0x6b, 0xff, 0x9b, 0xf8, 0xe5, 0xf5, 0x03, 0x44, 0xf8, 0xf9, 0xf4,
-	0x51, 0x19, 0xdd, 0xbc, 0xfe, 0xfe, 0x6a, 0x2d, 0xdc, 0x66, 0xdf, 0xe6, 0x5c, 0xf5, 0xc5, 0x16,
-	0x77, 0x5d, 0x53, 0x84, 0xf5, 0xd2, 0xdb, 0x75, 0xe1, 0x37, 0xca, 0x6f, 0x62, 0x7b, 0xe6, 0xe3,
-	0xb7, 0x7f, 0x02, 0x00, 0x00, 0xff, 0xff, 0x34, 0x8a, 0x0b, 0x9d, 0xa6, 0x02, 0x00, 0x00,
+	// 403 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0xc1, 0x8b, 0xd4, 0x30,
+	0x14, 0xc6, 0xed, 0x96, 0x5d, 0xa7, 0x6f, 0x14, 0xd6, 0xb0, 0xac, 0x61, 0x59, 0xb0, 0x5b, 0x10,
+	0xe7, 0x20, 0x6d, 0x19, 0x3d, 0x7b, 0xd8, 0xc5, 0x83, 0x27, 0x21, 0x8b, 0x0a, 0x22, 0x0c, 0x6d,
+	0xfa, 0x9c, 0x09, 0x33, 0x4d, 0x62, 0x92, 0xe9, 0xe2, 0x5f, 0xe3, 0xbf, 0x2a, 0x49, 0x5a, 0x46,
+	0x47, 0xf0, 0xd4, 0xf7, 0xbe, 0xfc, 0xf2, 0xbe, 0xf2, 0xe5, 0x01, 0x6d, 0x77, 0xa2, 0xd7, 0xd5,
+	0x43, 0x23, 0x5c, 0x35, 0xd4, 0xe1, 0x5b, 0x6a, 0xa3, 0x9c, 0x22, 0x4f, 0xc3, 0x49, 0x19, 0x94,
+	0xa1, 0xbe, 0xba, 0x8e, 0x20, 0x1a, 0xa3, 0x8c, 0xf5, 0x68, 0xac, 0x22, 0x5c, 0xac, 0xe1, 0xd9,
+	0xdd, 0x06, 0xf9, 0x96, 0x61, 0xd3, 0xfd, 0x64, 0xf8, 0x63, 0x8f, 0xd6, 0x91, 0x6b, 0xc8, 0x64,
+	0xd3, 0xa3, 0xd5, 0x0d, 0x47, 0x9a, 0xe4, 0xc9, 0x22, 0x63, 0x07, 0x81, 0xbc, 0x85, 0xcc, 0xcf,
+	0x5e, 0x59, 0x8d, 0x9c, 0x9e, 0xe4, 0xc9, 0x62, 0xbe, 0x7c, 0x5e, 0xfe, 0xe5, 0x59, 0x7e, 0x69,
+	0x84, 0xbb, 0xd7, 0xc8, 0xd9, 0xec, 0x61, 0xac, 0x8a, 0x5f, 0x09, 0xcc, 0x26, 0x99, 0xbc, 0x03,
+	0xe8, 0x50, 0xa3, 0xec, 0xec, 0x4a, 0x49, 0x9a, 0xe4, 0xe9, 0x62, 0xbe, 0x7c, 0x71, 0x34, 0xe3,
+	0x1e, 0xcd, 0x20, 0x38, 0xde, 0x29, 0xd9, 0x09, 0x27, 0x94, 0x64, 0xd9, 0x78, 0xe5, 0xa3, 0x24,
+	0x37, 0xf0, 0xa4, 0x15, 0xb2, 0x5b, 0x0d, 0x6a, 0xb7, 0xef, 0xd1, 0xd2, 0x93, 0x3c, 0x5d, 0x64,
+	0x6c, 0xee, 0xb5, 0xcf, 0x51, 0x22, 0x35, 0x5c, 0x7c, 0x17, 0x52, 0xd8, 0x0d, 0x4e, 0xd8, 0x4a,
+	0x48, 0xe1, 0x68, 0x1a, 0x50, 0x32, 0x9d, 0x45, 0xfc, 0x83, 0x14, 0xae, 0xf8, 0x06, 0xe7, 0xc7,
+	0x9e, 0x84, 0xc2, 0x63, 0x1b, 0xb5, 0x31, 0x87, 0xa9, 0xf5, 0x19, 0xf1, 0x09, 0x0b, 0x29, 0x64,
+	0xec, 0x20, 0x90, 0x73, 0x48, 0xb5, 0xea, 0x68, 0x1a, 0x74, 0x5f, 0x16, 0x1a, 0xc8, 0x9f, 0x41,
+	0x5b, 0xad, 0xa4, 0x45, 0xf2, 0x1a, 0x4e, 0xc3, 0x73, 0x84, 0xe9, 0xf3, 0xe5, 0xe5, 0x98, 0xc1,
+	0xf8, 0x44, 0x43, 0x5d, 0xbe, 0xf7, 0x15, 0x8b, 0x10, 0xb9, 0x80, 0x53, 0xe3, 0xaf, 0x07, 0xbf,
+	0x19, 0x8b, 0x0d, 0xb9, 0x84, 0x33, 0x83, 0x8d, 0x55, 0x72, 0xb4, 0x1b, 0xbb, 0x25, 0x07, 0xb8,
+	0x55, 0xca, 0xf9, 0xd0, 0xd1, 0x90, 0x4f, 0x00, 0x07, 0x7f, 0x92, 0x1f, 0x85, 0xfd, 0xcf, 0x0e,
+	0x5c, 0xdd, 0xfc, 0x87, 0x88, 0x3f, 0x5f, 0x3c, 0xaa, 0x93, 0xdb, 0x57, 0x5f, 0x5f, 0xae, 0x85,
+	0xdb, 0xec, 0xdb, 0x92, 0xab, 0xbe, 0xda, 0xe2, 0xae, 0x6b, 0xaa, 0xb8, 0x70, 0x7a, 0xbb, 0xae,
+	0xc2, 0x8e, 0x85, 0xdd, 0x6c, 0xcf, 0x42, 0xfd, 0xe6, 0xf7, 0x00, 0x4a, 0x07, 0x67, 0xa8, 0xb8,
+	0x02, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -19,7 +19,7 @@ type tunnel interface {
 	Recv() (*node.TunnelMsg, error)
 }
 
-func ServerStream(nsrv node.Controller_TunnelServer, stream net.Conn) {
+func ServerStream(nsrv node.Controller_TunnelServer, stream io.ReadWriteCloser) {
 	streamBidirectional(stream, nsrv, func() {})
 }
 
@@ -41,40 +41,43 @@ func Client(scc node.ControllerClient, ln net.Listener, auth *protoAuth.BlimpAut
 
 		log.WithFields(fields).Trace("new connection")
 		go func() {
-			connect(scc, stream, auth, name, port)
+			connect(scc, stream, auth, name, port, node.TunnelProtocol_TCP)
 			log.WithFields(fields).Trace("finish connection")
 		}()
 	}
 }
 
-func connect(scc node.ControllerClient, stream net.Conn,
-	auth *protoAuth.BlimpAuth, name string, port uint32) {
+func connect(scc node.ControllerClient, stream io.ReadWriteCloser,
+	auth *protoAuth.BlimpAuth, name string, port uint32, protocol node.TunnelProtocol) {
 	defer stream.Close()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	tnl, err := scc.Tunnel(ctx)
 	if err != nil {
 		log.WithError(err).Error("failed to establish tunnel")
+		cancel()
 		return
 	}
 
 	err = tnl.Send(&node.TunnelMsg{Msg: &node.TunnelMsg_Header{
 		Header: &node.TunnelHeader{
-			Auth: auth,
-			Name: name,
-			Port: port,
+			Auth:     auth,
+			Name:     name,
+			Port:     port,
+			Protocol: protocol,
 		}}})
 	if err != nil {
 		log.WithError(err).Error("failed to send tunnel connect")
 		//nolint:errcheck // Nothing we could do to handle this anyway.
 		tnl.CloseSend()
+		cancel()
 		return
 	}
 
 	streamBidirectional(stream, tnl, cancel)
 }
 
-func streamBidirectional(stream net.Conn, tnl tunnel, cancel func()) {
+func streamBidirectional(stream io.ReadWriteCloser, tnl tunnel, cancel func()) {
 	var wg sync.WaitGroup
 	wg.Add(2)
 
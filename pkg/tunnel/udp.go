@@ -0,0 +1,143 @@
+package tunnel
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	protoAuth "github.com/kelda/blimp/pkg/proto/auth"
+	"github.com/kelda/blimp/pkg/proto/node"
+)
+
+// udpIdleTimeout is how long a UDP session may go without receiving any
+// datagrams from the local client before its tunnel is closed.
+const udpIdleTimeout = 2 * time.Minute
+
+// maxDatagramSize is the largest UDP payload possible.
+const maxDatagramSize = 64 * 1024
+
+// ClientUDP forwards datagrams received on `pc` to the given service. UDP is
+// connectionless, so each local address that sends datagrams gets its own
+// tunnel, and responses are sent back to that address.
+func ClientUDP(scc node.ControllerClient, pc net.PacketConn, auth *protoAuth.BlimpAuth,
+	name string, port uint32) error {
+	return clientUDP(scc, pc, auth, name, port, udpIdleTimeout)
+}
+
+func clientUDP(scc node.ControllerClient, pc net.PacketConn, auth *protoAuth.BlimpAuth,
+	name string, port uint32, idleTimeout time.Duration) error {
+
+	fields := log.Fields{
+		"listen": pc.LocalAddr().String(),
+		"name":   name,
+		"port":   port,
+	}
+
+	var sessionsLock sync.Mutex
+	sessions := map[string]*udpSession{}
+	defer func() {
+		sessionsLock.Lock()
+		for _, session := range sessions {
+			session.Close()
+		}
+		sessionsLock.Unlock()
+	}()
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+
+		datagram := make([]byte, n)
+		copy(datagram, buf[:n])
+
+		sessionsLock.Lock()
+		session, ok := sessions[addr.String()]
+		if !ok {
+			session = newUDPSession(pc, addr, idleTimeout)
+			sessions[addr.String()] = session
+
+			log.WithFields(fields).WithField("client", addr.String()).Trace("new udp session")
+			go func() {
+				connect(scc, session, auth, name, port, node.TunnelProtocol_UDP)
+
+				sessionsLock.Lock()
+				delete(sessions, addr.String())
+				sessionsLock.Unlock()
+				log.WithFields(fields).WithField("client", addr.String()).Trace("finish udp session")
+			}()
+		}
+		sessionsLock.Unlock()
+
+		session.deliver(datagram)
+	}
+}
+
+// udpSession adapts the datagrams from a single client address into an
+// io.ReadWriteCloser so that they can be sent over a tunnel. Each Read
+// returns exactly one datagram, and each Write sends exactly one datagram.
+type udpSession struct {
+	pc          net.PacketConn
+	addr        net.Addr
+	idleTimeout time.Duration
+
+	datagrams chan []byte
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newUDPSession(pc net.PacketConn, addr net.Addr, idleTimeout time.Duration) *udpSession {
+	return &udpSession{
+		pc:          pc,
+		addr:        addr,
+		idleTimeout: idleTimeout,
+		datagrams:   make(chan []byte, 64),
+		closed:      make(chan struct{}),
+	}
+}
+
+// deliver queues a datagram received from the client. Datagrams are dropped
+// if the tunnel can't keep up, just as they would be on a congested network.
+func (s *udpSession) deliver(datagram []byte) {
+	// Check whether the session is closed first, since select picks randomly
+	// between the ready cases.
+	select {
+	case <-s.closed:
+		return
+	default:
+	}
+
+	select {
+	case s.datagrams <- datagram:
+	default:
+		log.WithField("client", s.addr.String()).Debug("UDP session is backed up, dropping datagram")
+	}
+}
+
+func (s *udpSession) Read(buf []byte) (int, error) {
+	timeout := time.NewTimer(s.idleTimeout)
+	defer timeout.Stop()
+
+	select {
+	case datagram := <-s.datagrams:
+		return copy(buf, datagram), nil
+	case <-timeout.C:
+		return 0, io.EOF
+	case <-s.closed:
+		return 0, io.EOF
+	}
+}
+
+func (s *udpSession) Write(buf []byte) (int, error) {
+	return s.pc.WriteTo(buf, s.addr)
+}
+
+func (s *udpSession) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return nil
+}
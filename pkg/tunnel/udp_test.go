@@ -0,0 +1,241 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/kelda/blimp/pkg/proto/node"
+)
+
+func TestUDPRoundTrip(t *testing.T) {
+	client, tunnels, stop := startUDPTunnel(t)
+	defer stop()
+
+	// Each datagram should be delivered, and echoed back, on its own.
+	for _, msg := range []string{"first", "second", "third"} {
+		assert.Equal(t, msg, roundTrip(t, client, msg))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(tunnels))
+}
+
+func TestUDPConcurrentClients(t *testing.T) {
+	echoAddr, stopEcho := startUDPEcho(t)
+	defer stopEcho()
+	listener, tunnels, stopClient := startClientUDP(t, echoAddr, udpIdleTimeout)
+	defer stopClient()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			conn, err := net.Dial("udp", listener)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.Close()
+
+			for j := 0; j < 3; j++ {
+				msg := fmt.Sprintf("client %d message %d", i, j)
+				assert.Equal(t, msg, roundTrip(t, conn, msg))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Each client address gets its own tunnel.
+	assert.Equal(t, int32(5), atomic.LoadInt32(tunnels))
+}
+
+func TestUDPSessionExpiry(t *testing.T) {
+	idleTimeout := 100 * time.Millisecond
+	echoAddr, stopEcho := startUDPEcho(t)
+	defer stopEcho()
+	listener, tunnels, stopClient := startClientUDP(t, echoAddr, idleTimeout)
+	defer stopClient()
+	client, err := net.Dial("udp", listener)
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.Equal(t, "before", roundTrip(t, client, "before"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(tunnels))
+
+	// The idle session should be closed, and the next datagram should open
+	// a new tunnel.
+	time.Sleep(3 * idleTimeout)
+	assert.Equal(t, "after", roundTrip(t, client, "after"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(tunnels))
+}
+
+func TestUDPSessionRead(t *testing.T) {
+	session := newUDPSession(nil, &net.UDPAddr{}, 50*time.Millisecond)
+	session.deliver([]byte("datagram"))
+
+	buf := make([]byte, 64)
+	n, err := session.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "datagram", string(buf[:n]))
+
+	// Reads time out once the session is idle.
+	_, err = session.Read(buf)
+	assert.Error(t, err)
+
+	// Delivering to a closed session doesn't block.
+	assert.NoError(t, session.Close())
+	session.deliver([]byte("dropped"))
+	_, err = session.Read(buf)
+	assert.Error(t, err)
+}
+
+// startUDPTunnel starts a UDP echo server, and a tunnel to it. It returns a
+// connection to the local end of the tunnel, a counter of the number of
+// tunnels that were opened, and a function that shuts everything down.
+func startUDPTunnel(t *testing.T) (net.Conn, *int32, func()) {
+	echoAddr, stopEcho := startUDPEcho(t)
+	listener, tunnels, stopClient := startClientUDP(t, echoAddr, udpIdleTimeout)
+	conn, err := net.Dial("udp", listener)
+	require.NoError(t, err)
+
+	stop := func() {
+		conn.Close()
+		stopClient()
+		stopEcho()
+	}
+	return conn, tunnels, stop
+}
+
+// startClientUDP runs clientUDP with tunnels to the given address, and returns
+// the address that clientUDP is listening on, and a function that stops it.
+func startClientUDP(t *testing.T, dst string, idleTimeout time.Duration) (string, *int32, func()) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	scc := &fakeControllerClient{dst: dst}
+	//nolint:errcheck // clientUDP returns an error once the listener is closed.
+	go clientUDP(scc, pc, nil, "service", 53, idleTimeout)
+	return pc.LocalAddr().String(), &scc.tunnels, func() { pc.Close() }
+}
+
+// startUDPEcho starts a UDP server that echoes datagrams back to their
+// sender. It returns the server's address, and a function that stops it.
+func startUDPEcho(t *testing.T) (string, func()) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			//nolint:errcheck // Errors cause the test to time out.
+			pc.WriteTo(buf[:n], addr)
+		}
+	}()
+	return pc.LocalAddr().String(), func() { pc.Close() }
+}
+
+func roundTrip(t *testing.T, conn net.Conn, msg string) string {
+	_, err := conn.Write([]byte(msg))
+	require.NoError(t, err)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, maxDatagramSize)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	return string(buf[:n])
+}
+
+// fakeControllerClient implements tunnels in-process by running the server
+// side of the tunnel against a UDP connection to `dst`, like the node
+// controller does.
+type fakeControllerClient struct {
+	node.ControllerClient
+	dst     string
+	tunnels int32
+}
+
+func (c *fakeControllerClient) Tunnel(ctx context.Context, _ ...grpc.CallOption) (
+	node.Controller_TunnelClient, error) {
+	atomic.AddInt32(&c.tunnels, 1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	toServer := make(chan *node.TunnelMsg)
+	toClient := make(chan *node.TunnelMsg)
+	client := fakeTunnelClient{tunnelPipe: tunnelPipe{ctx: ctx, send: toServer, recv: toClient}}
+	server := fakeTunnelServer{tunnelPipe: tunnelPipe{ctx: ctx, send: toClient, recv: toServer}}
+
+	go func() {
+		defer cancel()
+
+		header, err := server.Recv()
+		if err != nil || header.GetHeader().GetProtocol() != node.TunnelProtocol_UDP {
+			return
+		}
+
+		conn, err := net.Dial("udp", c.dst)
+		if err != nil {
+			return
+		}
+		ServerStream(server, conn)
+	}()
+	return client, nil
+}
+
+// tunnelPipe is one end of an in-memory tunnel.
+type tunnelPipe struct {
+	ctx  context.Context
+	send chan<- *node.TunnelMsg
+	recv <-chan *node.TunnelMsg
+}
+
+func (p tunnelPipe) Send(msg *node.TunnelMsg) error {
+	// gRPC serializes messages before Send returns, so senders may reuse
+	// the buffer afterwards.
+	if buf := msg.GetBuf(); buf != nil {
+		msg = &node.TunnelMsg{Msg: &node.TunnelMsg_Buf{Buf: append([]byte(nil), buf...)}}
+	}
+
+	select {
+	case p.send <- msg:
+		return nil
+	case <-p.ctx.Done():
+		return status.Error(codes.Canceled, "tunnel closed")
+	}
+}
+
+func (p tunnelPipe) Recv() (*node.TunnelMsg, error) {
+	select {
+	case msg := <-p.recv:
+		return msg, nil
+	case <-p.ctx.Done():
+		return nil, status.Error(codes.Canceled, "tunnel closed")
+	}
+}
+
+type fakeTunnelClient struct {
+	grpc.ClientStream
+	tunnelPipe
+}
+
+func (c fakeTunnelClient) CloseSend() error {
+	return nil
+}
+
+type fakeTunnelServer struct {
+	grpc.ServerStream
+	tunnelPipe
+}
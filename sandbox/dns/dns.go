@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"net"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -50,7 +52,54 @@ type dnsTable struct {
 	lister    listers.PodLister
 
 	recordLock sync.Mutex
-	records    map[string]net.IP
+	records    map[string][]record
+	// clients maps the IP of each customer pod to its DNS settings.
+	clients map[string]client
+	// rotation is incremented on each lookup so that responses for services
+	// with multiple replicas cycle through the replicas' IPs.
+	rotation int
+}
+
+// networkSet is a set of network IDs. A nil set represents pods that were
+// deployed before Blimp supported multiple networks, and is treated as
+// being connected to all networks.
+type networkSet map[string]struct{}
+
+func (networks networkSet) intersects(other networkSet) bool {
+	if networks == nil || other == nil {
+		return true
+	}
+
+	for network := range networks {
+		if _, ok := other[network]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// client contains the settings that affect how a customer pod's queries are
+// resolved.
+type client struct {
+	// networks are the networks that the pod is connected to. It's used to
+	// decide which records are visible to the pod.
+	networks networkSet
+
+	// project is the Compose project that the pod is part of. Hostnames
+	// within the project's domain are never resolved externally.
+	project string
+
+	// upstreams are the DNS servers used to resolve external hostnames. If
+	// empty, the DNS server's default resolver is used.
+	upstreams []string
+}
+
+// record is a potential answer for a hostname.
+type record struct {
+	ip    net.IP
+	ready bool
+	// networks are the networks that the record is visible on.
+	networks networkSet
 }
 
 func run(kubeClient kubernetes.Interface, namespace string) {
@@ -81,8 +130,10 @@ func run(kubeClient kubernetes.Interface, namespace string) {
 			table.UpdateTable()
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
-			// Don't bother updating the table if the pod's IP didn't change.
-			if oldObj.(*corev1.Pod).Status.PodIP == newObj.(*corev1.Pod).Status.PodIP {
+			// Don't bother updating the table if the pod's IP and readiness
+			// didn't change.
+			oldPod, newPod := oldObj.(*corev1.Pod), newObj.(*corev1.Pod)
+			if oldPod.Status.PodIP == newPod.Status.PodIP && podIsReady(oldPod) == podIsReady(newPod) {
 				return
 			}
 			table.UpdateTable()
@@ -116,14 +167,21 @@ func (table *dnsTable) UpdateTable() {
 		return
 	}
 
-	records := podsToDNS(pods)
-	table.records = records
+	table.records, table.clients = podsToDNS(pods)
 }
 
 func (table *dnsTable) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
 	defer w.Close()
 
-	resp := table.genResponse(req)
+	var clientIP net.IP
+	switch addr := w.RemoteAddr().(type) {
+	case *net.UDPAddr:
+		clientIP = addr.IP
+	case *net.TCPAddr:
+		clientIP = addr.IP
+	}
+
+	resp := table.genResponse(req, clientIP)
 	if resp == nil {
 		return
 	}
@@ -133,7 +191,7 @@ func (table *dnsTable) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
 	}
 }
 
-func (table *dnsTable) genResponse(req *dns.Msg) *dns.Msg {
+func (table *dnsTable) genResponse(req *dns.Msg, clientIP net.IP) *dns.Msg {
 	resp := &dns.Msg{}
 	if len(req.Question) != 1 {
 		return resp.SetRcode(req, dns.RcodeNotImplemented)
@@ -150,8 +208,16 @@ func (table *dnsTable) genResponse(req *dns.Msg) *dns.Msg {
 		return resp.SetRcode(req, dns.RcodeNotImplemented)
 	}
 
-	ips := table.lookupA(q.Name)
+	ips := table.lookupA(q.Name, clientIP)
 	if len(ips) == 0 {
+		// The project's domain is in the pods' DNS search path, so
+		// resolvers query it for every external hostname before trying the
+		// hostname as is. Tell them that the name doesn't exist so that they
+		// move on immediately rather than waiting for a timeout.
+		if table.inProjectDomain(q.Name, clientIP) {
+			return resp.SetRcode(req, dns.RcodeNameError)
+		}
+
 		// Even though the client asked for a Kelda hostname that we know
 		// nothing about, it's possible we'll learn about it in the future.  For
 		// now, we'll just not respond, the client will time out, and try again
@@ -180,25 +246,40 @@ func (table *dnsTable) genResponse(req *dns.Msg) *dns.Msg {
 	return resp
 }
 
-func (table *dnsTable) lookupA(name string) []net.IP {
+// lookupA returns the IPs for the given hostname. Internal hostnames are only
+// visible to clients that share a network with the service.
+func (table *dnsTable) lookupA(name string, clientIP net.IP) []net.IP {
 	name = strings.TrimRight(strings.ToLower(name), ".")
 
 	// Try to see if it's an internal name first. If not, we'll fallback to
 	// external DNS.
 	table.recordLock.Lock()
-	ip := table.records[name]
+	internalIPs := table.visibleIPs(name, clientIP)
+	table.rotation++
+	rotation := table.rotation
+	var client client
+	if clientIP != nil {
+		client = table.clients[clientIP.String()]
+	}
 	table.recordLock.Unlock()
-	if ip != nil {
-		return []net.IP{ip}
+	if len(internalIPs) != 0 {
+		// Rotate the order of the IPs so that clients that only use the first
+		// record still spread their requests across the replicas.
+		ips := make([]net.IP, 0, len(internalIPs))
+		offset := rotation % len(internalIPs)
+		ips = append(ips, internalIPs[offset:]...)
+		ips = append(ips, internalIPs[:offset]...)
+		return ips
 	}
 
-	if strings.Count(name, ".") == 0 {
+	if strings.Count(name, ".") == 0 ||
+		(client.project != "" && strings.HasSuffix(name, "."+client.project)) {
 		// It's definitely an internal hostname, so don't bother looking it up
 		// externally.
 		return nil
 	}
 
-	ipStrs, err := lookupHost(name)
+	ipStrs, err := lookupHost(client.upstreams, name)
 	if err != nil {
 		log.WithError(err).Debug("Failed to lookup external record: ", name)
 		return nil
@@ -213,6 +294,21 @@ func (table *dnsTable) lookupA(name string) []net.IP {
 	return ips
 }
 
+// inProjectDomain returns whether the hostname is within the domain of the
+// client's Compose project.
+func (table *dnsTable) inProjectDomain(name string, clientIP net.IP) bool {
+	if clientIP == nil {
+		return false
+	}
+
+	table.recordLock.Lock()
+	project := table.clients[clientIP.String()].project
+	table.recordLock.Unlock()
+
+	name = strings.TrimRight(strings.ToLower(name), ".")
+	return project != "" && strings.HasSuffix(name, "."+project)
+}
+
 func makeTable(namespace string, lister listers.PodLister) *dnsTable {
 	tbl := &dnsTable{
 		namespace: namespace,
@@ -226,32 +322,150 @@ func makeTable(namespace string, lister listers.PodLister) *dnsTable {
 	return tbl
 }
 
-func podsToDNS(pods []*corev1.Pod) map[string]net.IP {
-	records := map[string]net.IP{}
+// visibleIPs returns the IPs for the given internal hostname that are
+// visible to the client. The caller must hold recordLock.
+func (table *dnsTable) visibleIPs(name string, clientIP net.IP) []net.IP {
+	// Clients that aren't customer pods can see all records.
+	var clientNetworks networkSet
+	if clientIP != nil {
+		clientNetworks = table.clients[clientIP.String()].networks
+	}
+
+	var readyIPs, allIPs []net.IP
+	for _, record := range table.records[name] {
+		if !record.networks.intersects(clientNetworks) {
+			continue
+		}
+
+		allIPs = append(allIPs, record.ip)
+		if record.ready {
+			readyIPs = append(readyIPs, record.ip)
+		}
+	}
+
+	// Only respond with the ready replicas. If none of the replicas are
+	// ready, fall back to responding with all of them so that services
+	// that are failing their healthchecks are still reachable.
+	if len(readyIPs) != 0 {
+		return readyIPs
+	}
+	return allIPs
+}
+
+func podsToDNS(pods []*corev1.Pod) (map[string][]record, map[string]client) {
+	// Sort the pods so that the records are in a consistent order.
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+
+	records := map[string][]record{}
+	clients := map[string]client{}
 	for _, pod := range pods {
 		ip := net.ParseIP(pod.Status.PodIP)
 		if ip == nil {
 			continue
 		}
 
-		serviceName := pod.Labels["blimp.service"]
-		records[strings.ToLower(serviceName)] = ip
+		var networks networkSet
+		if ids := metadata.ParseNetworks(pod.Labels); len(ids) != 0 {
+			networks = networkSet{}
+			for _, id := range ids {
+				networks[id] = struct{}{}
+			}
+		}
+
+		var upstreams []string
+		if servers, ok := pod.Annotations[metadata.DNSServersKey]; ok {
+			upstreams = strings.Split(servers, ",")
+		}
 
-		// Add aliases to DNS.
-		aliases, ok := pod.Annotations[metadata.AliasesKey]
-		if !ok {
-			continue
+		project := strings.ToLower(pod.Annotations[metadata.ProjectKey])
+		clients[ip.String()] = client{
+			networks:  networks,
+			project:   project,
+			upstreams: upstreams,
+		}
+
+		// Hostnames are resolvable both by themselves, and within the
+		// project's domain.
+		addRecord := func(hostname string, r record) {
+			hostname = strings.ToLower(hostname)
+			records[hostname] = append(records[hostname], r)
+			if project != "" {
+				records[hostname+"."+project] = append(records[hostname+"."+project], r)
+			}
+		}
+
+		// The service name and global aliases are visible on all of the
+		// pod's networks.
+		hostnames := []string{pod.Labels["blimp.service"]}
+		if aliases, ok := pod.Annotations[metadata.AliasesKey]; ok {
+			hostnames = append(hostnames, metadata.ParseAliases(aliases)...)
 		}
+		for _, hostname := range hostnames {
+			addRecord(hostname, record{ip: ip, ready: podIsReady(pod), networks: networks})
+		}
+
+		if aliasesStr, ok := pod.Annotations[metadata.NetworkAliasesKey]; ok {
+			networkAliases, err := metadata.ParseNetworkAliases(aliasesStr)
+			if err != nil {
+				log.WithError(err).WithField("pod", pod.Name).Warn("Failed to parse network aliases")
+			}
+
+			for network, aliases := range networkAliases {
+				for _, alias := range aliases {
+					addRecord(alias, record{
+						ip:       ip,
+						ready:    podIsReady(pod),
+						networks: networkSet{network: struct{}{}},
+					})
+				}
+			}
+		}
+	}
+	return records, clients
+}
 
-		for _, alias := range metadata.ParseAliases(aliases) {
-			records[strings.ToLower(alias)] = ip
+func podIsReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+			return true
 		}
 	}
-	return records
+	return false
 }
 
 var listenAndServe = func(table *dnsTable) error {
 	return table.server.ListenAndServe()
 }
 
-var lookupHost = net.LookupHost
+// lookupHost resolves the given external hostname using the given upstream
+// DNS servers. If no upstreams are provided, the system resolver is used.
+var lookupHost = func(upstreams []string, host string) ([]string, error) {
+	if len(upstreams) == 0 {
+		return net.LookupHost(host)
+	}
+
+	// Try the upstreams in order, like the resolver in the pod would if it
+	// was configured with the servers directly.
+	var err error
+	for _, upstream := range upstreams {
+		upstream := upstream
+		resolver := &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, upstream)
+			},
+		}
+
+		var addrs []string
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		addrs, err = resolver.LookupHost(ctx, host)
+		cancel()
+		if err == nil {
+			return addrs, nil
+		}
+	}
+	return nil, err
+}
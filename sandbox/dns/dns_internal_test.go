@@ -4,23 +4,27 @@ import (
 	"net"
 	"testing"
 
+	"github.com/miekg/dns"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/kelda/blimp/pkg/errors"
+	"github.com/kelda/blimp/pkg/metadata"
 )
 
 func TestLookupA(t *testing.T) {
 	tests := []struct {
 		name               string
-		records            map[string]net.IP
+		records            map[string][]record
 		req                string
 		expIPs             []net.IP
-		lookupExternalHost func(string) ([]string, error)
+		lookupExternalHost func([]string, string) ([]string, error)
 	}{
 		{
 			name: "internal hostname",
-			records: map[string]net.IP{
-				"host": net.IPv4(8, 8, 8, 8),
+			records: map[string][]record{
+				"host": {{ip: net.IPv4(8, 8, 8, 8), ready: true}},
 			},
 			req: "host.",
 			expIPs: []net.IP{
@@ -29,8 +33,8 @@ func TestLookupA(t *testing.T) {
 		},
 		{
 			name: "internal with tld",
-			records: map[string]net.IP{
-				"dev.kelda": net.IPv4(8, 8, 8, 8),
+			records: map[string][]record{
+				"dev.kelda": {{ip: net.IPv4(8, 8, 8, 8), ready: true}},
 			},
 			req: "dev.kelda.",
 			expIPs: []net.IP{
@@ -39,14 +43,14 @@ func TestLookupA(t *testing.T) {
 		},
 		{
 			name: "external hostname",
-			records: map[string]net.IP{
-				"does-not-match": net.IPv4(8, 8, 8, 8),
+			records: map[string][]record{
+				"does-not-match": {{ip: net.IPv4(8, 8, 8, 8), ready: true}},
 			},
 			req: "google.com.",
 			expIPs: []net.IP{
 				net.IPv4(9, 9, 9, 9),
 			},
-			lookupExternalHost: func(host string) ([]string, error) {
+			lookupExternalHost: func(_ []string, host string) ([]string, error) {
 				if host == "google.com" {
 					return []string{"9.9.9.9"}, nil
 				}
@@ -55,15 +59,15 @@ func TestLookupA(t *testing.T) {
 		},
 		{
 			name: "external hostname with multiple IPs",
-			records: map[string]net.IP{
-				"does-not-match": net.IPv4(8, 8, 8, 8),
+			records: map[string][]record{
+				"does-not-match": {{ip: net.IPv4(8, 8, 8, 8), ready: true}},
 			},
 			req: "google.com.",
 			expIPs: []net.IP{
 				net.IPv4(9, 9, 9, 9),
 				net.IPv4(10, 10, 10, 10),
 			},
-			lookupExternalHost: func(host string) ([]string, error) {
+			lookupExternalHost: func(_ []string, host string) ([]string, error) {
 				if host == "google.com" {
 					return []string{"9.9.9.9", "10.10.10.10"}, nil
 				}
@@ -75,6 +79,207 @@ func TestLookupA(t *testing.T) {
 	for _, test := range tests {
 		lookupHost = test.lookupExternalHost
 		tbl := dnsTable{records: test.records}
-		assert.Equal(t, test.expIPs, tbl.lookupA(test.req), test.name)
+		assert.Equal(t, test.expIPs, tbl.lookupA(test.req, nil), test.name)
 	}
 }
+
+func TestLookupARotation(t *testing.T) {
+	replicas := []net.IP{
+		net.IPv4(8, 8, 8, 8),
+		net.IPv4(9, 9, 9, 9),
+		net.IPv4(10, 10, 10, 10),
+	}
+	var records []record
+	for _, ip := range replicas {
+		records = append(records, record{ip: ip, ready: true})
+	}
+	tbl := dnsTable{records: map[string][]record{"web": records}}
+
+	// Each replica should be returned first exactly once over the course of
+	// len(replicas) lookups, and every response should contain all replicas.
+	firsts := map[string]struct{}{}
+	for i := 0; i < len(replicas); i++ {
+		ips := tbl.lookupA("web.", nil)
+		assert.ElementsMatch(t, replicas, ips)
+		firsts[ips[0].String()] = struct{}{}
+	}
+	assert.Len(t, firsts, len(replicas))
+}
+
+func TestLookupANetworks(t *testing.T) {
+	frontendIP := net.IPv4(10, 0, 0, 1)
+	backendIP := net.IPv4(10, 0, 0, 2)
+	proxyIP := net.IPv4(10, 0, 0, 3)
+	legacyIP := net.IPv4(10, 0, 0, 4)
+
+	frontend := networkSet{"frontend": {}}
+	backend := networkSet{"backend": {}}
+	tbl := dnsTable{
+		records: map[string][]record{
+			"web":   {{ip: frontendIP, ready: true, networks: frontend}},
+			"db":    {{ip: backendIP, ready: true, networks: backend}},
+			"proxy": {{ip: proxyIP, ready: true, networks: networkSet{"frontend": {}, "backend": {}}}},
+			"database": {
+				{ip: backendIP, ready: true, networks: backend},
+			},
+		},
+		clients: map[string]client{
+			frontendIP.String(): {networks: frontend},
+			backendIP.String():  {networks: backend},
+			proxyIP.String():    {networks: networkSet{"frontend": {}, "backend": {}}},
+			legacyIP.String():   {},
+		},
+	}
+	lookupHost = func([]string, string) ([]string, error) {
+		return nil, errors.New("unknown host")
+	}
+
+	tests := []struct {
+		name     string
+		clientIP net.IP
+		req      string
+		expIPs   []net.IP
+	}{
+		{
+			name:     "same network",
+			clientIP: frontendIP,
+			req:      "web.",
+			expIPs:   []net.IP{frontendIP},
+		},
+		{
+			name:     "different network",
+			clientIP: frontendIP,
+			req:      "db.",
+		},
+		{
+			name:     "network alias on different network",
+			clientIP: frontendIP,
+			req:      "database.",
+		},
+		{
+			name:     "service on multiple networks",
+			clientIP: backendIP,
+			req:      "proxy.",
+			expIPs:   []net.IP{proxyIP},
+		},
+		{
+			name:     "client on multiple networks",
+			clientIP: proxyIP,
+			req:      "db.",
+			expIPs:   []net.IP{backendIP},
+		},
+		{
+			name:     "pod without networks",
+			clientIP: legacyIP,
+			req:      "db.",
+			expIPs:   []net.IP{backendIP},
+		},
+		{
+			name:     "non-customer pod",
+			clientIP: net.IPv4(10, 0, 0, 5),
+			req:      "db.",
+			expIPs:   []net.IP{backendIP},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expIPs, tbl.lookupA(test.req, test.clientIP), test.name)
+	}
+}
+
+func TestLookupAClientSettings(t *testing.T) {
+	webIP := net.IPv4(10, 0, 0, 1)
+	customDNSIP := net.IPv4(10, 0, 0, 2)
+
+	pods := []*corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "web",
+				Labels: map[string]string{"blimp.service": "web"},
+				Annotations: map[string]string{
+					metadata.ProjectKey: "myapp",
+				},
+			},
+			Status: corev1.PodStatus{PodIP: webIP.String()},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "custom-dns",
+				Labels: map[string]string{"blimp.service": "custom-dns"},
+				Annotations: map[string]string{
+					metadata.ProjectKey:    "myapp",
+					metadata.DNSServersKey: "1.1.1.1:53,8.8.8.8:53",
+				},
+			},
+			Status: corev1.PodStatus{PodIP: customDNSIP.String()},
+		},
+	}
+
+	records, clients := podsToDNS(pods)
+	tbl := dnsTable{records: records, clients: clients}
+
+	var lookedUpWith []string
+	lookupHost = func(upstreams []string, host string) ([]string, error) {
+		lookedUpWith = upstreams
+		if host == "google.com" {
+			return []string{"9.9.9.9"}, nil
+		}
+		return nil, errors.New("unknown host")
+	}
+
+	// Services are resolvable within the project's domain.
+	assert.Equal(t, []net.IP{webIP}, tbl.lookupA("web.myapp.", customDNSIP))
+
+	// Unknown hostnames within the project's domain aren't resolved
+	// externally.
+	lookedUpWith = nil
+	assert.Empty(t, tbl.lookupA("db.myapp.", customDNSIP))
+	assert.Nil(t, lookedUpWith)
+
+	// External hostnames are resolved with the client's upstream servers.
+	assert.Equal(t, []net.IP{net.IPv4(9, 9, 9, 9)}, tbl.lookupA("google.com.", customDNSIP))
+	assert.Equal(t, []string{"1.1.1.1:53", "8.8.8.8:53"}, lookedUpWith)
+
+	assert.Equal(t, []net.IP{net.IPv4(9, 9, 9, 9)}, tbl.lookupA("google.com.", webIP))
+	assert.Empty(t, lookedUpWith)
+}
+
+func TestGenResponse(t *testing.T) {
+	webIP := net.IPv4(10, 0, 0, 1)
+	pods := []*corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "web",
+				Labels:      map[string]string{"blimp.service": "web"},
+				Annotations: map[string]string{metadata.ProjectKey: "myapp"},
+			},
+			Status: corev1.PodStatus{PodIP: webIP.String()},
+		},
+	}
+	records, clients := podsToDNS(pods)
+	tbl := dnsTable{records: records, clients: clients}
+
+	lookupHost = func(_ []string, host string) ([]string, error) {
+		return nil, errors.New("unknown host")
+	}
+
+	query := func(name string) *dns.Msg {
+		req := &dns.Msg{}
+		req.SetQuestion(name, dns.TypeA)
+		return tbl.genResponse(req, webIP)
+	}
+
+	resp := query("web.myapp.")
+	assert.Equal(t, dns.RcodeSuccess, resp.Rcode)
+	assert.Len(t, resp.Answer, 1)
+
+	// Unknown names within the project's domain don't exist, so that
+	// resolvers move on to the next search domain.
+	assert.Equal(t, dns.RcodeNameError, query("db.myapp.").Rcode)
+	assert.Equal(t, dns.RcodeNameError, query("google.com.myapp.").Rcode)
+
+	// Other unknown names aren't answered, since they might be deployed
+	// later.
+	assert.Nil(t, query("db."))
+	assert.Nil(t, query("unknown.example.com."))
+}
@@ -0,0 +1,228 @@
+// tini is a minimal init process that is injected into service containers.
+//
+// When run as `blimp-tini -- <command>`, it runs the command as a child
+// process, forwards signals to it, and reaps any zombie processes. It exits
+// with the same exit code as the command. This is equivalent to Docker's
+// `init: true`.
+//
+// When run as `blimp-tini -signal <signal>`, it sends the signal to PID 1, and
+// then blocks until the container is stopped. It's used as a preStop hook to
+// implement `stop_signal`, since Kubernetes always stops containers with
+// SIGTERM.
+//
+// The `-rlimit <name>=<soft>:<hard>` flag sets a resource limit before
+// running the command, which implements `ulimits`. With `-exec`, blimp-tini
+// replaces itself with the command once the limits are set, rather than
+// running the command as a child process.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func main() {
+	stopSignal := flag.String("signal", "", "send the given signal to PID 1, and wait for the container to stop")
+	stopPid := flag.Int("pid", 1, "the process to send the stop signal to")
+	execCommand := flag.Bool("exec", false, "replace blimp-tini with the command rather than running it as a child")
+	var rlimits rlimitFlags
+	flag.Var(&rlimits, "rlimit", "set a resource limit, in the format <name>=<soft>:<hard> (may be repeated)")
+	flag.Parse()
+
+	if *stopSignal != "" {
+		if err := sendStopSignal(*stopPid, *stopSignal); err != nil {
+			fmt.Fprintf(os.Stderr, "blimp-tini: %s\n", err)
+			os.Exit(1)
+		}
+
+		// Block until the container is stopped so that Kubernetes doesn't
+		// send SIGTERM until the grace period expires. We can't use an empty
+		// select since the Go runtime would exit with a deadlock error, as
+		// there aren't any other goroutines.
+		for {
+			time.Sleep(time.Hour)
+		}
+	}
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: blimp-tini [-rlimit <name>=<soft>:<hard>] [-exec] -- <command> [args...]")
+		os.Exit(1)
+	}
+
+	// The limits are inherited by the command.
+	for _, rlimit := range rlimits {
+		if err := unix.Setrlimit(rlimit.resource, &rlimit.limit); err != nil {
+			fmt.Fprintf(os.Stderr, "blimp-tini: failed to set ulimit %s: %s\n", rlimit.name, err)
+			os.Exit(1)
+		}
+	}
+
+	if *execCommand {
+		os.Exit(execute(flag.Args()))
+	}
+	os.Exit(runInit(flag.Args()))
+}
+
+// rlimitResources maps the ulimit names used by Docker to the resources.
+var rlimitResources = map[string]int{
+	"as":      unix.RLIMIT_AS,
+	"core":    unix.RLIMIT_CORE,
+	"cpu":     unix.RLIMIT_CPU,
+	"data":    unix.RLIMIT_DATA,
+	"fsize":   unix.RLIMIT_FSIZE,
+	"memlock": unix.RLIMIT_MEMLOCK,
+	"nofile":  unix.RLIMIT_NOFILE,
+	"nproc":   unix.RLIMIT_NPROC,
+	"stack":   unix.RLIMIT_STACK,
+}
+
+type rlimit struct {
+	name     string
+	resource int
+	limit    unix.Rlimit
+}
+
+// rlimitFlags collects the values of the repeated -rlimit flag.
+type rlimitFlags []rlimit
+
+func (flags *rlimitFlags) String() string {
+	var strs []string
+	for _, rlimit := range *flags {
+		strs = append(strs, rlimit.name)
+	}
+	return strings.Join(strs, ",")
+}
+
+func (flags *rlimitFlags) Set(value string) error {
+	rlimit, err := parseRlimit(value)
+	if err != nil {
+		return err
+	}
+	*flags = append(*flags, rlimit)
+	return nil
+}
+
+// parseRlimit parses a limit in the format <name>=<soft>:<hard>. Negative
+// values mean that the resource is unlimited.
+func parseRlimit(value string) (rlimit, error) {
+	nameAndLimits := strings.SplitN(value, "=", 2)
+	if len(nameAndLimits) != 2 {
+		return rlimit{}, fmt.Errorf("invalid rlimit %q: expected <name>=<soft>:<hard>", value)
+	}
+
+	name := nameAndLimits[0]
+	resource, ok := rlimitResources[name]
+	if !ok {
+		return rlimit{}, fmt.Errorf("unknown rlimit %q", name)
+	}
+
+	limits := strings.SplitN(nameAndLimits[1], ":", 2)
+	if len(limits) != 2 {
+		return rlimit{}, fmt.Errorf("invalid rlimit %q: expected <name>=<soft>:<hard>", value)
+	}
+
+	var parsed [2]uint64
+	for i, limit := range limits {
+		num, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return rlimit{}, fmt.Errorf("invalid rlimit %q: %s", value, err)
+		}
+
+		if num < 0 {
+			parsed[i] = unix.RLIM_INFINITY
+		} else {
+			parsed[i] = uint64(num)
+		}
+	}
+
+	return rlimit{
+		name:     name,
+		resource: resource,
+		limit:    unix.Rlimit{Cur: parsed[0], Max: parsed[1]},
+	}, nil
+}
+
+// execute replaces the current process with the given command. It only
+// returns if the command can't be run.
+func execute(args []string) int {
+	path, err := exec.LookPath(args[0])
+	if err == nil {
+		err = syscall.Exec(path, args, os.Environ())
+	}
+	fmt.Fprintf(os.Stderr, "blimp-tini: failed to run %s: %s\n", args[0], err)
+	return 127
+}
+
+func sendStopSignal(pid int, sigStr string) error {
+	sig, err := parseSignal(sigStr)
+	if err != nil {
+		return err
+	}
+	return syscall.Kill(pid, sig)
+}
+
+func parseSignal(sigStr string) (syscall.Signal, error) {
+	if num, err := strconv.Atoi(sigStr); err == nil {
+		return syscall.Signal(num), nil
+	}
+
+	sig := unix.SignalNum(sigStr)
+	if sig == 0 {
+		return 0, fmt.Errorf("unknown signal %q", sigStr)
+	}
+	return sig, nil
+}
+
+// runInit runs the given command, and returns its exit code.
+func runInit(args []string) int {
+	// Start listening for signals before starting the child so that none are
+	// dropped.
+	signals := make(chan os.Signal, 32)
+	signal.Notify(signals)
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "blimp-tini: failed to start %s: %s\n", args[0], err)
+		return 127
+	}
+	childPid := cmd.Process.Pid
+
+	for sig := range signals {
+		if sig != syscall.SIGCHLD {
+			// Forward the signal. The child may have already exited, in which
+			// case there's nothing to do.
+			_ = cmd.Process.Signal(sig)
+			continue
+		}
+
+		// Reap all exited processes, including orphaned processes that were
+		// reparented to us.
+		for {
+			var status syscall.WaitStatus
+			pid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+			if err != nil || pid <= 0 {
+				break
+			}
+
+			if pid == childPid {
+				if status.Signaled() {
+					return 128 + int(status.Signal())
+				}
+				return status.ExitStatus()
+			}
+		}
+	}
+	return 0
+}
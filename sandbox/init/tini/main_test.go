@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestStopSignalBlocks(t *testing.T) {
+	// Build the real binary rather than re-executing the test binary, since
+	// the test binary's extra goroutines would hide deadlocks.
+	dir, err := ioutil.TempDir("", "blimp-tini")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	binary := filepath.Join(dir, "blimp-tini")
+	output, err := exec.Command("go", "build", "-o", binary, ".").CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	// Signal 0 doesn't do anything, but still checks that the process can be
+	// signaled.
+	cmd := exec.Command(binary, "-signal", "0", "-pid", strconv.Itoa(os.Getpid()))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	require.NoError(t, cmd.Start())
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	// The hook should keep running until it's killed, since Kubernetes
+	// sends SIGTERM as soon as the hook exits.
+	select {
+	case err := <-exited:
+		t.Fatalf("blimp-tini exited before it was killed: %v\n%s", err, stderr.String())
+	case <-time.After(time.Second):
+	}
+
+	require.NoError(t, cmd.Process.Kill())
+	err = <-exited
+	exitErr, ok := err.(*exec.ExitError)
+	require.True(t, ok, "unexpected error: %v", err)
+	status := exitErr.Sys().(syscall.WaitStatus)
+	assert.True(t, status.Signaled())
+	assert.Equal(t, syscall.SIGKILL, status.Signal())
+}
+
+func TestParseRlimit(t *testing.T) {
+	tests := []struct {
+		value  string
+		exp    rlimit
+		expErr bool
+	}{
+		{
+			value: "nofile=20000:40000",
+			exp: rlimit{
+				name:     "nofile",
+				resource: unix.RLIMIT_NOFILE,
+				limit:    unix.Rlimit{Cur: 20000, Max: 40000},
+			},
+		},
+		{
+			value: "core=-1:-1",
+			exp: rlimit{
+				name:     "core",
+				resource: unix.RLIMIT_CORE,
+				limit:    unix.Rlimit{Cur: unix.RLIM_INFINITY, Max: unix.RLIM_INFINITY},
+			},
+		},
+		{value: "nofile=20000", expErr: true},
+		{value: "nofile", expErr: true},
+		{value: "nofile=soft:hard", expErr: true},
+		{value: "notreal=1:1", expErr: true},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.value, func(t *testing.T) {
+			parsed, err := parseRlimit(test.value)
+			if test.expErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.exp, parsed)
+		})
+	}
+}